@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/quavious/blog-factory-server/auth"
+	"github.com/quavious/blog-factory-server/bookmarks"
+	"github.com/quavious/blog-factory-server/comments"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/feeds"
+	"github.com/quavious/blog-factory-server/jobs"
+	"github.com/quavious/blog-factory-server/mail"
+	md "github.com/quavious/blog-factory-server/middleware"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/posts"
+	"github.com/quavious/blog-factory-server/reactions"
+	"github.com/quavious/blog-factory-server/reports"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/sitemap"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/tags"
+	"github.com/quavious/blog-factory-server/users"
+	"github.com/quavious/blog-factory-server/webhooks"
+)
+
+// newApp wires every controller to a new Echo server, and the handlers of the jobs to the queue,
+// whose workers are started by the caller. The database is expected to be migrated.
+func newApp(config *config.Config, repository *db.Repository, mailClient *mail.MailClient, queue *jobs.Queue) *echo.Echo {
+	index := search.NewIndex()
+	err := index.Load(repository)
+	if err != nil {
+		log.Println("error: loading the search index is failed.")
+	}
+	stores := store.NewSQLStore(repository)
+	jwtMiddleware := md.NewJWTMiddleware(config)
+	optionalJWTMiddleware := md.NewOptionalJWTMiddleware(config)
+	corsMiddleware := md.NewCORSMiddleware()
+	adminMiddleware := md.NewAdminMiddleware(repository)
+	mailClient.UseQueue(queue)
+	queue.Handle(notifications.JobDeliver, notifications.NewNotificationsService(config, repository, mailClient).Deliver)
+	queue.Handle(webhooks.JobDeliver, webhooks.NewWebhooksService(config, repository).Deliver)
+	e := echo.New()
+	e.IPExtractor = md.NewIPExtractor(config)
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, echo.Map{
+			"message": "Hello Go!",
+		})
+	})
+	e.Use(middleware.Recover())
+	e.Use(*corsMiddleware)
+	// e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+	// 	return func(c echo.Context) error {
+	// 		c.Response().Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
+	// 		return next(c)
+	// 	}
+	// })
+	authController := auth.NewAuthController(e, config, stores, &jwtMiddleware, mailClient)
+	usersController := users.NewUsersController(e, config, repository, stores, &jwtMiddleware, &adminMiddleware, mailClient)
+	postsController := posts.NewPostsController(e, config, repository, stores, &jwtMiddleware, &optionalJWTMiddleware, &adminMiddleware, index, mailClient)
+	commentsController := comments.NewCommentsController(e, config, repository, stores, &jwtMiddleware, &optionalJWTMiddleware, &adminMiddleware, mailClient)
+	tagsController := tags.NewTagsController(e, config, repository, &jwtMiddleware, &adminMiddleware, index)
+	notificationsController := notifications.NewNotificationsController(e, config, repository, &jwtMiddleware, &adminMiddleware, mailClient)
+	reactionsController := reactions.NewReactionsController(e, config, repository, &jwtMiddleware)
+	bookmarksController := bookmarks.NewBookmarksController(e, config, repository, stores, &jwtMiddleware, index, mailClient)
+	reportsController := reports.NewReportsController(e, config, repository, stores, &jwtMiddleware, &adminMiddleware, index, mailClient)
+	mailController := mail.NewMailController(e, &jwtMiddleware, &adminMiddleware, mailClient)
+	jobsController := jobs.NewJobsController(e, config, repository, &jwtMiddleware, &adminMiddleware)
+	webhooksController := webhooks.NewWebhooksController(e, config, repository, &jwtMiddleware, &adminMiddleware)
+	feedsController := feeds.NewFeedsController(e, config, repository, stores, index, mailClient)
+	sitemapController := sitemap.NewSitemapController(e, config, repository, index)
+
+	authController.UseRoute()
+	usersController.UseRoute()
+	postsController.UseRoute()
+	commentsController.UseRoute()
+	tagsController.UseRoute()
+	reportsController.UseRoute()
+	notificationsController.UseRoute()
+	reactionsController.UseRoute()
+	bookmarksController.UseRoute()
+	mailController.UseRoute()
+	jobsController.UseRoute()
+	webhooksController.UseRoute()
+	feedsController.UseRoute()
+	sitemapController.UseRoute()
+	return e
+}
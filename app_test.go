@@ -0,0 +1,1068 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/webhooks"
+)
+
+type postResponse struct {
+	Post *struct {
+		ID            int      `json:"id"`
+		Title         string   `json:"title"`
+		Content       string   `json:"content"`
+		Username      string   `json:"username"`
+		Tags          []string `json:"tags"`
+		CommentCount  int      `json:"commentCount"`
+		CommentStatus string   `json:"commentStatus"`
+	} `json:"post"`
+}
+
+type commentResponse struct {
+	ID       int    `json:"id"`
+	Content  string `json:"content"`
+	Username string `json:"username"`
+	ParentID *int   `json:"parentId"`
+	Depth    int    `json:"depth"`
+	Deleted  bool   `json:"deleted"`
+	Status   string `json:"status"`
+}
+
+func TestAuthFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	signUp := echo.Map{
+		"email":           "alice@example.com",
+		"username":        "alice",
+		"password":        "password",
+		"passwordConfirm": "password",
+	}
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/sign-up", signUp, nil)
+
+	token := app.requestEmailToken("alice@example.com")
+	if subject := app.lastMail("alice@example.com").Subject; subject != "Email Verification" {
+		t.Errorf("the verification mail has the subject %q", subject)
+	}
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/email-verification", echo.Map{"email": "alice@example.com", "token": token + "0"}, nil)
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/email-verification", echo.Map{"email": "alice@example.com", "token": token}, nil)
+
+	signUp["passwordConfirm"] = "different"
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/sign-up", signUp, nil)
+	signUp["passwordConfirm"] = "password"
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/sign-up", signUp, nil)
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/sign-up", signUp, nil)
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/email-token", echo.Map{"email": "alice@example.com"}, nil)
+
+	alice := &testUser{Email: "alice@example.com", Username: "alice", Password: "wrong"}
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/sign-in", echo.Map{"email": alice.Email, "password": alice.Password}, nil)
+	alice.Password = "password"
+	app.signIn(alice)
+	if len(alice.AccessToken) == 0 || len(alice.RefreshToken) == 0 {
+		t.Fatal("signing in returned no tokens")
+	}
+
+	var refreshed struct {
+		AccessToken string `json:"accessToken"`
+	}
+	app.expect(http.StatusOK, alice, http.MethodGet, "/auth/token-verification", nil, &refreshed)
+	if len(refreshed.AccessToken) == 0 {
+		t.Error("the token verification returned no access token")
+	}
+
+	app.expect(http.StatusForbidden, nil, http.MethodPost, "/auth/password-modification", echo.Map{}, nil)
+	app.expect(http.StatusBadRequest, alice, http.MethodPost, "/auth/password-modification", echo.Map{
+		"currentPassword":    "wrong",
+		"newPassword":        "new-password",
+		"newPasswordConfirm": "new-password",
+	}, nil)
+	app.expect(http.StatusOK, alice, http.MethodPost, "/auth/password-modification", echo.Map{
+		"currentPassword":    "password",
+		"newPassword":        "new-password",
+		"newPasswordConfirm": "new-password",
+	}, nil)
+	app.expect(http.StatusBadRequest, nil, http.MethodPost, "/auth/sign-in", echo.Map{"email": alice.Email, "password": "password"}, nil)
+	alice.Password = "new-password"
+	app.signIn(alice)
+
+	app.expect(http.StatusForbidden, nil, http.MethodPost, "/auth/sign-out", nil, nil)
+	app.expect(http.StatusOK, alice, http.MethodPost, "/auth/sign-out", nil, nil)
+}
+
+func TestPostsFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	reader := app.signUp("reader")
+
+	app.expect(http.StatusForbidden, nil, http.MethodPost, "/posts", echo.Map{"title": "Title"}, nil)
+	app.expect(http.StatusForbidden, reader, http.MethodPost, "/posts", echo.Map{"title": "Title"}, nil)
+	postID := app.createPost(admin, "First Post", "Go", "Testing")
+
+	var post postResponse
+	app.expect(http.StatusOK, nil, http.MethodGet, postPath(postID), nil, &post)
+	if post.Post == nil || post.Post.Title != "First Post" || post.Post.Username != "admin" {
+		t.Fatalf("got the post %+v", post.Post)
+	}
+	if fmt.Sprint(post.Post.Tags) != "[go testing]" {
+		t.Errorf("got the tags %v", post.Post.Tags)
+	}
+	if post.Post.CommentStatus != "open" {
+		t.Errorf("got the comment status %q", post.Post.CommentStatus)
+	}
+
+	app.createPost(admin, "Second Post", "go")
+	var list struct {
+		Posts []struct {
+			ID    int    `json:"id"`
+			Title string `json:"title"`
+		} `json:"posts"`
+	}
+	app.expect(http.StatusOK, reader, http.MethodGet, "/posts", nil, &list)
+	if len(list.Posts) != 2 || list.Posts[0].Title != "Second Post" || list.Posts[1].ID != postID {
+		t.Errorf("got the posts %+v", list.Posts)
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, "/posts/tag/go", nil, &list)
+	if len(list.Posts) != 2 {
+		t.Errorf("got %d posts of the tag, want 2", len(list.Posts))
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, "/posts/tag/testing", nil, &list)
+	if len(list.Posts) != 1 || list.Posts[0].ID != postID {
+		t.Errorf("got the posts of the tag %+v", list.Posts)
+	}
+
+	update := echo.Map{"title": "Edited Post", "description": "Edited", "content": "Edited content", "tags": []string{"go"}}
+	other := app.signUpAdmin("other")
+	app.expect(http.StatusForbidden, reader, http.MethodPut, postPath(postID), update, nil)
+	app.expect(http.StatusForbidden, other, http.MethodPut, postPath(postID), update, nil)
+	app.expect(http.StatusCreated, admin, http.MethodPut, postPath(postID), update, nil)
+	app.expect(http.StatusOK, nil, http.MethodGet, postPath(postID), nil, &post)
+	if post.Post == nil || post.Post.Title != "Edited Post" || post.Post.Content != "Edited content" {
+		t.Fatalf("got the edited post %+v", post.Post)
+	}
+	if fmt.Sprint(post.Post.Tags) != "[go]" {
+		t.Errorf("got the edited tags %v", post.Post.Tags)
+	}
+
+	app.expect(http.StatusForbidden, other, http.MethodDelete, postPath(postID), nil, nil)
+	app.expect(http.StatusCreated, admin, http.MethodDelete, postPath(postID), nil, nil)
+	app.expect(http.StatusOK, nil, http.MethodGet, postPath(postID), nil, &post)
+	if post.Post != nil {
+		t.Errorf("the deleted post is %+v", post.Post)
+	}
+	app.expect(http.StatusNotFound, admin, http.MethodDelete, postPath(postID), nil, nil)
+}
+
+func TestCommentsFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	bob := app.signUp("bob")
+	postID := app.createPost(admin, "Commented Post")
+	commentsPath := fmt.Sprintf("/posts/%d/comments", postID)
+
+	app.expect(http.StatusForbidden, nil, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Hello"}, nil)
+	app.expect(http.StatusBadRequest, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": ""}, nil)
+	app.expect(http.StatusNotFound, alice, http.MethodPost, "/comments", echo.Map{"postId": postID + 100, "content": "Hello"}, nil)
+
+	var created struct {
+		Comment commentResponse `json:"comment"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "First comment"}, &created)
+	parent := created.Comment
+	if parent.Username != "alice" || parent.Status != "approved" || parent.Depth != 0 {
+		t.Fatalf("got the comment %+v", parent)
+	}
+	app.expect(http.StatusCreated, bob, http.MethodPost, "/comments", echo.Map{"postId": postID, "parentId": parent.ID, "content": "A reply"}, &created)
+	reply := created.Comment
+	if reply.ParentID == nil || *reply.ParentID != parent.ID || reply.Depth != 1 {
+		t.Fatalf("got the reply %+v", reply)
+	}
+
+	var list struct {
+		Comments []commentResponse `json:"comments"`
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, commentsPath, nil, &list)
+	if len(list.Comments) != 2 || list.Comments[0].ID != parent.ID || list.Comments[1].ID != reply.ID {
+		t.Fatalf("got the comments %+v", list.Comments)
+	}
+	var post postResponse
+	app.expect(http.StatusOK, nil, http.MethodGet, postPath(postID), nil, &post)
+	if post.Post == nil || post.Post.CommentCount != 2 {
+		t.Errorf("got the post %+v", post.Post)
+	}
+
+	commentPath := fmt.Sprintf("/comments/%d", parent.ID)
+	app.expect(http.StatusForbidden, bob, http.MethodPut, commentPath, echo.Map{"postId": postID, "content": "Not mine"}, nil)
+	app.expect(http.StatusCreated, alice, http.MethodPut, commentPath, echo.Map{"postId": postID, "content": "Edited comment"}, &created)
+	if created.Comment.Content != "Edited comment" {
+		t.Errorf("got the edited comment %+v", created.Comment)
+	}
+
+	app.expect(http.StatusForbidden, bob, http.MethodDelete, commentPath, echo.Map{"postId": postID}, nil)
+	app.expect(http.StatusOK, alice, http.MethodDelete, commentPath, echo.Map{"postId": postID}, nil)
+	app.expect(http.StatusOK, nil, http.MethodGet, commentsPath, nil, &list)
+	if len(list.Comments) != 2 || !list.Comments[0].Deleted || list.Comments[0].Content == "Edited comment" {
+		t.Fatalf("the comment with a reply is not kept as deleted: %+v", list.Comments)
+	}
+	app.expect(http.StatusNotFound, alice, http.MethodPut, commentPath, echo.Map{"postId": postID, "content": "Again"}, nil)
+
+	app.expect(http.StatusOK, bob, http.MethodDelete, fmt.Sprintf("/comments/%d", reply.ID), echo.Map{"postId": postID}, nil)
+	app.expect(http.StatusOK, nil, http.MethodGet, commentsPath, nil, &list)
+	if len(list.Comments) != 0 {
+		t.Errorf("the deleted comment is kept without replies: %+v", list.Comments)
+	}
+}
+
+func TestLockedPostRejectsComments(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	postID := app.createPost(admin, "Locked Post")
+	app.expect(http.StatusCreated, admin, http.MethodPut, postPath(postID), echo.Map{
+		"title":          "Locked Post",
+		"description":    "Locked",
+		"content":        "Locked",
+		"commentsLocked": true,
+	}, nil)
+
+	var post postResponse
+	app.expect(http.StatusOK, nil, http.MethodGet, postPath(postID), nil, &post)
+	if post.Post == nil || post.Post.CommentStatus != "locked" {
+		t.Fatalf("got the post %+v", post.Post)
+	}
+	rec := app.call(alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Hello"})
+	if rec.Code < 400 {
+		t.Errorf("a comment on a locked post got status %d", rec.Code)
+	}
+}
+
+func TestModeratedCommentsWaitForApproval(t *testing.T) {
+	app := newTestApp(t, map[string]string{"COMMENT_MODERATION": "all"})
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	postID := app.createPost(admin, "Moderated Post")
+
+	var created struct {
+		Comment commentResponse `json:"comment"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Waiting"}, &created)
+	if created.Comment.Status != "pending" {
+		t.Fatalf("got the status %q, want pending", created.Comment.Status)
+	}
+	if subject := app.lastMail(admin.Email).Subject; subject != "A comment is waiting for approval" {
+		t.Errorf("the admin got the mail %q", subject)
+	}
+	var notified struct {
+		Notifications []struct {
+			Type string `json:"type"`
+		} `json:"notifications"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/notifications", nil, &notified)
+	if len(notified.Notifications) != 1 || notified.Notifications[0].Type != "pending" {
+		t.Errorf("got the notifications %+v", notified.Notifications)
+	}
+
+	// The author of the post may opt out of them like of the other notifications.
+	app.expect(http.StatusOK, admin, http.MethodPut, "/notifications/preferences", echo.Map{
+		"preferences": []echo.Map{{"type": "pending", "inApp": false, "email": false}},
+	}, nil)
+	mails := len(app.mailer.Messages())
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Muted"}, nil)
+	app.queue.RunDue()
+	if len(app.mailer.Messages()) != mails {
+		t.Errorf("a muted pending comment was mailed: %+v", app.mailer.Messages()[mails:])
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/notifications", nil, &notified)
+	if len(notified.Notifications) != 1 {
+		t.Errorf("a muted notification was stored: %+v", notified.Notifications)
+	}
+
+	var list struct {
+		Comments []commentResponse `json:"comments"`
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments", postID), nil, &list)
+	if len(list.Comments) != 0 {
+		t.Errorf("a pending comment is listed: %+v", list.Comments)
+	}
+	app.expect(http.StatusOK, admin, http.MethodPost, fmt.Sprintf("/comments/%d/approve", created.Comment.ID), nil, nil)
+	app.expect(http.StatusOK, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments", postID), nil, &list)
+	if len(list.Comments) != 1 || list.Comments[0].Status != "approved" {
+		t.Errorf("got the comments after approving %+v", list.Comments)
+	}
+}
+
+func TestLocalizedMails(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/email-token", echo.Map{"email": "bora@example.com", "locale": "ko-KR"}, nil)
+	if mail := app.lastMail("bora@example.com"); mail.Subject != "이메일 인증" || !strings.Contains(mail.HTML, `lang="ko"`) {
+		t.Errorf("got the verification mail %q", mail.Subject)
+	}
+	token := app.requestEmailToken("bora@example.com")
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/email-verification", echo.Map{"email": "bora@example.com", "token": token}, nil)
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/sign-up", echo.Map{
+		"email":           "bora@example.com",
+		"username":        "bora",
+		"password":        "password-bora",
+		"passwordConfirm": "password-bora",
+		"locale":          "ko",
+	}, nil)
+	app.expect(http.StatusCreated, nil, http.MethodPost, "/auth/password-restoration", echo.Map{
+		"email":              "bora@example.com",
+		"newPassword":        "new-password",
+		"newPasswordConfirm": "new-password",
+	}, nil)
+	if subject := app.lastMail("bora@example.com").Subject; subject != "비밀번호가 재설정되었습니다" {
+		t.Errorf("got the password reset mail %q", subject)
+	}
+
+	bora := &testUser{Email: "bora@example.com", Password: "new-password"}
+	app.signIn(bora)
+	app.expect(http.StatusOK, bora, http.MethodPut, "/users/locale", echo.Map{"locale": "en"}, nil)
+	app.expect(http.StatusBadRequest, bora, http.MethodPut, "/users/locale", echo.Map{"locale": "en, ko"}, nil)
+	var account struct {
+		Account struct {
+			Locale string `json:"locale"`
+		} `json:"account"`
+	}
+	app.expect(http.StatusOK, bora, http.MethodGet, "/users/account", nil, &account)
+	if account.Account.Locale != "en" {
+		t.Errorf("got the locale %q", account.Account.Locale)
+	}
+
+	var templates struct {
+		Templates []string `json:"templates"`
+		Locales   []string `json:"locales"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/mail/templates", nil, &templates)
+	if len(templates.Templates) == 0 || len(templates.Locales) < 2 {
+		t.Errorf("got the templates %+v", templates)
+	}
+	if rec := app.call(bora, http.MethodGet, "/mail/templates", nil); rec.Code < 400 {
+		t.Errorf("a user who is not an admin got status %d", rec.Code)
+	}
+	rec := app.call(admin, http.MethodGet, "/mail/templates/password_reset/preview?locale=ko", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<html lang=\"ko\">") {
+		t.Errorf("got the preview %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = app.call(admin, http.MethodGet, "/mail/templates/password_reset/preview?format=text", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Hello alice,") {
+		t.Errorf("got the text preview %d: %s", rec.Code, rec.Body.String())
+	}
+	app.expect(http.StatusNotFound, admin, http.MethodGet, "/mail/templates/missing/preview", nil, nil)
+}
+
+func TestMailsAreQueued(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	sent := len(app.mailer.Messages())
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/email-token", echo.Map{"email": "carol@example.com"}, nil)
+	if len(app.mailer.Messages()) != sent {
+		t.Error("the mail is sent before its job runs")
+	}
+	var list struct {
+		Jobs []struct {
+			ID     int64  `json:"id"`
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"jobs"`
+		Counts map[string]int `json:"counts"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/jobs?status=pending", nil, &list)
+	if len(list.Jobs) != 1 || list.Jobs[0].Type != "mail.send" || list.Counts["pending"] != 1 {
+		t.Fatalf("got the pending jobs %+v", list)
+	}
+	app.lastMail("carol@example.com")
+	rec := app.call(admin, http.MethodGet, fmt.Sprintf("/jobs/%d", list.Jobs[0].ID), nil)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "carol@example.com") {
+		t.Errorf("the mail job is shown with its payload: %d %s", rec.Code, rec.Body.String())
+	}
+	app.expect(http.StatusConflict, admin, http.MethodPost, fmt.Sprintf("/jobs/%d/retry", list.Jobs[0].ID), nil, nil)
+	app.expect(http.StatusNotFound, admin, http.MethodGet, "/jobs/999", nil, nil)
+	if rec := app.call(nil, http.MethodGet, "/jobs", nil); rec.Code < 400 {
+		t.Errorf("an anonymous user got status %d", rec.Code)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	type received struct {
+		event     string
+		signature string
+		timestamp string
+		body      []byte
+	}
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{
+			event:     r.Header.Get("X-Webhook-Event"),
+			signature: r.Header.Get("X-Webhook-Signature"),
+			timestamp: r.Header.Get("X-Webhook-Timestamp"),
+			body:      body,
+		})
+	}))
+	defer server.Close()
+
+	var created struct {
+		Webhook struct {
+			ID     int      `json:"id"`
+			Secret string   `json:"secret"`
+			Events []string `json:"events"`
+		} `json:"webhook"`
+	}
+	app.expect(http.StatusCreated, admin, http.MethodPost, "/webhooks", echo.Map{
+		"url":    server.URL,
+		"events": []string{"post.published", "post.updated", "post.deleted", "comment.created"},
+	}, &created)
+	if len(created.Webhook.Secret) == 0 || len(created.Webhook.Events) != 4 {
+		t.Fatalf("got the webhook %+v", created.Webhook)
+	}
+	if rec := app.call(alice, http.MethodPost, "/webhooks", echo.Map{"url": server.URL, "events": []string{"post.deleted"}}); rec.Code < 400 {
+		t.Errorf("a user who is not an admin created a webhook: %d", rec.Code)
+	}
+
+	postID := app.createPost(admin, "Hooked Post")
+	app.expect(http.StatusCreated, admin, http.MethodPut, postPath(postID), echo.Map{"title": "Hooked Post, Edited", "content": "Edited"}, nil)
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Nice"}, nil)
+	app.expect(http.StatusCreated, admin, http.MethodDelete, postPath(postID), nil, nil)
+	app.queue.RunDue()
+
+	want := []string{"post.published", "post.updated", "comment.created", "post.deleted"}
+	if len(requests) != len(want) {
+		t.Fatalf("got %d deliveries, want %d", len(requests), len(want))
+	}
+	for i, request := range requests {
+		var payload struct {
+			Event string `json:"event"`
+			Data  struct {
+				ID     int    `json:"id"`
+				PostID int    `json:"postId"`
+				URL    string `json:"url"`
+			} `json:"data"`
+		}
+		err := json.Unmarshal(request.body, &payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if request.event != want[i] || payload.Event != want[i] || len(payload.Data.URL) == 0 {
+			t.Errorf("delivery %d is %q: %s", i, request.event, request.body)
+		}
+		if request.signature != "sha256="+webhooks.Sign(created.Webhook.Secret, request.timestamp, request.body) {
+			t.Errorf("the signature of delivery %d does not match", i)
+		}
+	}
+
+	var deliveries struct {
+		Deliveries []struct {
+			ID     int64  `json:"id"`
+			Status string `json:"status"`
+		} `json:"deliveries"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, fmt.Sprintf("/webhooks/%d/deliveries", created.Webhook.ID), nil, &deliveries)
+	if len(deliveries.Deliveries) != 4 || deliveries.Deliveries[0].Status != "succeeded" {
+		t.Fatalf("got the deliveries %+v", deliveries.Deliveries)
+	}
+	app.expect(http.StatusCreated, admin, http.MethodPost, fmt.Sprintf("/webhooks/deliveries/%d/replay", deliveries.Deliveries[0].ID), nil, nil)
+	app.queue.RunDue()
+	if len(requests) != 5 || string(requests[4].body) != string(requests[3].body) {
+		t.Errorf("the replay sent %d requests", len(requests))
+	}
+
+	app.expect(http.StatusOK, admin, http.MethodPut, fmt.Sprintf("/webhooks/%d", created.Webhook.ID), echo.Map{
+		"url":      server.URL,
+		"events":   []string{"post.deleted"},
+		"isActive": false,
+	}, nil)
+	app.createPost(admin, "Unhooked Post")
+	app.queue.RunDue()
+	if len(requests) != 5 {
+		t.Errorf("an inactive webhook got %d requests", len(requests)-5)
+	}
+	app.expect(http.StatusOK, admin, http.MethodDelete, fmt.Sprintf("/webhooks/%d", created.Webhook.ID), nil, nil)
+	app.expect(http.StatusNotFound, admin, http.MethodGet, fmt.Sprintf("/webhooks/%d/deliveries", created.Webhook.ID), nil, nil)
+}
+
+func TestFeeds(t *testing.T) {
+	app := newTestApp(t, map[string]string{"SITE_NAME": "Test Blog"})
+	admin := app.signUpAdmin("admin")
+	first := app.createPost(admin, "First Post", "go")
+	app.createPost(admin, "Second Post", "news")
+
+	rec := app.call(nil, http.MethodGet, "/feed.xml", nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "application/rss+xml") {
+		t.Fatalf("got %d with %q", rec.Code, rec.Header().Get(echo.HeaderContentType))
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "<title>Test Blog</title>") || strings.Index(body, "Second Post") > strings.Index(body, "First Post") {
+		t.Errorf("the feed is not of the latest posts first: %s", body)
+	}
+	if !strings.Contains(body, fmt.Sprintf("http://localhost:3000/posts/%d", first)) || !strings.Contains(body, "<p>The content of First Post</p>") {
+		t.Errorf("the feed has no link or content of the post: %s", body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	conditional := httptest.NewRecorder()
+	app.echo.ServeHTTP(conditional, req)
+	if conditional.Code != http.StatusNotModified {
+		t.Errorf("got %d for the same ETag", conditional.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("If-Modified-Since", rec.Header().Get("Last-Modified"))
+	conditional = httptest.NewRecorder()
+	app.echo.ServeHTTP(conditional, req)
+	if conditional.Code != http.StatusNotModified {
+		t.Errorf("got %d for the same Last-Modified", conditional.Code)
+	}
+
+	rec = app.call(nil, http.MethodGet, "/tags/Go/atom.xml", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "First Post") || strings.Contains(rec.Body.String(), "Second Post") {
+		t.Errorf("got %d for the tag feed: %s", rec.Code, rec.Body.String())
+	}
+	var feed struct {
+		Title string `json:"title"`
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	rec = app.call(nil, http.MethodGet, "/users/ADMIN/feed.json", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d for the author feed", rec.Code)
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "Test Blog - admin" || len(feed.Items) != 2 {
+		t.Errorf("got the author feed %+v", feed)
+	}
+
+	for _, path := range []string{"/tags/missing/feed.xml", "/users/nobody/atom.xml"} {
+		if rec := app.call(nil, http.MethodGet, path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("%s got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	robots := filepath.Join(t.TempDir(), "robots.txt")
+	err := os.WriteFile(robots, []byte("User-agent: *\nDisallow: /admin\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(t, map[string]string{"ROBOTS_FILE": robots})
+	admin := app.signUpAdmin("admin")
+	first := app.createPost(admin, "First Post", "go")
+
+	sitemap := func() string {
+		rec := app.call(nil, http.MethodGet, "/sitemap.xml", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got %d for the sitemap", rec.Code)
+		}
+		return rec.Body.String()
+	}
+	body := sitemap()
+	for _, loc := range []string{
+		fmt.Sprintf("<loc>http://localhost:3000/posts/%d</loc>", first),
+		"<loc>http://localhost:3000/tags/go</loc>",
+		"<loc>http://localhost:3000/users/admin</loc>",
+	} {
+		if !strings.Contains(body, loc) {
+			t.Errorf("the sitemap has no %s: %s", loc, body)
+		}
+	}
+	if !strings.Contains(body, "<lastmod>") {
+		t.Errorf("the sitemap has no lastmod: %s", body)
+	}
+
+	second := app.createPost(admin, "Second Post", "news")
+	if body := sitemap(); !strings.Contains(body, fmt.Sprintf("/posts/%d<", second)) || !strings.Contains(body, "/tags/news<") {
+		t.Errorf("the cached sitemap was not invalidated by the new post: %s", body)
+	}
+	app.expect(http.StatusCreated, admin, http.MethodDelete, postPath(second), nil, nil)
+	if body := sitemap(); strings.Contains(body, fmt.Sprintf("/posts/%d<", second)) {
+		t.Errorf("the sitemap has the deleted post: %s", body)
+	}
+	if rec := app.call(nil, http.MethodGet, "/sitemaps/1.xml", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("got %d for a page of a sitemap which is not split", rec.Code)
+	}
+
+	rec := app.call(nil, http.MethodGet, "/robots.txt", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "User-agent: *\nDisallow: /admin\n" {
+		t.Errorf("got %d for the robots.txt: %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTagChangesAreSearchable(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	first := app.createPost(admin, "Generic Types", "golang")
+	second := app.createPost(admin, "Generic Pasta", "food")
+	var tags struct {
+		Tags []struct {
+			ID  int    `json:"id"`
+			Tag string `json:"tag"`
+		} `json:"tags"`
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, "/tags", nil, &tags)
+	tagIDs := map[string]int{}
+	for _, tag := range tags.Tags {
+		tagIDs[tag.Tag] = tag.ID
+	}
+
+	search := func(tag string) []int {
+		var res struct {
+			Results []struct {
+				Post struct {
+					ID int `json:"id"`
+				} `json:"post"`
+			} `json:"results"`
+		}
+		app.expect(http.StatusOK, nil, http.MethodGet, "/posts/search?q=generic&tag="+tag, nil, &res)
+		ids := []int{}
+		for _, result := range res.Results {
+			ids = append(ids, result.Post.ID)
+		}
+		return ids
+	}
+	app.expect(http.StatusOK, admin, http.MethodPut, fmt.Sprintf("/tags/%d", tagIDs["golang"]), echo.Map{"tag": "go"}, nil)
+	if got := search("go"); len(got) != 1 || got[0] != first {
+		t.Errorf("the renamed tag finds %v", got)
+	}
+	if got := search("golang"); len(got) != 0 {
+		t.Errorf("the old tag finds %v", got)
+	}
+	app.expect(http.StatusOK, admin, http.MethodPost, fmt.Sprintf("/tags/%d/merge", tagIDs["food"]), echo.Map{"targetId": tagIDs["golang"]}, nil)
+	if got := search("go"); len(got) != 2 || got[0] == got[1] || (got[0] != second && got[1] != second) {
+		t.Errorf("the merged tag finds %v", got)
+	}
+}
+
+func TestCommentDepthLimit(t *testing.T) {
+	app := newTestApp(t, map[string]string{"COMMENT_MAX_DEPTH": "1"})
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	postID := app.createPost(admin, "Deep Thread")
+	var created struct {
+		Comment commentResponse `json:"comment"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Top"}, &created)
+	top := created.Comment.ID
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "parentId": top, "content": "Reply"}, &created)
+	if created.Comment.Depth != 1 {
+		t.Fatalf("got the reply %+v", created.Comment)
+	}
+	app.expect(http.StatusBadRequest, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "parentId": created.Comment.ID, "content": "Too deep"}, nil)
+	other := app.createPost(admin, "Other Post")
+	app.expect(http.StatusBadRequest, alice, http.MethodPost, "/comments", echo.Map{"postId": other, "parentId": top, "content": "Elsewhere"}, nil)
+}
+
+func TestCommentIPIsNotForged(t *testing.T) {
+	// The requests of httptest come from 192.0.2.1.
+	tests := []struct {
+		proxies string
+		want    string
+	}{
+		{proxies: "", want: "192.0.2.1"},
+		{proxies: "192.0.2.1", want: "203.0.113.7"},
+		{proxies: "198.51.100.0/24", want: "192.0.2.1"},
+	}
+	for _, test := range tests {
+		app := newTestApp(t, map[string]string{"TRUSTED_PROXIES": test.proxies})
+		admin := app.signUpAdmin("admin")
+		alice := app.signUp("alice")
+		postID := app.createPost(admin, "Post")
+		app.headers = map[string]string{
+			echo.HeaderXForwardedFor: "203.0.113.7",
+			echo.HeaderXRealIP:       "203.0.113.8",
+		}
+		var created struct {
+			Comment commentResponse `json:"comment"`
+		}
+		app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Hello"}, &created)
+		var ip string
+		err := app.repository.QueryRow(`select ip from comments where id = ?`, created.Comment.ID).Scan(&ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ip != test.want {
+			t.Errorf("behind %q got the address %s, want %s", test.proxies, ip, test.want)
+		}
+	}
+}
+
+func TestCommentPages(t *testing.T) {
+	app := newTestApp(t, map[string]string{"SPAM_RATE_LIMIT": "100"})
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	postID := app.createPost(admin, "Busy Post")
+	comment := func(parentID int, content string) int {
+		var created struct {
+			Comment commentResponse `json:"comment"`
+		}
+		body := echo.Map{"postId": postID, "content": content}
+		if parentID > 0 {
+			body["parentId"] = parentID
+		}
+		app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", body, &created)
+		return created.Comment.ID
+	}
+	// The comments are written in the same instant often, so the ids break the ties.
+	roots := []int{}
+	for i := 1; i <= 5; i++ {
+		roots = append(roots, comment(0, fmt.Sprintf("Comment %d", i)))
+	}
+	reply := comment(roots[3], "Reply to 4")
+	comment(roots[3], "Another reply to 4")
+	comment(roots[1], "Reply to 2")
+	comment(reply, "Nested reply")
+
+	// pages reads every page of the sort and returns the top-level comments of each page.
+	pages := func(sort string) [][]int {
+		got := [][]int{}
+		cursor := ""
+		for len(got) < 10 {
+			var list struct {
+				Comments []commentResponse `json:"comments"`
+				Page     struct {
+					NextCursor string `json:"nextCursor"`
+				} `json:"page"`
+			}
+			app.expect(http.StatusOK, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments?sort=%s&limit=2&cursor=%s", postID, sort, url.QueryEscape(cursor)), nil, &list)
+			page := []int{}
+			for _, comment := range list.Comments {
+				if comment.ParentID == nil {
+					page = append(page, comment.ID)
+				}
+			}
+			got = append(got, page)
+			cursor = list.Page.NextCursor
+			if len(cursor) == 0 {
+				return got
+			}
+		}
+		t.Fatalf("the %s sort does not end", sort)
+		return nil
+	}
+	tests := []struct {
+		sort string
+		want [][]int
+	}{
+		{sort: "oldest", want: [][]int{{roots[0], roots[1]}, {roots[2], roots[3]}, {roots[4]}}},
+		{sort: "newest", want: [][]int{{roots[4], roots[3]}, {roots[2], roots[1]}, {roots[0]}}},
+		{sort: "top", want: [][]int{{roots[3], roots[1]}, {roots[0], roots[2]}, {roots[4]}}},
+	}
+	for _, test := range tests {
+		if got := pages(test.sort); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("the %s pages are %v, want %v", test.sort, got, test.want)
+		}
+	}
+
+	var list struct {
+		Comments []commentResponse `json:"comments"`
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments?sort=oldest&limit=2&cursor=", postID), nil, &list)
+	if len(list.Comments) != 3 || list.Comments[1].ID != roots[1] || list.Comments[2].ParentID == nil {
+		t.Errorf("the page does not have the replies of its comments: %+v", list.Comments)
+	}
+	app.expect(http.StatusBadRequest, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments?sort=random", postID), nil, nil)
+	app.expect(http.StatusBadRequest, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments?cursor=broken", postID), nil, nil)
+}
+
+func TestBookmarksFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	first := app.createPost(admin, "First Post")
+	second := app.createPost(admin, "Second Post")
+
+	var collection struct {
+		ID int `json:"id"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/users/bookmarks/collections", echo.Map{"name": "Reading"}, &collection)
+	app.expect(http.StatusConflict, alice, http.MethodPost, "/users/bookmarks/collections", echo.Map{"name": "READING"}, nil)
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/users/bookmarks", echo.Map{"postId": first}, nil)
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/users/bookmarks", echo.Map{"postId": second}, nil)
+	// Bookmarking the post again moves it into the collection.
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/users/bookmarks", echo.Map{"postId": first, "collectionId": collection.ID}, nil)
+	app.expect(http.StatusNotFound, alice, http.MethodPost, "/users/bookmarks", echo.Map{"postId": second + 100}, nil)
+
+	type bookmarkList struct {
+		Bookmarks []struct {
+			PostID       int  `json:"postId"`
+			CollectionID *int `json:"collectionId"`
+			Post         *struct {
+				Title      string `json:"title"`
+				Bookmarked bool   `json:"bookmarked"`
+			} `json:"post"`
+		} `json:"bookmarks"`
+	}
+	var list bookmarkList
+	app.expect(http.StatusOK, alice, http.MethodGet, "/users/bookmarks", nil, &list)
+	if len(list.Bookmarks) != 2 || list.Bookmarks[0].PostID != second || list.Bookmarks[0].Post == nil || !list.Bookmarks[0].Post.Bookmarked {
+		t.Fatalf("got the bookmarks %+v", list.Bookmarks)
+	}
+	app.expect(http.StatusOK, alice, http.MethodGet, fmt.Sprintf("/users/bookmarks?collection=%d", collection.ID), nil, &list)
+	if len(list.Bookmarks) != 1 || list.Bookmarks[0].PostID != first || list.Bookmarks[0].CollectionID == nil {
+		t.Errorf("got the bookmarks of the collection %+v", list.Bookmarks)
+	}
+	var collections struct {
+		Collections []struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		} `json:"collections"`
+	}
+	app.expect(http.StatusOK, alice, http.MethodGet, "/users/bookmarks/collections", nil, &collections)
+	if len(collections.Collections) != 1 || collections.Collections[0].Count != 1 {
+		t.Errorf("got the collections %+v", collections.Collections)
+	}
+
+	app.expect(http.StatusOK, alice, http.MethodDelete, fmt.Sprintf("/users/bookmarks/%d", second), nil, nil)
+	app.expect(http.StatusNotFound, alice, http.MethodDelete, fmt.Sprintf("/users/bookmarks/%d", second), nil, nil)
+	app.expect(http.StatusOK, alice, http.MethodDelete, fmt.Sprintf("/users/bookmarks/collections/%d", collection.ID), nil, nil)
+	app.expect(http.StatusOK, alice, http.MethodGet, "/users/bookmarks", nil, &list)
+	if len(list.Bookmarks) != 1 || list.Bookmarks[0].PostID != first {
+		t.Errorf("got the bookmarks %+v after the removals", list.Bookmarks)
+	}
+}
+
+func TestReactionsFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	bob := app.signUp("bob")
+	postID := app.createPost(admin, "Liked Post")
+	var created struct {
+		Comment commentResponse `json:"comment"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Hello"}, &created)
+
+	type toggled struct {
+		Reacted   bool `json:"reacted"`
+		Reactions []struct {
+			Type    string `json:"type"`
+			Count   int    `json:"count"`
+			Reacted bool   `json:"reacted"`
+		} `json:"reactions"`
+	}
+	count := func(res toggled, reaction string) int {
+		for _, counted := range res.Reactions {
+			if counted.Type == reaction {
+				return counted.Count
+			}
+		}
+		return 0
+	}
+	postReactions := fmt.Sprintf("/posts/%d/reactions", postID)
+	var res toggled
+	app.expect(http.StatusOK, alice, http.MethodPost, postReactions, echo.Map{"type": "👍"}, &res)
+	if !res.Reacted || count(res, "👍") != 1 {
+		t.Fatalf("got the reactions %+v", res)
+	}
+	app.expect(http.StatusOK, bob, http.MethodPost, postReactions, echo.Map{"type": "👍"}, &res)
+	if count(res, "👍") != 2 {
+		t.Errorf("got the reactions %+v", res)
+	}
+	app.expect(http.StatusOK, alice, http.MethodPost, postReactions, echo.Map{"type": "👍"}, &res)
+	if res.Reacted || count(res, "👍") != 1 {
+		t.Errorf("toggling the reaction again did not remove it: %+v", res)
+	}
+	app.expect(http.StatusBadRequest, alice, http.MethodPost, postReactions, echo.Map{"type": "🦀"}, nil)
+	app.expect(http.StatusNotFound, alice, http.MethodPost, fmt.Sprintf("/posts/%d/reactions", postID+100), echo.Map{"type": "👍"}, nil)
+
+	commentReactions := fmt.Sprintf("/comments/%d/reactions", created.Comment.ID)
+	app.expect(http.StatusOK, bob, http.MethodPost, commentReactions, echo.Map{"type": "❤️"}, &res)
+	if !res.Reacted || count(res, "❤️") != 1 {
+		t.Errorf("got the reactions of the comment %+v", res)
+	}
+	var list struct {
+		Comments []struct {
+			ID        int `json:"id"`
+			Reactions []struct {
+				Type  string `json:"type"`
+				Count int    `json:"count"`
+			} `json:"reactions"`
+		} `json:"comments"`
+	}
+	app.expect(http.StatusOK, nil, http.MethodGet, fmt.Sprintf("/posts/%d/comments?sort=liked", postID), nil, &list)
+	if len(list.Comments) != 1 || len(list.Comments[0].Reactions) == 0 {
+		t.Errorf("the comments have no reactions: %+v", list.Comments)
+	}
+}
+
+func TestNotificationsFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	postID := app.createPost(admin, "Notified Post")
+
+	type notificationList struct {
+		Notifications []struct {
+			ID            int    `json:"id"`
+			Type          string `json:"type"`
+			ActorUsername string `json:"actorUsername"`
+			Read          bool   `json:"read"`
+		} `json:"notifications"`
+		Unread int `json:"unread"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "First"}, nil)
+	app.queue.RunDue()
+	var list notificationList
+	app.expect(http.StatusOK, admin, http.MethodGet, "/notifications", nil, &list)
+	if len(list.Notifications) != 1 || list.Notifications[0].Type != "comment" || list.Notifications[0].ActorUsername != "alice" || list.Unread != 1 {
+		t.Fatalf("got the notifications %+v", list)
+	}
+	app.expect(http.StatusOK, admin, http.MethodPost, fmt.Sprintf("/notifications/%d/read", list.Notifications[0].ID), nil, nil)
+	var unread struct {
+		Unread int `json:"unread"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/notifications/unread", nil, &unread)
+	if unread.Unread != 0 {
+		t.Errorf("got %d unread notifications", unread.Unread)
+	}
+
+	// Saving the preferences twice updates them in place.
+	for i := 0; i < 2; i++ {
+		app.expect(http.StatusOK, admin, http.MethodPut, "/notifications/preferences", echo.Map{
+			"preferences": []echo.Map{{"type": "comment", "inApp": false, "email": false}},
+		}, nil)
+	}
+	var preferences struct {
+		Preferences []struct {
+			Type  string `json:"type"`
+			InApp bool   `json:"inApp"`
+		} `json:"preferences"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/notifications/preferences", nil, &preferences)
+	for _, preference := range preferences.Preferences {
+		if preference.Type == "comment" && preference.InApp {
+			t.Errorf("the preference is not saved: %+v", preferences.Preferences)
+		}
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/comments", echo.Map{"postId": postID, "content": "Second"}, nil)
+	app.queue.RunDue()
+	app.expect(http.StatusOK, admin, http.MethodGet, "/notifications", nil, &list)
+	if len(list.Notifications) != 1 {
+		t.Errorf("a muted notification was stored: %+v", list.Notifications)
+	}
+	app.expect(http.StatusOK, admin, http.MethodPost, "/notifications/read", nil, nil)
+}
+
+func TestReportsFlow(t *testing.T) {
+	app := newTestApp(t, map[string]string{"REPORT_HIDE_THRESHOLD": "2"})
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	bob := app.signUp("bob")
+	postID := app.createPost(admin, "Reported Post")
+	report := echo.Map{"targetType": "post", "targetId": postID, "reason": "spam"}
+
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/reports", report, nil)
+	app.expect(http.StatusConflict, alice, http.MethodPost, "/reports", report, nil)
+	shown := func() bool {
+		var res struct {
+			Post *postResponse `json:"post"`
+		}
+		app.expect(http.StatusOK, nil, http.MethodGet, postPath(postID), nil, &res)
+		return res.Post != nil
+	}
+	if !shown() {
+		t.Fatal("the post is hidden under the threshold")
+	}
+	app.expect(http.StatusCreated, bob, http.MethodPost, "/reports", report, nil)
+	if shown() {
+		t.Errorf("the post reported to the threshold is shown")
+	}
+
+	var reports struct {
+		Reports []struct {
+			ID      int `json:"id"`
+			Reports int `json:"reports"`
+		} `json:"reports"`
+	}
+	if rec := app.call(alice, http.MethodGet, "/reports", nil); rec.Code < 400 {
+		t.Errorf("a user who is not an admin listed the reports: %d", rec.Code)
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/reports?status=open", nil, &reports)
+	if len(reports.Reports) != 2 || reports.Reports[0].Reports != 2 {
+		t.Fatalf("got the reports %+v", reports.Reports)
+	}
+	app.expect(http.StatusOK, admin, http.MethodPost, fmt.Sprintf("/reports/%d/resolve", reports.Reports[0].ID), echo.Map{"action": "dismiss"}, nil)
+	if !shown() {
+		t.Errorf("the dismissed post is hidden")
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/reports?status=open", nil, &reports)
+	if len(reports.Reports) != 0 {
+		t.Errorf("the reports are still open: %+v", reports.Reports)
+	}
+}
+
+func TestBookmarksOfHiddenPosts(t *testing.T) {
+	app := newTestApp(t, map[string]string{"REPORT_HIDE_THRESHOLD": "2"})
+	admin := app.signUpAdmin("admin")
+	alice := app.signUp("alice")
+	bob := app.signUp("bob")
+	postID := app.createPost(admin, "Hidden Post")
+
+	var collection struct {
+		ID int `json:"id"`
+	}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/users/bookmarks/collections", echo.Map{"name": "Later"}, &collection)
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/users/bookmarks", echo.Map{"postId": postID, "collectionId": collection.ID}, nil)
+	bookmarked := func() (int, int) {
+		var list struct {
+			Bookmarks []struct {
+				PostID int `json:"postId"`
+			} `json:"bookmarks"`
+		}
+		app.expect(http.StatusOK, alice, http.MethodGet, "/users/bookmarks", nil, &list)
+		var collections struct {
+			Collections []struct {
+				Count int `json:"count"`
+			} `json:"collections"`
+		}
+		app.expect(http.StatusOK, alice, http.MethodGet, "/users/bookmarks/collections", nil, &collections)
+		if len(collections.Collections) != 1 {
+			t.Fatalf("got the collections %+v", collections.Collections)
+		}
+		return len(list.Bookmarks), collections.Collections[0].Count
+	}
+
+	report := echo.Map{"targetType": "post", "targetId": postID, "reason": "spam"}
+	app.expect(http.StatusCreated, alice, http.MethodPost, "/reports", report, nil)
+	app.expect(http.StatusCreated, bob, http.MethodPost, "/reports", report, nil)
+	if listed, counted := bookmarked(); listed != 0 || counted != 0 {
+		t.Errorf("the bookmark of the hidden post is listed %d times and counted %d times", listed, counted)
+	}
+
+	var reports struct {
+		Reports []struct {
+			ID int `json:"id"`
+		} `json:"reports"`
+	}
+	app.expect(http.StatusOK, admin, http.MethodGet, "/reports?status=open", nil, &reports)
+	app.expect(http.StatusOK, admin, http.MethodPost, fmt.Sprintf("/reports/%d/resolve", reports.Reports[0].ID), echo.Map{"action": "dismiss"}, nil)
+	if listed, counted := bookmarked(); listed != 1 || counted != 1 {
+		t.Errorf("the bookmark of the shown post is listed %d times and counted %d times", listed, counted)
+	}
+
+	app.expect(http.StatusCreated, admin, http.MethodDelete, postPath(postID), nil, nil)
+	var rows int
+	err := app.repository.QueryRow(`select count(*) from bookmarks where post_id = ?`, postID).Scan(&rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 0 {
+		t.Errorf("the deleted post has %d bookmarks", rows)
+	}
+}
@@ -7,11 +7,12 @@ import (
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
 	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/store"
 )
 
 type AuthController struct {
 	*echo.Echo
-	repository    *db.Repository
+	stores        store.Store
 	config        *config.Config
 	jwtMiddleware *echo.MiddlewareFunc
 	mailClient    *mail.MailClient
@@ -20,12 +21,12 @@ type AuthController struct {
 func NewAuthController(
 	echo *echo.Echo,
 	config *config.Config,
-	repository *db.Repository,
+	stores store.Store,
 	jwtMiddleware *echo.MiddlewareFunc,
 	mailClient *mail.MailClient,
 ) *AuthController {
 	return &AuthController{
-		repository:    repository,
+		stores:        stores,
 		config:        config,
 		Echo:          echo,
 		jwtMiddleware: jwtMiddleware,
@@ -34,7 +35,7 @@ func NewAuthController(
 }
 
 func (controller *AuthController) UseRoute() {
-	authService := NewAuthService(controller.repository, controller.mailClient, controller.config)
+	authService := NewAuthService(controller.stores, controller.mailClient, controller.config)
 	controller.POST("/auth/sign-up", func(c echo.Context) error {
 		model := new(SignUpModel)
 		err := c.Bind(model)
@@ -44,6 +45,9 @@ func (controller *AuthController) UseRoute() {
 				Message: "Invalid data form.",
 			})
 		}
+		if len(model.Locale) == 0 {
+			model.Locale = c.Request().Header.Get("Accept-Language")
+		}
 		isSigned := authService.SignUp(model)
 		if !isSigned {
 			return c.JSON(http.StatusBadRequest, &db.BadResponse{
@@ -117,6 +121,9 @@ func (controller *AuthController) UseRoute() {
 				Message: "Invalid data form.",
 			})
 		}
+		if len(model.Locale) == 0 {
+			model.Locale = c.Request().Header.Get("Accept-Language")
+		}
 		isOK := authService.SendEmail(model)
 		if !isOK {
 			return c.JSON(http.StatusBadRequest, &db.BadResponse{
@@ -213,7 +220,7 @@ func (controller *AuthController) UseRoute() {
 			"status":  true,
 			"message": "The password was changed.",
 		})
-	})
+	}, *controller.jwtMiddleware)
 
 	controller.POST("/auth/password-restoration", func(c echo.Context) error {
 		model := new(RestorePasswordModel)
@@ -7,6 +7,8 @@ type SignUpModel struct {
 	Username        string `json:"username"`
 	Password        string `json:"password"`
 	PasswordConfirm string `json:"passwordConfirm"`
+	// Locale is the language of the mails to the user. The Accept-Language header is used when it is empty.
+	Locale string `json:"locale"`
 }
 
 type SignInModel struct {
@@ -26,7 +28,8 @@ type JWTBody struct {
 }
 
 type SendEmailModel struct {
-	Email string `json:"email"`
+	Email  string `json:"email"`
+	Locale string `json:"locale"`
 }
 
 type VerifyEmailModel struct {
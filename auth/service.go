@@ -12,35 +12,30 @@ import (
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
 	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/store"
 	"github.com/quavious/blog-factory-server/users"
 	"github.com/quavious/blog-factory-server/utils"
 )
 
 type AuthService struct {
-	repository *db.Repository
+	users      store.UserStore
+	tokens     store.TokenStore
 	mailClient *mail.MailClient
 	config     *config.Config
 }
 
-func NewAuthService(repository *db.Repository, mailClient *mail.MailClient, config *config.Config) *AuthService {
+func NewAuthService(stores store.Store, mailClient *mail.MailClient, config *config.Config) *AuthService {
 	return &AuthService{
-		repository: repository,
+		users:      stores.Users(),
+		tokens:     stores.Tokens(),
 		mailClient: mailClient,
 		config:     config,
 	}
 }
 
 func (service *AuthService) SignUp(model *SignUpModel) bool {
-	isVerified, err := service.repository.Query("select * from verified_emails where email = ?", model.Email)
-	if err != nil {
-		log.Println("error: this email is not verified")
-		return false
-	}
-	index := 0
-	for isVerified.Next() {
-		index++
-	}
-	if index == 0 {
+	isVerified, err := service.tokens.IsVerified(model.Email)
+	if err != nil || !isVerified {
 		log.Println("error: this email is not verified")
 		return false
 	}
@@ -53,7 +48,13 @@ func (service *AuthService) SignUp(model *SignUpModel) bool {
 		log.Println("error: uuid is not created correctly.")
 		return false
 	}
-	_, err = service.repository.Exec("insert into users (id, email, username, password) values (?, ?, ?, ?)", uuid.String(), model.Email, model.Username, password)
+	err = service.users.Create(&users.User{
+		ID:       uuid.String(),
+		Email:    model.Email,
+		Username: model.Username,
+		Password: password,
+		Locale:   locale(model.Locale),
+	})
 	if err != nil {
 		log.Println("error: new user insertion is failed.")
 		return false
@@ -63,13 +64,15 @@ func (service *AuthService) SignUp(model *SignUpModel) bool {
 
 func (service *AuthService) SignIn(model *SignInModel) (*JWTToken, *users.User) {
 	jwtAccessSecret, jwtRefreshSecret := service.config.GetJWTSecret()
-	row := service.repository.QueryRow("select id, email, password, username, is_admin from users where email = ?", model.Email)
-	user := new(users.User)
-	err := row.Scan(&user.ID, &user.Email, &user.Password, &user.Username, &user.IsAdmin)
+	user, err := service.users.ByEmail(model.Email)
 	if err != nil {
 		log.Println(err)
 		return nil, nil
 	}
+	if user.IsSuspended {
+		log.Println("error: this user is suspended.")
+		return nil, nil
+	}
 	isOK, err := utils.Verify(model.Password, user.Password)
 	if err != nil || !isOK {
 		log.Println("error: password does not match.")
@@ -100,7 +103,7 @@ func (service *AuthService) SignIn(model *SignInModel) (*JWTToken, *users.User)
 	if err != nil {
 		return nil, nil
 	}
-	_, err = service.repository.Exec("update users set hashed_refresh_token = ? where id = ?", hashedRefreshToken, user.ID)
+	err = service.users.SetRefreshToken(user.ID, hashedRefreshToken)
 	if err != nil {
 		return nil, nil
 	}
@@ -113,26 +116,17 @@ func (service *AuthService) SignOut() bool {
 }
 
 func (service *AuthService) SendEmail(model *SendEmailModel) bool {
-	num := 0
-	rows, err := service.repository.Query("select email from users where email = ?", model.Email)
-	if err != nil {
-		log.Println(err.Error())
-		return false
-	}
-	for rows.Next() {
-		num++
-	}
-	if num > 0 {
+	_, err := service.users.ByEmail(model.Email)
+	if !errors.Is(err, db.ErrNotFound) {
 		return false
 	}
 	emailToken := utils.NewEmailToken()
 	expiredAt := time.Now().Add(time.Minute * 10)
-	_, err = service.repository.Exec("insert into email_tokens (email, token, expired_at) values (?, ?, ?)", model.Email, emailToken, expiredAt)
+	err = service.tokens.CreateEmailToken(model.Email, emailToken, expiredAt)
 	if err != nil {
-		log.Println(err)
 		return false
 	}
-	isOK := service.mailClient.SendToken(emailToken, model.Email)
+	isOK := service.mailClient.SendToken(emailToken, model.Email, model.Locale)
 	if !isOK {
 		return false
 	}
@@ -140,17 +134,11 @@ func (service *AuthService) SendEmail(model *SendEmailModel) bool {
 }
 
 func (service *AuthService) VerifyEmail(model *VerifyEmailModel) bool {
-	var expiredAt time.Time
-	row := service.repository.QueryRow("select expired_at from email_tokens where email = ? and token = ? order by id desc", model.Email, model.Token)
-	err := row.Scan(&expiredAt)
+	expiredAt, err := service.tokens.EmailTokenExpiry(model.Email, model.Token)
 	if err != nil || !expiredAt.After(time.Now()) {
 		return false
 	}
-	_, err = service.repository.Exec("insert ignore into verified_emails (email) values (?)", model.Email)
-	if err != nil {
-		return false
-	}
-	return true
+	return service.tokens.VerifyEmail(model.Email) == nil
 }
 
 func (service *AuthService) VerifyJWTToken(header string, cookie *http.Cookie) *string {
@@ -174,11 +162,8 @@ func (service *AuthService) ModifyPassword(userID string, model *ModifyPasswordM
 	if model.NewPassword != model.NewPasswordConfirm {
 		return false
 	}
-	row := service.repository.QueryRow("select id, email, username, password from users where id = ?", userID)
-	user := new(users.User)
-	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.Password)
+	user, err := service.users.ByID(userID)
 	if err != nil {
-		log.Println(err)
 		return false
 	}
 	isOK, err := utils.Verify(model.CurrentPassword, user.Password)
@@ -190,11 +175,7 @@ func (service *AuthService) ModifyPassword(userID string, model *ModifyPasswordM
 		log.Println(err)
 		return false
 	}
-	_, err = service.repository.Exec("update users set password = ? where id = ?", newHash, user.ID)
-	if err != nil {
-		return false
-	}
-	return true
+	return service.users.SetPassword(user.ID, newHash) == nil
 }
 
 func (service *AuthService) RestorePassword(model *RestorePasswordModel) bool {
@@ -206,14 +187,31 @@ func (service *AuthService) RestorePassword(model *RestorePasswordModel) bool {
 		log.Println("error: hashing password is failed")
 		return false
 	}
-	_, err = service.repository.Exec(`update users set password = ? where email = ?`, hash, model.Email)
+	user, err := service.users.ByEmail(model.Email)
 	if err != nil {
-		log.Println(err.Error())
 		return false
 	}
+	err = service.users.SetPassword(user.ID, hash)
+	if err != nil {
+		return false
+	}
+	if service.mailClient != nil {
+		service.mailClient.SendTemplate(user.Email, user.Locale, "password_reset", map[string]interface{}{
+			"Username": user.Username,
+		})
+	}
 	return true
 }
 
+// locale returns the preferred language of an Accept-Language header, or of a language tag.
+func locale(acceptLanguage string) string {
+	locales := mail.ParseLocales(acceptLanguage)
+	if len(locales) == 0 {
+		return ""
+	}
+	return locales[0]
+}
+
 func (service *AuthService) confirmJWTToken(tokens *JWTToken) *string {
 	jwtAccessSecret, jwtRefreshSecret := service.config.GetJWTSecret()
 	accessToken, err := jwt.Parse(tokens.AccessToken, func(token *jwt.Token) (interface{}, error) {
@@ -248,11 +246,10 @@ func (service *AuthService) confirmJWTToken(tokens *JWTToken) *string {
 		log.Println("error: jwt map claims type casting")
 		return nil
 	}
-	user := new(users.User)
-	row := service.repository.QueryRow("select id, email, hashed_refresh_token from users where id = ?", claim["userId"])
-	err = row.Scan(&user.ID, &user.Email, &user.RefreshToken)
-	if err != nil {
-		log.Println(err)
+	userID, _ := claim["userId"].(string)
+	user, err := service.users.ByID(userID)
+	if err != nil || user.IsSuspended {
+		log.Println("error: no users or the user is suspended.")
 		return nil
 	}
 	isOK, err := utils.Verify(tokens.RefreshToken, user.RefreshToken)
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+func newTestService() *AuthService {
+	return NewAuthService(store.NewMemoryStore(), nil, &config.Config{})
+}
+
+func TestSignUpNeedsVerifiedEmail(t *testing.T) {
+	service := newTestService()
+	signUp := &SignUpModel{Email: "alice@example.com", Username: "alice", Password: "password"}
+	if service.SignUp(signUp) {
+		t.Fatal("an unverified email signed up")
+	}
+
+	err := service.tokens.CreateEmailToken(signUp.Email, "123456", time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.VerifyEmail(&VerifyEmailModel{Email: signUp.Email, Token: "654321"}) {
+		t.Fatal("a wrong token verified the email")
+	}
+	if !service.VerifyEmail(&VerifyEmailModel{Email: signUp.Email, Token: "123456"}) {
+		t.Fatal("the token did not verify the email")
+	}
+	if !service.SignUp(signUp) {
+		t.Fatal("a verified email did not sign up")
+	}
+	if service.SignUp(signUp) {
+		t.Fatal("the same email signed up twice")
+	}
+
+	tokens, user := service.SignIn(&SignInModel{Email: signUp.Email, Password: "wrong"})
+	if tokens != nil || user != nil {
+		t.Fatal("a wrong password signed in")
+	}
+	tokens, user = service.SignIn(&SignInModel{Email: signUp.Email, Password: signUp.Password})
+	if tokens == nil || user == nil || user.Username != signUp.Username {
+		t.Fatal("the user did not sign in")
+	}
+	stored, err := service.users.ByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.RefreshToken) == 0 {
+		t.Fatal("the refresh token is not stored")
+	}
+}
+
+func TestExpiredTokenDoesNotVerify(t *testing.T) {
+	service := newTestService()
+	err := service.tokens.CreateEmailToken("alice@example.com", "123456", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.VerifyEmail(&VerifyEmailModel{Email: "alice@example.com", Token: "123456"}) {
+		t.Fatal("an expired token verified the email")
+	}
+}
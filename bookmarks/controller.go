@@ -0,0 +1,202 @@
+package bookmarks
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+type BookmarksController struct {
+	*echo.Echo
+	config        *config.Config
+	repository    *db.Repository
+	stores        store.Store
+	jwtMiddleware *echo.MiddlewareFunc
+	index         *search.Index
+	mailClient    *mail.MailClient
+}
+
+func NewBookmarksController(echo *echo.Echo, config *config.Config, repository *db.Repository, stores store.Store, jwtMiddleware *echo.MiddlewareFunc, index *search.Index, mailClient *mail.MailClient) *BookmarksController {
+	return &BookmarksController{
+		Echo:          echo,
+		config:        config,
+		repository:    repository,
+		stores:        stores,
+		jwtMiddleware: jwtMiddleware,
+		index:         index,
+		mailClient:    mailClient,
+	}
+}
+
+func (controller *BookmarksController) UseRoute() {
+	bookmarksService := NewBookmarksService(controller.config, controller.repository, controller.stores, controller.index, controller.mailClient)
+	controller.GET("/users/bookmarks", func(c echo.Context) error {
+		model := new(ListBookmarksModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		bookmarks, pageInfo, err := bookmarksService.List(userID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the bookmarks is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":    true,
+			"bookmarks": bookmarks,
+			"page":      pageInfo,
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.POST("/users/bookmarks", func(c echo.Context) error {
+		model := new(AddBookmarkModel)
+		err := c.Bind(model)
+		if err != nil || model.PostID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		bookmark, err := bookmarksService.Add(userID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Bookmarking the post is failed.",
+			})
+		}
+		return c.JSON(http.StatusCreated, echo.Map{
+			"status":   true,
+			"bookmark": bookmark,
+			"message":  "The post is bookmarked.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.DELETE("/users/bookmarks/:postId", func(c echo.Context) error {
+		param := c.Param("postId")
+		postID, err := strconv.Atoi(param)
+		if err != nil || postID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid post id.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		err = bookmarksService.Remove(userID, postID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Removing the bookmark is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The bookmark is removed.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.GET("/users/bookmarks/collections", func(c echo.Context) error {
+		userID := c.Get("userID").(string)
+		collections, err := bookmarksService.Collections(userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the collections is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":      true,
+			"collections": collections,
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.POST("/users/bookmarks/collections", func(c echo.Context) error {
+		model := new(SaveCollectionModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		id, err := bookmarksService.CreateCollection(userID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Creating the collection is failed.",
+			})
+		}
+		return c.JSON(http.StatusCreated, echo.Map{
+			"status":  true,
+			"id":      id,
+			"message": "New collection is created.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.PUT("/users/bookmarks/collections/:id", func(c echo.Context) error {
+		model := new(SaveCollectionModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		param := c.Param("id")
+		id, err := strconv.Atoi(param)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid collection id.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		err = bookmarksService.RenameCollection(userID, id, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Renaming the collection is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The collection is renamed.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.DELETE("/users/bookmarks/collections/:id", func(c echo.Context) error {
+		param := c.Param("id")
+		id, err := strconv.Atoi(param)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid collection id.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		err = bookmarksService.DeleteCollection(userID, id)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Deleting the collection is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The collection is deleted.",
+		})
+	}, *controller.jwtMiddleware)
+}
@@ -0,0 +1,39 @@
+package bookmarks
+
+import (
+	"time"
+
+	"github.com/quavious/blog-factory-server/posts"
+)
+
+type AddBookmarkModel struct {
+	PostID int `json:"postId"`
+	// CollectionID is nil to keep the bookmark out of the collections.
+	CollectionID *int `json:"collectionId"`
+}
+
+type ListBookmarksModel struct {
+	// Collection is the id of a collection, or empty for every bookmark.
+	Collection string `query:"collection"`
+	Cursor     string `query:"cursor"`
+	Limit      int    `query:"limit"`
+}
+
+type BookmarkModel struct {
+	ID           int              `json:"id"`
+	PostID       int              `json:"postId"`
+	CollectionID *int             `json:"collectionId"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	Post         *posts.PostModel `json:"post,omitempty"`
+}
+
+type CollectionModel struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Count     int       `json:"count"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SaveCollectionModel struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,280 @@
+package bookmarks
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/posts"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+type BookmarksService struct {
+	config       *config.Config
+	repository   *db.Repository
+	postsService *posts.PostsService
+}
+
+func NewBookmarksService(config *config.Config, repository *db.Repository, stores store.Store, index *search.Index, mailClient *mail.MailClient) *BookmarksService {
+	return &BookmarksService{
+		config:       config,
+		repository:   repository,
+		postsService: posts.NewPostsService(config, repository, stores, index, mailClient),
+	}
+}
+
+// Add bookmarks the post, or moves the bookmark into the collection when the post is already bookmarked.
+func (service *BookmarksService) Add(userID string, model *AddBookmarkModel) (*BookmarkModel, error) {
+	bookmark := &BookmarkModel{PostID: model.PostID, CollectionID: model.CollectionID}
+	err := service.repository.Transaction(func(tx *db.Tx) error {
+		var isHidden bool
+		row := tx.QueryRow(`select is_hidden from posts where id = ?`, model.PostID)
+		err := row.Scan(&isHidden)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && isHidden) {
+			return fmt.Errorf("%w: post %d", db.ErrNotFound, model.PostID)
+		}
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		if model.CollectionID != nil {
+			err = checkCollection(tx, userID, *model.CollectionID)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(tx.Dialect().Upsert(`
+		insert into bookmarks (user_id, post_id, collection_id, created_at)
+		values (?, ?, ?, ?)`, []string{"user_id", "post_id"}, "collection_id = excluded.collection_id"), userID, model.PostID, model.CollectionID, time.Now().UTC())
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		row = tx.QueryRow(`select id, created_at from bookmarks where user_id = ? and post_id = ?`, userID, model.PostID)
+		err = row.Scan(&bookmark.ID, &bookmark.CreatedAt)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookmark, nil
+}
+
+// Remove deletes the bookmark of the post.
+func (service *BookmarksService) Remove(userID string, postID int) error {
+	res, err := service.repository.Exec(`delete from bookmarks where user_id = ? and post_id = ?`, userID, postID)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: post %d is not bookmarked", db.ErrNotFound, postID)
+	}
+	return nil
+}
+
+// List returns a page of the bookmarks of the user with their posts, the latest saved first.
+// The bookmarks of hidden posts are left out.
+func (service *BookmarksService) List(userID string, model *ListBookmarksModel) ([]BookmarkModel, *db.PageInfo, error) {
+	cursor, err := db.DecodeCursor(model.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	pageSize, maxPageSize := service.config.GetPageSize()
+	limit := db.PageLimit(model.Limit, pageSize, maxPageSize)
+	where := []string{"b.user_id = ?"}
+	args := []interface{}{userID}
+	if len(model.Collection) > 0 {
+		collectionID, err := strconv.Atoi(model.Collection)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid collection", db.ErrInvalid)
+		}
+		where = append(where, "b.collection_id = ?")
+		args = append(args, collectionID)
+	}
+	if cursor != nil {
+		where = append(where, "(b.created_at < ? or (b.created_at = ? and b.id < ?))")
+		args = append(args, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	args = append(args, limit+1)
+	rows, err := service.repository.Query(fmt.Sprintf(`
+	select b.id, b.post_id, b.collection_id, b.created_at
+	from bookmarks as b
+	join posts as p on b.post_id = p.id
+	where not p.is_hidden and %s
+	order by b.created_at desc, b.id desc
+	limit ?`, strings.Join(where, " and ")), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	bookmarks := []BookmarkModel{}
+	for rows.Next() {
+		bookmark := BookmarkModel{}
+		var collectionID sql.NullInt64
+		err := rows.Scan(&bookmark.ID, &bookmark.PostID, &collectionID, &bookmark.CreatedAt)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if collectionID.Valid {
+			id := int(collectionID.Int64)
+			bookmark.CollectionID = &id
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	rows.Close()
+	pageInfo := &db.PageInfo{Limit: limit}
+	if len(bookmarks) > limit {
+		bookmarks = bookmarks[:limit]
+		last := bookmarks[len(bookmarks)-1]
+		pageInfo.NextCursor = (&db.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+	}
+	ids := make([]int, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.PostID
+	}
+	loaded := service.postsService.PostsByIDs(ids, userID)
+	for i := range bookmarks {
+		bookmarks[i].Post = loaded[bookmarks[i].PostID]
+	}
+	return bookmarks, pageInfo, nil
+}
+
+// Collections returns the collections of the user with how many bookmarks of shown posts they
+// have, by name.
+func (service *BookmarksService) Collections(userID string) ([]CollectionModel, error) {
+	rows, err := service.repository.Query(`
+	select c.id, c.name, c.created_at, count(b.id)
+	from bookmark_collections as c
+	left join bookmarks as b on b.collection_id = c.id
+		and exists (select 1 from posts as p where p.id = b.post_id and not p.is_hidden)
+	where c.user_id = ?
+	group by c.id, c.name, c.created_at
+	order by c.name asc`, userID)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	collections := []CollectionModel{}
+	for rows.Next() {
+		collection := CollectionModel{}
+		err := rows.Scan(&collection.ID, &collection.Name, &collection.CreatedAt, &collection.Count)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+// CreateCollection makes a named collection and returns its id. The names of a user are unique.
+func (service *BookmarksService) CreateCollection(userID string, model *SaveCollectionModel) (int, error) {
+	name, err := collectionName(model.Name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := db.Insert(service.repository, service.repository.Dialect().InsertIgnore(`
+	insert into bookmark_collections (user_id, name, created_at)
+	values (?, ?, ?)`), userID, name, time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: the collection %q exists", db.ErrConflict, name)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return int(id), nil
+}
+
+// RenameCollection changes the name of a collection of the user.
+func (service *BookmarksService) RenameCollection(userID string, id int, model *SaveCollectionModel) error {
+	name, err := collectionName(model.Name)
+	if err != nil {
+		return err
+	}
+	return service.repository.Transaction(func(tx *db.Tx) error {
+		err := checkCollection(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		var existing int
+		row := tx.QueryRow(fmt.Sprintf(`select count(*) from bookmark_collections where user_id = ? and %s = ? and id <> ?`, tx.Dialect().Fold("name")), userID, strings.ToLower(name), id)
+		err = row.Scan(&existing)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("%w: the collection %q exists", db.ErrConflict, name)
+		}
+		_, err = tx.Exec(`update bookmark_collections set name = ? where id = ?`, name, id)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return nil
+	})
+}
+
+// DeleteCollection deletes a collection of the user. Its bookmarks are kept out of the collections.
+func (service *BookmarksService) DeleteCollection(userID string, id int) error {
+	return service.repository.Transaction(func(tx *db.Tx) error {
+		err := checkCollection(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`update bookmarks set collection_id = null where collection_id = ?`, id)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		_, err = tx.Exec(`delete from bookmark_collections where id = ?`, id)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return nil
+	})
+}
+
+func checkCollection(executor db.Executor, userID string, id int) error {
+	var ownerID string
+	row := executor.QueryRow(`select user_id from bookmark_collections where id = ?`, id)
+	err := row.Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && ownerID != userID) {
+		return fmt.Errorf("%w: collection %d", db.ErrNotFound, id)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+func collectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 || len([]rune(name)) > 50 {
+		return "", fmt.Errorf("%w: the name must have 1 to 50 characters", db.ErrInvalid)
+	}
+	return name, nil
+}
@@ -1,38 +1,83 @@
 package comments
 
 import (
-	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/store"
 )
 
 type CommentsController struct {
 	*echo.Echo
-	config        *config.Config
-	repository    *db.Repository
-	jwtMiddleware *echo.MiddlewareFunc
+	config                *config.Config
+	repository            *db.Repository
+	stores                store.Store
+	jwtMiddleware         *echo.MiddlewareFunc
+	optionalJWTMiddleware *echo.MiddlewareFunc
+	adminMiddleware       *echo.MiddlewareFunc
+	mailClient            *mail.MailClient
 }
 
 func NewCommentsController(
 	echo *echo.Echo,
 	config *config.Config,
 	repository *db.Repository,
+	stores store.Store,
 	jwtMiddleware *echo.MiddlewareFunc,
+	optionalJWTMiddleware *echo.MiddlewareFunc,
+	adminMiddleware *echo.MiddlewareFunc,
+	mailClient *mail.MailClient,
 ) *CommentsController {
 	return &CommentsController{
-		Echo:          echo,
-		config:        config,
-		repository:    repository,
-		jwtMiddleware: jwtMiddleware,
+		Echo:                  echo,
+		config:                config,
+		repository:            repository,
+		stores:                stores,
+		jwtMiddleware:         jwtMiddleware,
+		optionalJWTMiddleware: optionalJWTMiddleware,
+		adminMiddleware:       adminMiddleware,
+		mailClient:            mailClient,
 	}
 }
 
 func (controller *CommentsController) UseRoute() {
-	commentsService := NewCommentsService(controller.config, controller.repository)
+	commentsService := NewCommentsService(controller.config, controller.repository, controller.stores, controller.mailClient)
+	controller.GET("/posts/:id/comments", func(c echo.Context) error {
+		param := c.Param("id")
+		postID, err := strconv.Atoi(param)
+		if err != nil || postID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid post id.",
+			})
+		}
+		model := new(ListCommentsModel)
+		err = c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		userID, _ := c.Get("userID").(string)
+		comments, pageInfo, err := commentsService.List(postID, model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the comments is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":   true,
+			"comments": View(comments, c.QueryParam("view")),
+			"page":     pageInfo,
+		})
+	}, *controller.optionalJWTMiddleware)
+
 	controller.POST("/comments", func(c echo.Context) error {
 		model := new(CreateCommentModel)
 		err := c.Bind(model)
@@ -42,18 +87,21 @@ func (controller *CommentsController) UseRoute() {
 				Message: "Invalid data form.",
 			})
 		}
+		model.IP = c.RealIP()
+		model.UserAgent = c.Request().UserAgent()
 		userID := c.Get("userID").(string)
-		comments := commentsService.Create(model, userID)
-		if comments == nil {
-			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+		comment, err := commentsService.Create(model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
 				Status:  false,
 				Message: "Creating new comment is failed.",
+				Code:    ErrorCode(err),
 			})
 		}
 		return c.JSON(http.StatusCreated, echo.Map{
-			"status":   true,
-			"comments": comments,
-			"message":  "New comment is created.",
+			"status":  true,
+			"comment": comment,
+			"message": "New comment is created.",
 		})
 	}, *controller.jwtMiddleware)
 
@@ -75,17 +123,18 @@ func (controller *CommentsController) UseRoute() {
 			})
 		}
 		userID := c.Get("userID").(string)
-		comments := commentsService.Update(model, id, userID)
-		if comments == nil {
-			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+		comment, err := commentsService.Update(model, id, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
 				Status:  false,
-				Message: "Deleting the comment is failed.",
+				Message: "Updating the comment is failed.",
+				Code:    ErrorCode(err),
 			})
 		}
 		return c.JSON(http.StatusCreated, echo.Map{
-			"status":   true,
-			"comments": comments,
-			"message":  "New comment is updated.",
+			"status":  true,
+			"comment": comment,
+			"message": "The comment is updated.",
 		})
 	}, *controller.jwtMiddleware)
 
@@ -98,27 +147,148 @@ func (controller *CommentsController) UseRoute() {
 				Message: "Invalid comment id.",
 			})
 		}
-		model := new(DeleteCommentModel)
-		err = c.Bind(model)
+		userID := c.Get("userID").(string)
+		comment, err := commentsService.Delete(id, userID)
 		if err != nil {
-			log.Println(err.Error())
-			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
 				Status:  false,
-				Message: "Invalid post id.",
+				Message: "Deleting the comment is failed.",
 			})
 		}
-		userID := c.Get("userID").(string)
-		comments := commentsService.Delete(model, id, userID)
-		if comments == nil {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"comment": comment,
+			"message": "The comment is deleted.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.GET("/comments/moderation", func(c echo.Context) error {
+		pageSize, maxPageSize := controller.config.GetPageSize()
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		comments, err := commentsService.Queue(db.PageLimit(limit, pageSize, maxPageSize))
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the moderation queue is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":   true,
+			"comments": comments,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/comments/moderation", func(c echo.Context) error {
+		model := new(ModerateCommentsModel)
+		err := c.Bind(model)
+		if err != nil {
 			return c.JSON(http.StatusBadRequest, &db.BadResponse{
 				Status:  false,
-				Message: "Deleting the comment is failed.",
+				Message: "Invalid data form.",
+			})
+		}
+		moderated, err := commentsService.Moderate(model, c.Get("userID").(string))
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Moderating the comments is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":    true,
+			"moderated": moderated,
+			"message":   "The comments are moderated.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	for _, action := range []string{ActionApprove, ActionReject} {
+		action := action
+		controller.POST("/comments/:id/"+action, func(c echo.Context) error {
+			param := c.Param("id")
+			id, err := strconv.Atoi(param)
+			if err != nil || id < 1 {
+				return c.JSON(http.StatusBadRequest, &db.BadResponse{
+					Status:  false,
+					Message: "Invalid comment id.",
+				})
+			}
+			moderated, err := commentsService.Moderate(&ModerateCommentsModel{IDs: []int{id}, Action: action}, c.Get("userID").(string))
+			if err != nil {
+				return c.JSON(db.StatusCode(err), &db.BadResponse{
+					Status:  false,
+					Message: "Moderating the comment is failed.",
+				})
+			}
+			if moderated == 0 {
+				return c.JSON(http.StatusNotFound, &db.BadResponse{
+					Status:  false,
+					Message: "No pending comments.",
+				})
+			}
+			return c.JSON(http.StatusOK, echo.Map{
+				"status":  true,
+				"message": "The comment is moderated.",
+			})
+		}, *controller.jwtMiddleware, *controller.adminMiddleware)
+	}
+
+	controller.GET("/comments/spam", func(c echo.Context) error {
+		pageSize, maxPageSize := controller.config.GetPageSize()
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		comments, err := commentsService.SpamFolder(db.PageLimit(limit, pageSize, maxPageSize))
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the spam folder is failed.",
 			})
 		}
 		return c.JSON(http.StatusOK, echo.Map{
 			"status":   true,
 			"comments": comments,
-			"message":  "The comment is deleted.",
 		})
-	}, *controller.jwtMiddleware)
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/comments/:id/spam", func(c echo.Context) error {
+		param := c.Param("id")
+		id, err := strconv.Atoi(param)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid comment id.",
+			})
+		}
+		err = commentsService.MarkSpam(id)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Marking the comment as spam is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The comment is marked as spam.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/comments/:id/ham", func(c echo.Context) error {
+		param := c.Param("id")
+		id, err := strconv.Atoi(param)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid comment id.",
+			})
+		}
+		err = commentsService.MarkHam(id)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Marking the comment as ham is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The comment is marked as ham.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
 }
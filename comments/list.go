@@ -0,0 +1,182 @@
+package comments
+
+import (
+	"fmt"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// Sorts of the comment list.
+const (
+	SortOldest = "oldest"
+	SortNewest = "newest"
+	SortTop    = "top"
+	SortLiked  = "liked"
+)
+
+// visible narrows the query to the comments which the viewer may see.
+func visible(query *store.CommentQuery, viewer *Viewer) *store.CommentQuery {
+	if viewer.IsModerator {
+		return query
+	}
+	query.Statuses = []string{StatusApproved}
+	query.AuthorID = viewer.UserID
+	query.AuthorStatuses = []string{StatusPending}
+	return query
+}
+
+// commentModels returns the stored comments with the usernames of their authors. The content
+// of a deleted comment is replaced by the placeholder.
+func (service *CommentsService) commentModels(stored []store.Comment) (CommentArray, error) {
+	userIDs := []string{}
+	for _, comment := range stored {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	usernames, err := service.stores.Users().Usernames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	comments := CommentArray{}
+	for _, comment := range stored {
+		model := CommentModel{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+			Username:  usernames[comment.UserID],
+			ParentID:  comment.ParentID,
+			Depth:     comment.Depth,
+			Deleted:   comment.Deleted,
+			Status:    comment.Status,
+		}
+		if model.Deleted {
+			model.Content = deletedContent
+			model.Username = ""
+		}
+		comments = append(comments, model)
+	}
+	return comments, nil
+}
+
+// Comment returns one comment as the viewer sees it.
+func (service *CommentsService) Comment(id int, viewer *Viewer) (*CommentModel, error) {
+	stored, err := service.stores.Comments().ByID(id)
+	if err != nil {
+		return nil, err
+	}
+	shown := viewer.IsModerator || stored.Status == StatusApproved || (stored.Status == StatusPending && stored.UserID == viewer.UserID)
+	if !shown {
+		return nil, fmt.Errorf("%w: comment %d", db.ErrNotFound, id)
+	}
+	rendered, err := service.commentModels([]store.Comment{*stored})
+	if err != nil {
+		return nil, err
+	}
+	service.render(rendered)
+	service.react(rendered, viewer)
+	return &rendered[0], nil
+}
+
+// List returns a page of the top-level comments of the post with all of their replies, in thread order.
+// The oldest and newest sorts page by keyset. The top sort, which puts the most replied comments first,
+// and the liked sort, which puts the comments with the most reactions first, page by offset.
+func (service *CommentsService) List(postID int, model *ListCommentsModel, userID string) (CommentArray, *db.PageInfo, error) {
+	pageSize, maxPageSize := service.config.GetPageSize()
+	limit := db.PageLimit(model.Limit, pageSize, maxPageSize)
+	pageInfo := &db.PageInfo{Limit: limit}
+	cursor, err := db.DecodeCursor(model.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	viewer := service.Viewer(userID)
+	status, err := service.postCommentStatus(postID, viewer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status == CommentsDisabled {
+		return CommentArray{}, pageInfo, nil
+	}
+
+	query := visible(&store.CommentQuery{PostID: postID, Roots: true, Limit: limit + 1}, viewer)
+	switch model.Sort {
+	case "", SortOldest:
+		query.Order = store.OrderOldest
+	case SortNewest:
+		query.Order = store.OrderLatest
+	case SortTop:
+		query.Order = store.OrderReplied
+		query.ReplyStatus = StatusApproved
+	case SortLiked:
+		query.Order = store.OrderLiked
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown sort %q", db.ErrInvalid, model.Sort)
+	}
+	ranked := model.Sort == SortTop || model.Sort == SortLiked
+	if cursor != nil {
+		if ranked {
+			query.Offset = cursor.Offset
+		} else {
+			query.After = &store.Key{CreatedAt: cursor.CreatedAt, ID: cursor.ID}
+		}
+	}
+	stored, err := service.stores.Comments().List(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	roots, err := service.commentModels(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(roots) > limit {
+		roots = roots[:limit]
+		last := roots[len(roots)-1]
+		next := &db.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		if ranked {
+			next = &db.Cursor{Offset: query.Offset + limit}
+		}
+		pageInfo.NextCursor = next.Encode()
+	}
+	replies, err := service.replies(roots, viewer)
+	if err != nil {
+		return nil, nil, err
+	}
+	thread := Thread(append(roots, replies...))
+	service.render(thread)
+	service.react(thread, viewer)
+	return thread, pageInfo, nil
+}
+
+// replies loads the replies of the comments one depth at a time.
+func (service *CommentsService) replies(parents CommentArray, viewer *Viewer) (CommentArray, error) {
+	replies := CommentArray{}
+	for len(parents) > 0 {
+		parentIDs := []int{}
+		for _, parent := range parents {
+			parentIDs = append(parentIDs, parent.ID)
+		}
+		stored, err := service.stores.Comments().List(visible(&store.CommentQuery{ParentIDs: parentIDs}, viewer))
+		if err != nil {
+			return nil, err
+		}
+		parents, err = service.commentModels(stored)
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, parents...)
+	}
+	return replies, nil
+}
+
+// postCommentStatus returns the comment status of the post. A hidden post is found only by the moderators.
+func (service *CommentsService) postCommentStatus(postID int, viewer *Viewer) (string, error) {
+	post, err := service.stores.Posts().ByID(postID)
+	if err != nil {
+		return "", err
+	}
+	if post.IsHidden && !viewer.IsModerator {
+		return "", fmt.Errorf("%w: post %d", db.ErrNotFound, postID)
+	}
+	return service.settingsStatus(PostSettings(post)), nil
+}
@@ -1,10 +1,19 @@
 package comments
 
-import "time"
+import (
+	"time"
+
+	"github.com/quavious/blog-factory-server/reactions"
+)
 
 type CreateCommentModel struct {
-	Content string `json:"content"`
-	PostID  int    `json:"postId"`
+	Content  string `json:"content"`
+	PostID   int    `json:"postId"`
+	ParentID *int   `json:"parentId"`
+	// Honeypot is a form field hidden from people, so only bots fill it.
+	Honeypot  string `json:"website"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type UpdateCommentModel struct {
@@ -12,16 +21,31 @@ type UpdateCommentModel struct {
 	PostID  int    `json:"postId"`
 }
 
-type DeleteCommentModel struct {
-	PostID int `json:"postId"`
+type ListCommentsModel struct {
+	Sort   string `query:"sort"`
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit"`
+}
+
+type ModerateCommentsModel struct {
+	IDs    []int  `json:"ids"`
+	Action string `json:"action"`
 }
 
 type CommentArray []CommentModel
 
 type CommentModel struct {
-	ID        int       `json:"id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Username  string    `json:"username"`
+	ID          int                       `json:"id"`
+	PostID      int                       `json:"postId"`
+	Content     string                    `json:"content"`
+	ContentHTML string                    `json:"contentHtml"`
+	CreatedAt   time.Time                 `json:"createdAt"`
+	UpdatedAt   time.Time                 `json:"updatedAt"`
+	Username    string                    `json:"username"`
+	ParentID    *int                      `json:"parentId"`
+	Depth       int                       `json:"depth"`
+	Deleted     bool                      `json:"deleted"`
+	Status      string                    `json:"status"`
+	Reactions   []reactions.ReactionModel `json:"reactions"`
+	Replies     CommentArray              `json:"replies,omitempty"`
 }
@@ -0,0 +1,210 @@
+package comments
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+const (
+	ModerationOpen  = "open"
+	ModerationFirst = "first"
+	ModerationAll   = "all"
+)
+
+const (
+	StatusApproved = "approved"
+	StatusPending  = "pending"
+	StatusRejected = "rejected"
+	StatusSpam     = "spam"
+	// StatusHidden is an approved comment hidden after readers reported it.
+	StatusHidden = "hidden"
+)
+
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+)
+
+// IsModerationMode reports whether the mode is one of the moderation modes.
+func IsModerationMode(mode string) bool {
+	return mode == ModerationOpen || mode == ModerationFirst || mode == ModerationAll
+}
+
+// Viewer is the user who reads the comments. Pending comments are shown only to
+// their author and to the moderators.
+type Viewer struct {
+	UserID      string
+	IsModerator bool
+	IsSuspended bool
+}
+
+func (service *CommentsService) Viewer(userID string) *Viewer {
+	return LookupViewer(service.stores.Users(), userID)
+}
+
+// LookupViewer looks up whether the user is a moderator. An empty id is an anonymous viewer.
+func LookupViewer(users store.UserStore, userID string) *Viewer {
+	viewer := &Viewer{UserID: userID}
+	if len(userID) == 0 {
+		return viewer
+	}
+	user, err := users.ByID(userID)
+	if err != nil {
+		return viewer
+	}
+	viewer.IsModerator = user.IsAdmin
+	viewer.IsSuspended = user.IsSuspended
+	return viewer
+}
+
+// moderationMode returns the moderation mode of the post, falling back to the site mode.
+func (service *CommentsService) moderationMode(post *store.Post) string {
+	mode := post.CommentModeration
+	if mode.Valid && IsModerationMode(mode.String) {
+		return mode.String
+	}
+	if IsModerationMode(service.config.GetCommentModeration()) {
+		return service.config.GetCommentModeration()
+	}
+	return ModerationOpen
+}
+
+// newCommentStatus decides whether a new comment of the viewer waits for approval.
+func (service *CommentsService) newCommentStatus(comments store.CommentStore, post *store.Post, viewer *Viewer) (string, error) {
+	mode := service.moderationMode(post)
+	if viewer.IsModerator || mode == ModerationOpen {
+		return StatusApproved, nil
+	}
+	if mode == ModerationAll {
+		return StatusPending, nil
+	}
+	approved, err := comments.CountByUser(viewer.UserID, StatusApproved, time.Time{})
+	if err != nil {
+		return "", err
+	}
+	if approved > 0 {
+		return StatusApproved, nil
+	}
+	return StatusPending, nil
+}
+
+// notifyPending tells the author of the post that the comment of the user waits for approval.
+func (service *CommentsService) notifyPending(id int, postID int, userID string) {
+	post, err := service.stores.Posts().ByID(postID)
+	if err != nil {
+		return
+	}
+	service.notifications.Notify(notifications.Event{
+		Type:      notifications.TypePending,
+		UserID:    post.UserID,
+		ActorID:   userID,
+		PostID:    postID,
+		CommentID: id,
+		Message:   fmt.Sprintf("A comment on \"%s\" is waiting for approval.", post.Title),
+	})
+}
+
+// Queue returns the pending comments of every post, the oldest first.
+func (service *CommentsService) Queue(limit int) (CommentArray, error) {
+	return service.commentsWithStatus(StatusPending, store.OrderOldest, limit)
+}
+
+func (service *CommentsService) commentsWithStatus(status string, order string, limit int) (CommentArray, error) {
+	stored, err := service.stores.Comments().List(&store.CommentQuery{
+		Statuses: []string{status},
+		Order:    order,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.commentModels(stored)
+}
+
+// Moderate approves or rejects the pending comments and returns how many were changed.
+// The authors of the changed comments are notified of the result, and the approved comments
+// are announced like new ones.
+func (service *CommentsService) Moderate(model *ModerateCommentsModel, moderatorID string) (int64, error) {
+	status := ""
+	switch model.Action {
+	case ActionApprove:
+		status = StatusApproved
+	case ActionReject:
+		status = StatusRejected
+	default:
+		return 0, fmt.Errorf("%w: unknown action %q", db.ErrInvalid, model.Action)
+	}
+	if len(model.IDs) == 0 {
+		return 0, fmt.Errorf("%w: no comments", db.ErrInvalid)
+	}
+	pending := []int{}
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		for _, id := range model.IDs {
+			changed, err := stores.Comments().ChangeStatus(id, StatusPending, status)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			pending = append(pending, id)
+			comment, err := stores.Comments().ByID(id)
+			if err != nil {
+				return err
+			}
+			err = service.dispatchCreated(tx, comment)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range pending {
+		service.notifyModerated(id, status, moderatorID)
+		if status == StatusApproved {
+			service.announce(id, false)
+		}
+	}
+	return int64(len(pending)), nil
+}
+
+// Hide hides an approved comment from everyone except the moderators.
+func Hide(comments store.CommentStore, id int) error {
+	_, err := comments.ChangeStatus(id, StatusApproved, StatusHidden)
+	return err
+}
+
+// Restore shows a hidden comment again.
+func Restore(comments store.CommentStore, id int) error {
+	_, err := comments.ChangeStatus(id, StatusHidden, StatusApproved)
+	return err
+}
+
+// Remove deletes the comment of any author like Delete does. It is used by the moderators.
+// The executor removes the mentions and the reactions of the comment.
+func Remove(executor db.Executor, comments store.CommentStore, id int) error {
+	comment, err := comments.ByID(id)
+	if err != nil {
+		return err
+	}
+	if comment.Deleted {
+		return nil
+	}
+	return removeComment(executor, comments, comment)
+}
+
+// Author returns the post and the author of the comment.
+func Author(comments store.CommentStore, id int) (int, string, error) {
+	comment, err := comments.ByID(id)
+	if err != nil {
+		return 0, "", err
+	}
+	return comment.PostID, comment.UserID, nil
+}
@@ -0,0 +1,121 @@
+package comments
+
+import (
+	"fmt"
+
+	"github.com/quavious/blog-factory-server/mentions"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/reactions"
+)
+
+// announce notifies the users concerned by an approved comment: the mentioned users, the author of
+// the parent comment and the author of the post, each once. Comments waiting for moderation announce
+// nothing until they are approved. An edit only notifies the users who are mentioned for the first time.
+func (service *CommentsService) announce(id int, edited bool) {
+	comment, err := service.stores.Comments().ByID(id)
+	if err != nil || comment.Deleted || comment.Status != StatusApproved {
+		return
+	}
+	author, err := service.stores.Users().ByID(comment.UserID)
+	if err != nil {
+		return
+	}
+	post, err := service.stores.Posts().ByID(comment.PostID)
+	if err != nil {
+		return
+	}
+	parentAuthorID := ""
+	if comment.ParentID != nil {
+		parent, err := service.stores.Comments().ByID(*comment.ParentID)
+		if err == nil {
+			parentAuthorID = parent.UserID
+		}
+	}
+	mentioned, err := mentions.Record(service.repository, mentions.SourceComment, id, comment.UserID, comment.Content)
+	if err != nil {
+		return
+	}
+	notified := map[string]bool{}
+	events := []notifications.Event{}
+	add := func(eventType string, recipientID string, message string) {
+		if notified[recipientID] {
+			return
+		}
+		notified[recipientID] = true
+		events = append(events, notifications.Event{
+			Type:      eventType,
+			UserID:    recipientID,
+			ActorID:   comment.UserID,
+			PostID:    post.ID,
+			CommentID: id,
+			Message:   message,
+		})
+	}
+	for _, mentionedID := range mentioned {
+		add(notifications.TypeMention, mentionedID, fmt.Sprintf("%s mentioned you in a comment on \"%s\".", author.Username, post.Title))
+	}
+	if !edited {
+		if len(parentAuthorID) > 0 {
+			add(notifications.TypeReply, parentAuthorID, fmt.Sprintf("%s replied to your comment on \"%s\".", author.Username, post.Title))
+		}
+		add(notifications.TypeComment, post.UserID, fmt.Sprintf("%s commented on \"%s\".", author.Username, post.Title))
+	}
+	service.notifications.Notify(events...)
+}
+
+// notifyModerated tells the author of the comment whether the moderators approved or rejected it.
+func (service *CommentsService) notifyModerated(id int, status string, moderatorID string) {
+	comment, err := service.stores.Comments().ByID(id)
+	if err != nil {
+		return
+	}
+	post, err := service.stores.Posts().ByID(comment.PostID)
+	if err != nil {
+		return
+	}
+	service.notifications.Notify(notifications.Event{
+		Type:      notifications.TypeModeration,
+		UserID:    comment.UserID,
+		ActorID:   moderatorID,
+		PostID:    post.ID,
+		CommentID: id,
+		Message:   fmt.Sprintf("Your comment on \"%s\" was %s.", post.Title, status),
+	})
+}
+
+// render links the mentions in the content of the comments.
+func (service *CommentsService) render(comments CommentArray) {
+	ids := []int{}
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	usernames, err := mentions.Usernames(service.repository, mentions.SourceComment, ids)
+	if err != nil {
+		usernames = nil
+	}
+	for i := range comments {
+		if comments[i].Deleted {
+			comments[i].ContentHTML = comments[i].Content
+			continue
+		}
+		comments[i].ContentHTML = mentions.Render(comments[i].Content, usernames[comments[i].ID], service.config.GetSiteURL())
+	}
+}
+
+// react loads the reaction counts of the comments and marks the reactions of the viewer.
+func (service *CommentsService) react(comments CommentArray, viewer *Viewer) {
+	ids := []int{}
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	loaded, err := reactions.Load(service.repository, reactions.TargetComment, ids, viewer.UserID)
+	if err != nil {
+		loaded = nil
+	}
+	for i := range comments {
+		comments[i].Reactions = loaded[comments[i].ID]
+		if comments[i].Reactions == nil {
+			comments[i].Reactions = []reactions.ReactionModel{}
+		}
+	}
+}
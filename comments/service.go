@@ -1,95 +1,249 @@
 package comments
 
 import (
-	"log"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/mentions"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/reactions"
+	"github.com/quavious/blog-factory-server/spam"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/webhooks"
 )
 
 type CommentsService struct {
-	config     *config.Config
-	repository *db.Repository
+	config        *config.Config
+	repository    *db.Repository
+	stores        store.Store
+	classifier    spam.Classifier
+	notifications *notifications.NotificationsService
 }
 
-func NewCommentsService(config *config.Config, repository *db.Repository) *CommentsService {
+func NewCommentsService(config *config.Config, repository *db.Repository, stores store.Store, mailClient *mail.MailClient) *CommentsService {
 	return &CommentsService{
-		config:     config,
-		repository: repository,
+		config:        config,
+		repository:    repository,
+		stores:        stores,
+		classifier:    spam.NewClassifier(config),
+		notifications: notifications.NewNotificationsService(config, repository, mailClient),
 	}
 }
 
-func (service *CommentsService) getComments(postID int) *CommentArray {
-	rows, err := service.repository.Query(`
-	select c.id, c.content, c.created_at, c.updated_at, u.username
-	from comments as c
-	join posts as p on c.post_id = p.id
-	join users as u on c.user_id = u.id
-	where c.post_id = ?
-	order by c.created_at asc
-	`, postID)
-	if err != nil {
-		log.Println(err.Error())
-		return nil
+// Create writes the comment and returns it.
+func (service *CommentsService) Create(model *CreateCommentModel, userID string) (*CommentModel, error) {
+	if len(strings.TrimSpace(model.Content)) == 0 {
+		return nil, fmt.Errorf("%w: the comment is empty", db.ErrInvalid)
 	}
-	comments := new(CommentArray)
-	for rows.Next() {
-		comment := new(CommentModel)
-		err := rows.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt, &comment.Username)
+	viewer := service.Viewer(userID)
+	if viewer.IsSuspended {
+		return nil, fmt.Errorf("%w: the user is suspended", db.ErrForbidden)
+	}
+	verdict := &spam.Verdict{}
+	if !viewer.IsModerator {
+		var err error
+		verdict, err = service.classify(model, userID)
 		if err != nil {
-			log.Println(err.Error())
-		} else {
-			*comments = append(*comments, *comment)
+			return nil, err
 		}
 	}
-	return comments
+	status := ""
+	var id int
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		post, err := stores.Posts().ByID(model.PostID)
+		if err != nil {
+			return err
+		}
+		err = service.checkOpen(post, viewer)
+		if err != nil {
+			return err
+		}
+		status, err = service.newCommentStatus(stores.Comments(), post, viewer)
+		if err != nil {
+			return err
+		}
+		if verdict.Spam {
+			status = StatusSpam
+		}
+		depth := 0
+		if model.ParentID != nil {
+			parent, err := stores.Comments().ByID(*model.ParentID)
+			if err != nil {
+				return err
+			}
+			if parent.PostID != model.PostID {
+				return fmt.Errorf("%w: the parent comment is on another post", db.ErrInvalid)
+			}
+			if parent.Deleted || parent.Status != StatusApproved {
+				return fmt.Errorf("%w: the parent comment is not open for replies", db.ErrInvalid)
+			}
+			depth = parent.Depth + 1
+			if depth > service.config.GetCommentMaxDepth() {
+				return fmt.Errorf("%w: the thread is too deep", db.ErrInvalid)
+			}
+		}
+		createdAt := time.Now().UTC()
+		comment := &store.Comment{
+			PostID:    model.PostID,
+			ParentID:  model.ParentID,
+			UserID:    userID,
+			Content:   model.Content,
+			Depth:     depth,
+			Status:    status,
+			IP:        model.IP,
+			UserAgent: model.UserAgent,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		id, err = stores.Comments().Create(comment)
+		if err != nil {
+			return err
+		}
+		comment.ID = id
+		return service.dispatchCreated(tx, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status == StatusPending {
+		service.notifyPending(id, model.PostID, userID)
+	}
+	service.announce(id, false)
+	return service.Comment(id, viewer)
 }
 
-func (service *CommentsService) Create(model *CreateCommentModel, userID string) *CommentArray {
-	createdAt := time.Now().UTC()
-	res, err := service.repository.Exec(`insert into comments (content, post_id, created_at, updated_at, user_id) values (?, ?, ?, ?, ?)`, model.Content, model.PostID, createdAt, createdAt, userID)
-	if err != nil {
-		log.Println(err.Error())
+// dispatchCreated sends the comment.created event of an approved comment. A comment which waits
+// for approval or is classified as spam sends it when it is approved.
+func (service *CommentsService) dispatchCreated(executor db.Executor, comment *store.Comment) error {
+	if comment.Status != StatusApproved {
 		return nil
 	}
-	inserted, err := res.LastInsertId()
+	return webhooks.Dispatch(executor, webhooks.EventCommentCreated, &webhooks.CommentData{
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		UserID:    comment.UserID,
+		Content:   comment.Content,
+		Status:    comment.Status,
+		URL:       notifications.Link(service.config, comment.PostID, comment.ID),
+		CreatedAt: comment.CreatedAt,
+	})
+}
+
+// Update edits the comment and returns it.
+func (service *CommentsService) Update(model *UpdateCommentModel, id int, userID string) (*CommentModel, error) {
+	if len(strings.TrimSpace(model.Content)) == 0 {
+		return nil, fmt.Errorf("%w: the comment is empty", db.ErrInvalid)
+	}
+	viewer := service.Viewer(userID)
+	if viewer.IsSuspended {
+		return nil, fmt.Errorf("%w: the user is suspended", db.ErrForbidden)
+	}
+	comment, err := service.stores.Comments().ByID(id)
 	if err != nil {
-		log.Println(err.Error())
-		return nil
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, fmt.Errorf("%w: comment %d is not written by this user", db.ErrForbidden, id)
+	}
+	if comment.Deleted {
+		return nil, fmt.Errorf("%w: comment %d is deleted", db.ErrNotFound, id)
+	}
+	post, err := service.stores.Posts().ByID(comment.PostID)
+	if err != nil {
+		return nil, err
 	}
-	log.Println(inserted)
-	comments := service.getComments(model.PostID)
-	return comments
+	err = service.checkOpen(post, viewer)
+	if err != nil {
+		return nil, err
+	}
+	err = service.stores.Comments().UpdateContent(id, model.Content, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	service.announce(id, true)
+	return service.Comment(id, viewer)
 }
 
-func (service *CommentsService) Update(model *UpdateCommentModel, id int, userID string) *CommentArray {
-	updatedAt := time.Now().UTC()
-	res, err := service.repository.Exec(`update comments set content = ?, updated_at = ? where id = ? and user_id = ?`, model.Content, updatedAt, id, userID)
+// Delete removes the comment. A comment with replies is kept as a "[deleted]" placeholder
+// so that the thread stays intact, and placeholders left without replies are removed.
+// It returns the comment as a placeholder.
+func (service *CommentsService) Delete(id int, userID string) (*CommentModel, error) {
+	var deleted *CommentModel
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		comment, err := stores.Comments().ByID(id)
+		if err != nil {
+			return err
+		}
+		if comment.UserID != userID {
+			return fmt.Errorf("%w: comment %d is not written by this user", db.ErrForbidden, id)
+		}
+		if comment.Deleted {
+			return fmt.Errorf("%w: comment %d is deleted", db.ErrNotFound, id)
+		}
+		deleted = &CommentModel{
+			ID:       comment.ID,
+			PostID:   comment.PostID,
+			Content:  deletedContent,
+			ParentID: comment.ParentID,
+			Depth:    comment.Depth,
+			Deleted:  true,
+			Status:   comment.Status,
+		}
+		return removeComment(tx, stores.Comments(), comment)
+	})
 	if err != nil {
-		log.Println(err.Error())
-		return nil
+		return nil, err
 	}
-	updated, err := res.RowsAffected()
-	if err != nil || updated == 0 {
-		log.Println(err.Error())
-		return nil
-	}
-	comments := service.getComments(model.PostID)
-	return comments
+	return deleted, nil
 }
 
-func (service *CommentsService) Delete(model *DeleteCommentModel, id int, userID string) *CommentArray {
-	res, err := service.repository.Exec(`delete from comments where id = ? and user_id = ?`, id, userID)
+// removeComment removes the mentions and the reactions of the comment and collapses it.
+func removeComment(executor db.Executor, comments store.CommentStore, comment *store.Comment) error {
+	err := mentions.Remove(executor, mentions.SourceComment, comment.ID)
 	if err != nil {
-		log.Println(err.Error())
-		return nil
+		return err
 	}
-	deleted, err := res.RowsAffected()
-	if err != nil || deleted == 0 {
-		log.Println(err.Error())
-		return nil
+	err = reactions.Remove(executor, reactions.TargetComment, comment.ID)
+	if err != nil {
+		return err
+	}
+	return collapse(comments, comment)
+}
+
+// collapse deletes the comment, or marks it deleted when it has replies. Then it deletes the
+// ancestors which are marked deleted and have no replies left.
+func collapse(comments store.CommentStore, comment *store.Comment) error {
+	for comment != nil {
+		replies, err := comments.CountReplies(comment.ID)
+		if err != nil {
+			return err
+		}
+		if replies > 0 {
+			if comment.Deleted {
+				return nil
+			}
+			return comments.MarkDeleted(comment.ID)
+		}
+		err = comments.Delete(comment.ID)
+		if err != nil {
+			return err
+		}
+		if comment.ParentID == nil {
+			return nil
+		}
+		parent, err := comments.ByID(*comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if !parent.Deleted {
+			return nil
+		}
+		comment = parent
 	}
-	comments := service.getComments(model.PostID)
-	return comments
+	return nil
 }
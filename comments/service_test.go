@@ -0,0 +1,343 @@
+package comments
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/migrations"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/webhooks"
+)
+
+// newTestService returns the service on a memory store with the users alice, bob, carol and the
+// moderator mod, a post of alice and a post of alice whose first comments wait for approval.
+// The tables of the other domains are in a new SQLite database.
+func newTestService(t *testing.T) (*CommentsService, store.Store, int, int) {
+	t.Helper()
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "comments.db"))
+	t.Setenv("COMMENT_MAX_DEPTH", "1")
+	config := config.FromEnv()
+	repository := db.NewRepository(config)
+	if repository == nil {
+		t.Fatal("the database is not opened")
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := store.NewMemoryStore()
+	registered := time.Now().UTC().Add(-24 * time.Hour)
+	for _, name := range []string{"alice", "bob", "carol", "mod"} {
+		err := stores.Users().Create(&store.User{ID: name, Email: name + "@example.com", Username: name, IsAdmin: name == "mod", CreatedAt: registered})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC()
+	postID, err := stores.Posts().Create(&store.Post{Title: "Open", UserID: "alice", CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moderatedID, err := stores.Posts().Create(&store.Post{
+		Title:             "Moderated",
+		UserID:            "alice",
+		CommentModeration: sql.NullString{String: ModerationFirst, Valid: true},
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCommentsService(config, repository, stores, nil), stores, postID, moderatedID
+}
+
+func TestCreate(t *testing.T) {
+	service, _, postID, moderatedID := newTestService(t)
+	root, err := service.Create(&CreateCommentModel{PostID: postID, Content: "Root"}, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Status != StatusApproved || root.Username != "bob" || root.Depth != 0 {
+		t.Errorf("got the comment %+v", root)
+	}
+	tests := []struct {
+		name   string
+		model  *CreateCommentModel
+		userID string
+		status string
+		depth  int
+		want   error
+	}{
+		{name: "a reply", model: &CreateCommentModel{PostID: postID, ParentID: &root.ID, Content: "Reply"}, userID: "alice", status: StatusApproved, depth: 1},
+		{name: "an empty comment", model: &CreateCommentModel{PostID: postID, Content: " "}, userID: "bob", want: db.ErrInvalid},
+		{name: "a missing post", model: &CreateCommentModel{PostID: moderatedID + 1, Content: "Comment"}, userID: "bob", want: db.ErrNotFound},
+		{name: "a parent on another post", model: &CreateCommentModel{PostID: moderatedID, ParentID: &root.ID, Content: "Reply"}, userID: "bob", want: db.ErrInvalid},
+		{name: "the first comment on a moderated post", model: &CreateCommentModel{PostID: moderatedID, Content: "First"}, userID: "carol", status: StatusPending},
+		{name: "an author with approved comments", model: &CreateCommentModel{PostID: moderatedID, Content: "Again"}, userID: "bob", status: StatusApproved},
+		{name: "a moderator", model: &CreateCommentModel{PostID: moderatedID, Content: "Moderator"}, userID: "mod", status: StatusApproved},
+	}
+	for _, test := range tests {
+		comment, err := service.Create(test.model, test.userID)
+		if test.want != nil {
+			if !errors.Is(err, test.want) {
+				t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if comment.Status != test.status || comment.Depth != test.depth {
+			t.Errorf("%s: got the status %s and the depth %d, want %s and %d", test.name, comment.Status, comment.Depth, test.status, test.depth)
+		}
+	}
+	thread, _, err := service.List(postID, &ListCommentsModel{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.Create(&CreateCommentModel{PostID: postID, ParentID: &thread[1].ID, Content: "Deep"}, "bob")
+	if !errors.Is(err, db.ErrInvalid) {
+		t.Errorf("a reply deeper than the limit got %v", err)
+	}
+
+	// A spam comment is written into the spam folder, where its author does not see it.
+	_, err = service.Create(&CreateCommentModel{PostID: postID, Content: "Spam", Honeypot: "https://example.com"}, "carol")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("creating a spam comment got %v", err)
+	}
+	folder, err := service.SpamFolder(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folder) != 1 || folder[0].Username != "carol" || folder[0].Status != StatusSpam {
+		t.Errorf("got the spam folder %+v", folder)
+	}
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	service, stores, postID, _ := newTestService(t)
+	root, err := service.Create(&CreateCommentModel{PostID: postID, Content: "Root"}, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := service.Create(&CreateCommentModel{PostID: postID, ParentID: &root.ID, Content: "Reply"}, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.Update(&UpdateCommentModel{Content: "Edited"}, root.ID, "carol")
+	if !errors.Is(err, db.ErrForbidden) {
+		t.Errorf("editing the comment of another user got %v", err)
+	}
+	edited, err := service.Update(&UpdateCommentModel{Content: "Edited"}, root.ID, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Content != "Edited" {
+		t.Errorf("got the edited content %q", edited.Content)
+	}
+
+	_, err = service.Delete(root.ID, "carol")
+	if !errors.Is(err, db.ErrForbidden) {
+		t.Errorf("deleting the comment of another user got %v", err)
+	}
+	deleted, err := service.Delete(root.ID, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted.Deleted || deleted.Content != deletedContent {
+		t.Errorf("got the deleted comment %+v", deleted)
+	}
+	thread, _, err := service.List(postID, &ListCommentsModel{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread) != 2 || !thread[0].Deleted || thread[0].Username != "" || thread[1].ID != reply.ID {
+		t.Errorf("got the thread %+v", thread)
+	}
+	_, err = service.Update(&UpdateCommentModel{Content: "Again"}, root.ID, "bob")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("editing a deleted comment got %v", err)
+	}
+
+	_, err = service.Delete(reply.ID, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{root.ID, reply.ID} {
+		if _, err := stores.Comments().ByID(id); !errors.Is(err, db.ErrNotFound) {
+			t.Errorf("comment %d is left after its thread was deleted: %v", id, err)
+		}
+	}
+}
+
+func TestModerateAndList(t *testing.T) {
+	service, _, _, moderatedID := newTestService(t)
+	pending := []int{}
+	for _, userID := range []string{"bob", "carol"} {
+		comment, err := service.Create(&CreateCommentModel{PostID: moderatedID, Content: "Waiting"}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pending = append(pending, comment.ID)
+	}
+
+	visible := func(userID string) []int {
+		t.Helper()
+		thread, _, err := service.List(moderatedID, &ListCommentsModel{}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return commentIDs(thread)
+	}
+	if got := visible(""); len(got) != 0 {
+		t.Errorf("an anonymous reader sees %v", got)
+	}
+	if got := visible("bob"); !equalIDs(got, pending[:1]) {
+		t.Errorf("the author sees %v, want %v", got, pending[:1])
+	}
+	if got := visible("mod"); !equalIDs(got, pending) {
+		t.Errorf("the moderator sees %v, want %v", got, pending)
+	}
+	if _, err := service.Comment(pending[1], service.Viewer("bob")); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("the pending comment of another user got %v", err)
+	}
+
+	queue, err := service.Queue(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := commentIDs(queue); !equalIDs(got, pending) {
+		t.Errorf("the queue is %v, want %v", got, pending)
+	}
+
+	_, err = service.Moderate(&ModerateCommentsModel{IDs: pending, Action: "delete"}, "mod")
+	if !errors.Is(err, db.ErrInvalid) {
+		t.Errorf("an unknown action got %v", err)
+	}
+	changed, err := service.Moderate(&ModerateCommentsModel{IDs: pending[:1], Action: ActionApprove}, "mod")
+	if err != nil || changed != 1 {
+		t.Fatalf("approving changed %d comments: %v", changed, err)
+	}
+	changed, err = service.Moderate(&ModerateCommentsModel{IDs: pending, Action: ActionReject}, "mod")
+	if err != nil || changed != 1 {
+		t.Fatalf("rejecting changed %d comments, want only the pending one: %v", changed, err)
+	}
+	if got := visible(""); !equalIDs(got, pending[:1]) {
+		t.Errorf("an anonymous reader sees %v after the moderation, want %v", got, pending[:1])
+	}
+	if got := visible("carol"); !equalIDs(got, pending[:1]) {
+		t.Errorf("the author of the rejected comment sees %v", got)
+	}
+	queue, err = service.Queue(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 0 {
+		t.Errorf("the queue is left with %v", commentIDs(queue))
+	}
+}
+
+func TestCreatedWebhook(t *testing.T) {
+	service, _, postID, moderatedID := newTestService(t)
+	hooks := webhooks.NewWebhooksService(service.config, service.repository)
+	webhook, err := hooks.Create(&webhooks.SaveWebhookModel{URL: "https://example.com/hook", Events: []string{webhooks.EventCommentCreated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivered := func() []int {
+		t.Helper()
+		deliveries, err := hooks.Deliveries(webhook.ID, &webhooks.ListDeliveriesModel{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []int{}
+		for i := len(deliveries) - 1; i >= 0; i-- {
+			payload := struct {
+				Data webhooks.CommentData `json:"data"`
+			}{}
+			err := json.Unmarshal(deliveries[i].Payload, &payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.Data.Status != StatusApproved {
+				t.Errorf("comment %d is sent with the status %s", payload.Data.ID, payload.Data.Status)
+			}
+			ids = append(ids, payload.Data.ID)
+		}
+		return ids
+	}
+
+	approved, err := service.Create(&CreateCommentModel{PostID: postID, Content: "Shown"}, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := service.Create(&CreateCommentModel{PostID: moderatedID, Content: "Waiting"}, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.Create(&CreateCommentModel{PostID: postID, Content: "Spam", Honeypot: "https://example.com"}, "carol")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("creating a spam comment got %v", err)
+	}
+	if got := delivered(); !equalIDs(got, []int{approved.ID}) {
+		t.Errorf("sent %v before the moderation, want only the approved comment %d", got, approved.ID)
+	}
+
+	_, err = service.Moderate(&ModerateCommentsModel{IDs: []int{pending.ID}, Action: ActionApprove}, "mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder, err := service.SpamFolder(10)
+	if err != nil || len(folder) != 1 {
+		t.Fatalf("got the spam folder %+v: %v", folder, err)
+	}
+	err = service.MarkHam(folder[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := delivered(); !equalIDs(got, []int{approved.ID, pending.ID, folder[0].ID}) {
+		t.Errorf("sent %v, want the comments once each as they were approved", got)
+	}
+}
+
+func TestCommentsOnHiddenPosts(t *testing.T) {
+	service, stores, postID, _ := newTestService(t)
+	comment, err := service.Create(&CreateCommentModel{PostID: postID, Content: "Before"}, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stores.Posts().SetHidden(postID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.Create(&CreateCommentModel{PostID: postID, Content: "Hidden"}, "bob")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("a comment on a hidden post got %v", err)
+	}
+	_, err = service.Update(&UpdateCommentModel{Content: "Edited"}, comment.ID, "bob")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("editing a comment on a hidden post got %v", err)
+	}
+	_, _, err = service.List(postID, &ListCommentsModel{}, "bob")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("listing the comments of a hidden post got %v", err)
+	}
+	_, err = service.Create(&CreateCommentModel{PostID: postID, Content: "Moderator"}, "mod")
+	if err != nil {
+		t.Errorf("a moderator could not comment on a hidden post: %v", err)
+	}
+}
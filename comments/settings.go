@@ -0,0 +1,100 @@
+package comments
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// Comment statuses of a post.
+const (
+	CommentsOpen     = "open"
+	CommentsLocked   = "locked"
+	CommentsClosed   = "closed"
+	CommentsDisabled = "disabled"
+)
+
+var (
+	ErrCommentsDisabled = fmt.Errorf("%w: comments are disabled", db.ErrForbidden)
+	ErrCommentsLocked   = fmt.Errorf("%w: comments are locked", db.ErrForbidden)
+	ErrCommentsClosed   = fmt.Errorf("%w: comments are closed", db.ErrForbidden)
+)
+
+// ErrorCode returns the code of the response for the errors of the comment settings.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrCommentsDisabled):
+		return "comments_disabled"
+	case errors.Is(err, ErrCommentsLocked):
+		return "comments_locked"
+	case errors.Is(err, ErrCommentsClosed):
+		return "comments_closed"
+	}
+	return ""
+}
+
+// Settings are the comment settings of a post. A null CloseDays follows the site setting.
+type Settings struct {
+	Disabled  bool
+	Locked    bool
+	CloseDays sql.NullInt64
+	CreatedAt time.Time
+}
+
+// Status tells whether the post takes new comments. Comments close closeDays days after
+// the post is published, unless the post has its own number of days.
+func (settings *Settings) Status(closeDays int, now time.Time) string {
+	if settings.Disabled {
+		return CommentsDisabled
+	}
+	if settings.Locked {
+		return CommentsLocked
+	}
+	if settings.CloseDays.Valid {
+		closeDays = int(settings.CloseDays.Int64)
+	}
+	if closeDays > 0 && now.After(settings.CreatedAt.AddDate(0, 0, closeDays)) {
+		return CommentsClosed
+	}
+	return CommentsOpen
+}
+
+// checkOpen returns an error unless the viewer may write comments on the post.
+// Moderators may still write on hidden, locked and closed posts. A hidden post is
+// not found by the others, like its comments are not.
+func (service *CommentsService) checkOpen(post *store.Post, viewer *Viewer) error {
+	if post.IsHidden && !viewer.IsModerator {
+		return fmt.Errorf("%w: post %d", db.ErrNotFound, post.ID)
+	}
+	switch service.settingsStatus(PostSettings(post)) {
+	case CommentsDisabled:
+		return ErrCommentsDisabled
+	case CommentsLocked:
+		if !viewer.IsModerator {
+			return ErrCommentsLocked
+		}
+	case CommentsClosed:
+		if !viewer.IsModerator {
+			return ErrCommentsClosed
+		}
+	}
+	return nil
+}
+
+// PostSettings returns the comment settings of the post.
+func PostSettings(post *store.Post) *Settings {
+	return &Settings{
+		Disabled:  post.CommentsDisabled,
+		Locked:    post.CommentsLocked,
+		CloseDays: post.CommentsCloseDays,
+		CreatedAt: post.CreatedAt,
+	}
+}
+
+func (service *CommentsService) settingsStatus(settings *Settings) string {
+	return settings.Status(service.config.GetCommentAutoCloseDays(), time.Now().UTC())
+}
@@ -0,0 +1,38 @@
+package comments
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestSettingsStatus(t *testing.T) {
+	created := time.Date(2022, 5, 1, 9, 0, 0, 0, time.UTC)
+	days := func(n int64) sql.NullInt64 {
+		return sql.NullInt64{Int64: n, Valid: true}
+	}
+	tests := []struct {
+		name      string
+		settings  Settings
+		closeDays int
+		now       time.Time
+		want      string
+	}{
+		{name: "open without auto-close", now: created.AddDate(10, 0, 0), want: CommentsOpen},
+		{name: "disabled wins over locked", settings: Settings{Disabled: true, Locked: true}, now: created, want: CommentsDisabled},
+		{name: "disabled wins over closed", settings: Settings{Disabled: true}, closeDays: 1, now: created.AddDate(0, 0, 2), want: CommentsDisabled},
+		{name: "locked wins over closed", settings: Settings{Locked: true}, closeDays: 1, now: created.AddDate(0, 0, 2), want: CommentsLocked},
+		{name: "open right at the close time", closeDays: 7, now: created.AddDate(0, 0, 7), want: CommentsOpen},
+		{name: "closed right after the close time", closeDays: 7, now: created.AddDate(0, 0, 7).Add(time.Second), want: CommentsClosed},
+		{name: "the days of the post win over the site", settings: Settings{CloseDays: days(30)}, closeDays: 7, now: created.AddDate(0, 0, 8), want: CommentsOpen},
+		{name: "the post closes sooner than the site", settings: Settings{CloseDays: days(1)}, closeDays: 7, now: created.AddDate(0, 0, 2), want: CommentsClosed},
+		{name: "zero days of the post never close", settings: Settings{CloseDays: days(0)}, closeDays: 7, now: created.AddDate(1, 0, 0), want: CommentsOpen},
+		{name: "negative site days never close", closeDays: -1, now: created.AddDate(1, 0, 0), want: CommentsOpen},
+	}
+	for _, test := range tests {
+		test.settings.CreatedAt = created
+		if got := test.settings.Status(test.closeDays, test.now); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/spam"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// submission collects what the spam classifiers need to know about a comment and its author.
+func (service *CommentsService) submission(users store.UserStore, userID string, postID int, content string) (*spam.Submission, error) {
+	user, err := users.ByID(userID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("%w: user %s", db.ErrForbidden, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	submission := &spam.Submission{
+		Content:          content,
+		Username:         user.Username,
+		Email:            user.Email,
+		AccountCreatedAt: user.CreatedAt,
+	}
+	if siteURL := service.config.GetSiteURL(); len(siteURL) > 0 {
+		submission.Permalink = fmt.Sprintf("%s/posts/%d", siteURL, postID)
+	}
+	return submission, nil
+}
+
+// classify runs the spam classifiers on a new comment.
+func (service *CommentsService) classify(model *CreateCommentModel, userID string) (*spam.Verdict, error) {
+	submission, err := service.submission(service.stores.Users(), userID, model.PostID, model.Content)
+	if err != nil {
+		return nil, err
+	}
+	submission.Honeypot = model.Honeypot
+	submission.IP = model.IP
+	submission.UserAgent = model.UserAgent
+	submission.RecentComments, err = service.stores.Comments().CountByUser(userID, "", time.Now().UTC().Add(-spam.RateWindow))
+	if err != nil {
+		return nil, err
+	}
+	submission.Allowed, submission.Blocked, err = spam.Lookup(service.repository, userID, model.IP)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	verdict, err := service.classifier.Check(submission)
+	if err != nil {
+		log.Println(err.Error())
+		return &spam.Verdict{}, nil
+	}
+	if verdict.Spam {
+		log.Printf("spam: a comment of %s is spam, %v\n", submission.Username, verdict.Reasons)
+	}
+	return verdict, nil
+}
+
+// SpamFolder returns the comments classified as spam, the newest first.
+func (service *CommentsService) SpamFolder(limit int) (CommentArray, error) {
+	return service.commentsWithStatus(StatusSpam, store.OrderLatest, limit)
+}
+
+// MarkSpam moves the comment to the spam folder and blocks its author.
+func (service *CommentsService) MarkSpam(id int) error {
+	return service.train(id, StatusSpam)
+}
+
+// MarkHam approves a comment of the spam folder and allows its author.
+func (service *CommentsService) MarkHam(id int) error {
+	return service.train(id, StatusApproved)
+}
+
+func (service *CommentsService) train(id int, status string) error {
+	var submission *spam.Submission
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		comment, err := stores.Comments().ByID(id)
+		if err != nil {
+			return err
+		}
+		if status == StatusApproved && comment.Status != StatusSpam {
+			return fmt.Errorf("%w: comment %d is not spam", db.ErrInvalid, id)
+		}
+		submission, err = service.submission(stores.Users(), comment.UserID, comment.PostID, comment.Content)
+		if err != nil {
+			return err
+		}
+		submission.IP = comment.IP
+		submission.UserAgent = comment.UserAgent
+		_, err = stores.Comments().ChangeStatus(id, comment.Status, status)
+		if err != nil {
+			return err
+		}
+		comment.Status = status
+		err = service.dispatchCreated(tx, comment)
+		if err != nil {
+			return err
+		}
+		if status == StatusSpam {
+			err = spam.Block(tx, comment.UserID, comment.IP)
+		} else {
+			err = spam.Allow(tx, comment.UserID)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if status == StatusApproved {
+		service.announce(id, false)
+	}
+	trainer, ok := service.classifier.(spam.Trainer)
+	if !ok {
+		return nil
+	}
+	if status == StatusSpam {
+		trainer.MarkSpam(submission)
+	} else {
+		trainer.MarkHam(submission)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package comments
+
+import "sort"
+
+const deletedContent = "[deleted]"
+
+const (
+	ViewFlat = "flat"
+	ViewTree = "tree"
+)
+
+// Thread orders the comments so that every reply follows its parent. The top-level comments
+// keep their order and the replies are the oldest first. The order of the result is the
+// flattened thread and the depth tells the indentation.
+func Thread(comments CommentArray) CommentArray {
+	children := map[int][]CommentModel{}
+	roots := []CommentModel{}
+	ids := map[int]bool{}
+	for _, comment := range comments {
+		ids[comment.ID] = true
+	}
+	for _, comment := range comments {
+		if comment.ParentID == nil || !ids[*comment.ParentID] {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+	thread := CommentArray{}
+	var walk func(level []CommentModel)
+	walk = func(level []CommentModel) {
+		for _, comment := range level {
+			thread = append(thread, comment)
+			replies := children[comment.ID]
+			sort.SliceStable(replies, func(i, j int) bool {
+				return replies[i].CreatedAt.Before(replies[j].CreatedAt)
+			})
+			walk(replies)
+		}
+	}
+	walk(roots)
+	return thread
+}
+
+// Tree nests the replies of the threaded comments under their parents.
+func Tree(comments CommentArray) CommentArray {
+	thread := Thread(comments)
+	nodes := map[int]*CommentModel{}
+	for i := range thread {
+		thread[i].Replies = nil
+		nodes[thread[i].ID] = &thread[i]
+	}
+	// Build from the deepest replies so that every node is complete before it is copied into its parent.
+	for i := len(thread) - 1; i >= 0; i-- {
+		comment := &thread[i]
+		if comment.ParentID == nil {
+			continue
+		}
+		parent, ok := nodes[*comment.ParentID]
+		if !ok {
+			continue
+		}
+		parent.Replies = append(CommentArray{*comment}, parent.Replies...)
+	}
+	tree := CommentArray{}
+	for _, comment := range thread {
+		if comment.ParentID == nil || nodes[*comment.ParentID] == nil {
+			tree = append(tree, *nodes[comment.ID])
+		}
+	}
+	return tree
+}
+
+// View returns the comments as a tree or as the flattened thread.
+func View(comments CommentArray, view string) CommentArray {
+	if view == ViewTree {
+		return Tree(comments)
+	}
+	return Thread(comments)
+}
@@ -0,0 +1,178 @@
+package comments
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func testComment(id int, parentID *int, minutes int) CommentModel {
+	return CommentModel{
+		ID:        id,
+		ParentID:  parentID,
+		CreatedAt: time.Date(2022, 5, 1, 9, minutes, 0, 0, time.UTC),
+	}
+}
+
+func commentIDs(comments CommentArray) []int {
+	ids := []int{}
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
+
+func equalIDs(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestThread(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments CommentArray
+		want     []int
+	}{
+		{
+			name:     "empty",
+			comments: CommentArray{},
+			want:     []int{},
+		},
+		{
+			name: "replies follow their parents",
+			comments: CommentArray{
+				testComment(1, nil, 0),
+				testComment(2, nil, 1),
+				testComment(3, intPointer(1), 2),
+				testComment(4, intPointer(3), 3),
+			},
+			want: []int{1, 3, 4, 2},
+		},
+		{
+			name: "top-level comments keep their order and replies are the oldest first",
+			comments: CommentArray{
+				testComment(2, nil, 1),
+				testComment(1, nil, 0),
+				testComment(4, intPointer(1), 5),
+				testComment(3, intPointer(1), 4),
+			},
+			want: []int{2, 1, 3, 4},
+		},
+		{
+			name: "a reply to a missing parent is a top-level comment",
+			comments: CommentArray{
+				testComment(1, nil, 0),
+				testComment(5, intPointer(9), 1),
+			},
+			want: []int{1, 5},
+		},
+	}
+	for _, test := range tests {
+		if got := commentIDs(Thread(test.comments)); !equalIDs(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	tree := Tree(CommentArray{
+		testComment(1, nil, 0),
+		testComment(2, nil, 1),
+		testComment(4, intPointer(1), 3),
+		testComment(3, intPointer(1), 2),
+		testComment(5, intPointer(3), 4),
+		testComment(6, intPointer(9), 5),
+	})
+	if got := commentIDs(tree); !equalIDs(got, []int{1, 2, 6}) {
+		t.Fatalf("got the roots %v", got)
+	}
+	if got := commentIDs(tree[0].Replies); !equalIDs(got, []int{3, 4}) {
+		t.Fatalf("got the replies %v", got)
+	}
+	if got := commentIDs(tree[0].Replies[0].Replies); !equalIDs(got, []int{5}) {
+		t.Errorf("got the nested replies %v", got)
+	}
+	if len(tree[1].Replies) != 0 || len(tree[0].Replies[1].Replies) != 0 {
+		t.Errorf("got replies on the leaves: %+v", tree)
+	}
+	if got := commentIDs(View(CommentArray{testComment(1, nil, 0), testComment(2, intPointer(1), 1)}, ViewFlat)); !equalIDs(got, []int{1, 2}) {
+		t.Errorf("the flat view got %v", got)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	// The thread is 1 <- 2 <- 3 and 1 <- 4, the comment 2 is already deleted.
+	newThread := func(t *testing.T) store.CommentStore {
+		stores := store.NewMemoryStore()
+		err := stores.Users().Create(&store.User{ID: "alice", Email: "alice@example.com", Username: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		postID, err := stores.Posts().Create(&store.Post{Title: "Post", UserID: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		comments := stores.Comments()
+		for _, comment := range []*store.Comment{
+			{PostID: postID, UserID: "alice"},
+			{PostID: postID, UserID: "alice", ParentID: intPointer(1), Deleted: true},
+			{PostID: postID, UserID: "alice", ParentID: intPointer(2)},
+			{PostID: postID, UserID: "alice", ParentID: intPointer(1)},
+		} {
+			_, err := comments.Create(comment)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return comments
+	}
+	tests := []struct {
+		name    string
+		removed []int
+		deleted []int
+		marked  []int
+	}{
+		{name: "a leaf is deleted", removed: []int{4}, deleted: []int{4}},
+		{name: "a comment with replies is marked deleted", removed: []int{1}, marked: []int{1}},
+		{name: "the deleted parent without replies is deleted too", removed: []int{3}, deleted: []int{2, 3}},
+		{name: "every deleted ancestor is deleted", removed: []int{1, 4, 3}, deleted: []int{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		comments := newThread(t)
+		for _, id := range test.removed {
+			comment, err := comments.ByID(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = collapse(comments, comment)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, id := range test.deleted {
+			if _, err := comments.ByID(id); !errors.Is(err, db.ErrNotFound) {
+				t.Errorf("%s: comment %d is not deleted", test.name, id)
+			}
+		}
+		for _, id := range test.marked {
+			comment, err := comments.ByID(id)
+			if err != nil || !comment.Deleted {
+				t.Errorf("%s: comment %d is not marked deleted: %+v", test.name, id, comment)
+			}
+		}
+	}
+}
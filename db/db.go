@@ -5,21 +5,59 @@ import (
 	"log"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	"github.com/quavious/blog-factory-server/config"
+	_ "modernc.org/sqlite"
 )
 
+// Repository is the database. Its queries are rebound to the placeholders of its dialect.
 type Repository struct {
 	*sql.DB
+	dialect Dialect
 }
 
 func NewRepository(config *config.Config) *Repository {
-	uri := config.GetDBAddress()
-	db, err := sql.Open("mysql", uri)
+	dialect, err := ParseDialect(config.GetDBDialect())
 	if err != nil {
 		log.Println(err)
 		return nil
 	}
+	repository, err := Open(dialect, config.GetDBAddress())
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return repository
+}
+
+// Open opens the database of the dialect.
+func Open(dialect Dialect, address string) (*Repository, error) {
+	db, err := sql.Open(string(dialect), address)
+	if err != nil {
+		return nil, err
+	}
 	return &Repository{
-		DB: db,
+		DB:      db,
+		dialect: dialect,
+	}, nil
+}
+
+// Dialect returns the dialect of the database. A repository made without one is MySQL.
+func (repository *Repository) Dialect() Dialect {
+	if len(repository.dialect) == 0 {
+		return MySQL
 	}
+	return repository.dialect
+}
+
+func (repository *Repository) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return repository.DB.Exec(repository.Dialect().Rebind(query), args...)
+}
+
+func (repository *Repository) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return repository.DB.Query(repository.Dialect().Rebind(query), args...)
+}
+
+func (repository *Repository) QueryRow(query string, args ...interface{}) *sql.Row {
+	return repository.DB.QueryRow(repository.Dialect().Rebind(query), args...)
 }
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL flavor of the database. The queries are written in MySQL with ? placeholders,
+// and the dialect rewrites the parts which the other databases spell differently.
+type Dialect string
+
+const (
+	MySQL      Dialect = "mysql"
+	SQLite     Dialect = "sqlite"
+	PostgreSQL Dialect = "postgres"
+)
+
+// excluded is a reference to a value of the inserted row in the assignments of Upsert.
+var excluded = regexp.MustCompile(`\bexcluded\.(\w+)`)
+
+// ParseDialect returns the dialect of the name. An empty name is MySQL.
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", "mysql":
+		return MySQL, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	case "postgres", "postgresql":
+		return PostgreSQL, nil
+	}
+	return "", fmt.Errorf("unknown database dialect %q", name)
+}
+
+// Rebind replaces the ? placeholders with the numbered $1, $2... of PostgreSQL.
+// Question marks in quoted strings are kept.
+func (dialect Dialect) Rebind(query string) string {
+	if dialect != PostgreSQL || !strings.Contains(query, "?") {
+		return query
+	}
+	rebound := strings.Builder{}
+	rebound.Grow(len(query) + 8)
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			rebound.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		rebound.WriteRune(r)
+	}
+	return rebound.String()
+}
+
+// InsertIgnore turns an "insert into" statement into one which skips the rows that would
+// break a unique key.
+func (dialect Dialect) InsertIgnore(insert string) string {
+	insert = strings.TrimSpace(insert)
+	switch dialect {
+	case SQLite:
+		return "insert or ignore" + strings.TrimPrefix(insert, "insert")
+	case PostgreSQL:
+		return insert + " on conflict do nothing"
+	}
+	return "insert ignore" + strings.TrimPrefix(insert, "insert")
+}
+
+// Upsert adds the clause which updates the row having the same keys as the inserted one.
+// The assignments refer to the inserted values as excluded.column, and to the stored
+// values by the table name.
+func (dialect Dialect) Upsert(insert string, keys []string, assignments string) string {
+	insert = strings.TrimSpace(insert)
+	if dialect == MySQL {
+		return insert + " on duplicate key update " + excluded.ReplaceAllString(assignments, "values($1)")
+	}
+	return fmt.Sprintf("%s on conflict (%s) do update set %s", insert, strings.Join(keys, ", "), assignments)
+}
+
+// Fold returns the column for a comparison which ignores the case, against a lowercased value.
+// MySQL and the nocase columns of SQLite ignore it anyway, and PostgreSQL compares the lowercased
+// column, which has an index on the expression.
+func (dialect Dialect) Fold(column string) string {
+	if dialect == PostgreSQL {
+		return "lower(" + column + ")"
+	}
+	return column
+}
+
+// ForUpdate returns the clause which locks the selected rows until the transaction ends. SQLite
+// has no such clause, as a write transaction locks the whole database.
+func (dialect Dialect) ForUpdate() string {
+	if dialect == SQLite {
+		return ""
+	}
+	return "for update"
+}
+
+// Insert runs an insert statement into a table with an id column and returns the id of the
+// new row. PostgreSQL returns it from the statement, as its driver has no LastInsertId. When
+// the statement ignores the row, Insert returns sql.ErrNoRows.
+func Insert(executor Executor, insert string, args ...interface{}) (int64, error) {
+	if executor.Dialect() == PostgreSQL {
+		var id int64
+		err := executor.QueryRow(strings.TrimSpace(insert)+" returning id", args...).Scan(&id)
+		return id, err
+	}
+	res, err := executor.Exec(insert, args...)
+	if err != nil {
+		return 0, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if inserted == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return res.LastInsertId()
+}
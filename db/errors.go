@@ -0,0 +1,30 @@
+package db
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrInvalid   = errors.New("invalid data")
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+	ErrConflict  = errors.New("conflict")
+	ErrInternal  = errors.New("internal error")
+)
+
+// StatusCode maps an error returned by a service to the HTTP status code of the response.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
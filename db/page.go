@@ -0,0 +1,60 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cursor points at the last item of a page. It is sent to the clients as an opaque string.
+type Cursor struct {
+	CreatedAt time.Time `json:"t,omitempty"`
+	ID        int       `json:"i,omitempty"`
+	Offset    int       `json:"o,omitempty"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// PageInfo is the pagination part of every list response.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+func (cursor *Cursor) Encode() string {
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeCursor parses a cursor made by Encode. An empty string is no cursor.
+func DecodeCursor(value string) (*Cursor, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalid)
+	}
+	cursor := new(Cursor)
+	err = json.Unmarshal(decoded, cursor)
+	if err != nil || cursor.Offset < 0 {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalid)
+	}
+	return cursor, nil
+}
+
+// PageLimit returns the requested page size, or the default one when it is not in 1..max.
+func PageLimit(requested int, fallback int, max int) int {
+	if requested < 1 {
+		return fallback
+	}
+	if requested > max {
+		return max
+	}
+	return requested
+}
@@ -3,4 +3,5 @@ package db
 type BadResponse struct {
 	Status  bool   `json:"status"`
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
 }
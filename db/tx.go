@@ -0,0 +1,64 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// Executor is implemented by both *Repository and *Tx.
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Dialect() Dialect
+}
+
+// Tx is a transaction of the repository. Its queries are rebound like those of the repository.
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (tx *Tx) Dialect() Dialect {
+	return tx.dialect
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.Rebind(query), args...)
+}
+
+// Transaction runs fn in a transaction. It commits when fn returns nil and rolls back otherwise.
+func (repository *Repository) Transaction(fn func(tx *Tx) error) (err error) {
+	sqlTx, err := repository.Begin()
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	tx := &Tx{Tx: sqlTx, dialect: repository.Dialect()}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(rollbackErr.Error())
+			}
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			log.Println(commitErr.Error())
+			err = fmt.Errorf("%w: %v", ErrInternal, commitErr)
+		}
+	}()
+	return fn(tx)
+}
@@ -0,0 +1,118 @@
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+type FeedsController struct {
+	*echo.Echo
+	config     *config.Config
+	repository *db.Repository
+	stores     store.Store
+	index      *search.Index
+	mailClient *mail.MailClient
+}
+
+func NewFeedsController(echo *echo.Echo, config *config.Config, repository *db.Repository, stores store.Store, index *search.Index, mailClient *mail.MailClient) *FeedsController {
+	return &FeedsController{
+		Echo:       echo,
+		config:     config,
+		repository: repository,
+		stores:     stores,
+		index:      index,
+		mailClient: mailClient,
+	}
+}
+
+// format is an encoding of the feeds and the name of its document.
+type format struct {
+	name        string
+	contentType string
+	encode      func(feed *Feed) ([]byte, error)
+}
+
+var formats = []format{
+	{name: "feed.xml", contentType: "application/rss+xml; charset=UTF-8", encode: RSS},
+	{name: "atom.xml", contentType: "application/atom+xml; charset=UTF-8", encode: Atom},
+	{name: "feed.json", contentType: "application/feed+json; charset=UTF-8", encode: JSONFeed},
+}
+
+func (controller *FeedsController) UseRoute() {
+	feedsService := NewFeedsService(controller.config, controller.repository, controller.stores, controller.index, controller.mailClient)
+	for _, format := range formats {
+		format := format
+		serve := func(c echo.Context, tag string, username string) error {
+			feed, err := feedsService.Feed(tag, username, c.Request().URL.Path)
+			if err != nil {
+				return c.JSON(db.StatusCode(err), &db.BadResponse{
+					Status:  false,
+					Message: "Loading the feed is failed.",
+				})
+			}
+			body, err := format.encode(feed)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, &db.BadResponse{
+					Status:  false,
+					Message: "Loading the feed is failed.",
+				})
+			}
+			return Conditional(c, format.contentType, body, feed.Updated)
+		}
+		controller.GET("/"+format.name, func(c echo.Context) error {
+			return serve(c, "", "")
+		})
+		controller.GET("/tags/:tag/"+format.name, func(c echo.Context) error {
+			return serve(c, c.Param("tag"), "")
+		})
+		controller.GET("/users/:username/"+format.name, func(c echo.Context) error {
+			return serve(c, "", c.Param("username"))
+		})
+	}
+}
+
+// Conditional writes the document with its ETag and Last-Modified headers, or answers Not Modified
+// when the request has the same ETag, or has not been modified since the time in its header.
+// A zero modified time sends no Last-Modified header.
+func Conditional(c echo.Context, contentType string, body []byte, modified time.Time) error {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	modified = modified.UTC().Truncate(time.Second)
+	if !modified.IsZero() {
+		header.Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+	if notModified(c.Request(), etag, modified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, contentType, body)
+}
+
+// notModified checks If-None-Match first, and If-Modified-Since only when the request has no ETags.
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); len(match) > 0 {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	return !modified.After(since)
+}
@@ -0,0 +1,185 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2022, 5, 1, 9, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:       "Blog Factory",
+		Description: "The latest posts of Blog Factory",
+		Link:        "https://blog.example.com",
+		URL:         "https://blog.example.com/feed.xml",
+		Updated:     published.Add(time.Hour),
+		Items: []Item{{
+			ID:        1,
+			Title:     "Hello & Welcome",
+			Link:      "https://blog.example.com/posts/1",
+			Summary:   "The first post",
+			Content:   `<p>Hi <a href="https://blog.example.com/users/bob">@bob</a></p>`,
+			Author:    "alice",
+			AuthorURL: "https://blog.example.com/users/alice",
+			Tags:      []string{"go", "news"},
+			Published: published,
+			Updated:   published.Add(time.Hour),
+		}},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	body, err := RSS(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title      string   `xml:"title"`
+				GUID       string   `xml:"guid"`
+				Content    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories []string `xml:"category"`
+				PubDate    string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	err = xml.Unmarshal(body, &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.Channel.Title != "Blog Factory" || len(document.Channel.Items) != 1 {
+		t.Fatalf("got the channel %+v", document.Channel)
+	}
+	item := document.Channel.Items[0]
+	if item.Title != "Hello & Welcome" || item.GUID != "https://blog.example.com/posts/1" || item.Creator != "alice" {
+		t.Errorf("got the item %+v", item)
+	}
+	if !strings.Contains(item.Content, `<a href="https://blog.example.com/users/bob">`) {
+		t.Errorf("got the content %q", item.Content)
+	}
+	if len(item.Categories) != 2 || item.PubDate != "Sun, 01 May 2022 09:00:00 +0000" {
+		t.Errorf("got the categories %v and the date %q", item.Categories, item.PubDate)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := Atom(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+			Author  struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	err = xml.Unmarshal(body, &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.ID != "https://blog.example.com/feed.xml" || document.Updated != "2022-05-01T10:00:00Z" || len(document.Entries) != 1 {
+		t.Fatalf("got the feed %+v", document)
+	}
+	entry := document.Entries[0]
+	if entry.ID != "https://blog.example.com/posts/1" || entry.Author.Name != "alice" || entry.Content.Type != "html" || !strings.HasPrefix(entry.Content.Value, "<p>") {
+		t.Errorf("got the entry %+v", entry)
+	}
+}
+
+func TestJSONFeed(t *testing.T) {
+	feed := testFeed()
+	feed.Items[0].Content = ""
+	body, err := JSONFeed(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ID          string `json:"id"`
+			ContentHTML string `json:"content_html"`
+			ContentText string `json:"content_text"`
+			Authors     []struct {
+				Name string `json:"name"`
+			} `json:"authors"`
+			Tags []string `json:"tags"`
+		} `json:"items"`
+	}
+	err = json.Unmarshal(body, &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.Version != "https://jsonfeed.org/version/1.1" || len(document.Items) != 1 {
+		t.Fatalf("got the feed %s", body)
+	}
+	item := document.Items[0]
+	if item.ContentHTML != "" || item.ContentText != "The first post" || item.Authors[0].Name != "alice" || len(item.Tags) != 2 {
+		t.Errorf("got the item %+v", item)
+	}
+}
+
+func TestRenderContent(t *testing.T) {
+	rendered := renderContent("Hi @Bob <3\nbye\n\n\nNext", map[string]string{"bob": "Bob"}, "https://blog.example.com")
+	want := `<p>Hi <a href="https://blog.example.com/users/Bob">@Bob</a> &lt;3<br>bye</p><p>Next</p>`
+	if rendered != want {
+		t.Errorf("got %q, want %q", rendered, want)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	modified := time.Date(2022, 5, 1, 9, 0, 0, 500, time.UTC)
+	serve := func(header string, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		if len(header) > 0 {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		err := Conditional(echo.New().NewContext(req, rec), "application/rss+xml", []byte("<rss/>"), modified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	rec := serve("", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || len(etag) == 0 || rec.Header().Get("Last-Modified") != "Sun, 01 May 2022 09:00:00 GMT" {
+		t.Fatalf("got %d with the headers %v", rec.Code, rec.Header())
+	}
+	tests := []struct {
+		header string
+		value  string
+		want   int
+	}{
+		{"If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match", `"other", W/` + etag, http.StatusNotModified},
+		{"If-None-Match", `"other"`, http.StatusOK},
+		{"If-Modified-Since", "Sun, 01 May 2022 09:00:00 GMT", http.StatusNotModified},
+		{"If-Modified-Since", "Sun, 01 May 2022 08:59:59 GMT", http.StatusOK},
+		{"If-Modified-Since", "yesterday", http.StatusOK},
+	}
+	for _, test := range tests {
+		if rec := serve(test.header, test.value); rec.Code != test.want {
+			t.Errorf("%s: %s got %d, want %d", test.header, test.value, rec.Code, test.want)
+		}
+	}
+}
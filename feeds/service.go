@@ -0,0 +1,109 @@
+package feeds
+
+import (
+	"strings"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/mentions"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/posts"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/tags"
+)
+
+type FeedsService struct {
+	config       *config.Config
+	repository   *db.Repository
+	postsService *posts.PostsService
+}
+
+func NewFeedsService(config *config.Config, repository *db.Repository, stores store.Store, index *search.Index, mailClient *mail.MailClient) *FeedsService {
+	return &FeedsService{
+		config:       config,
+		repository:   repository,
+		postsService: posts.NewPostsService(config, repository, stores, index, mailClient),
+	}
+}
+
+// Feed returns the feed of the latest posts, of the tag or of the author when they are not empty.
+// The path is the path of the feed on the server, which makes its address.
+func (service *FeedsService) Feed(tag string, username string, path string) (*Feed, error) {
+	latest, username, err := service.postsService.Feed(tag, username)
+	if err != nil {
+		return nil, err
+	}
+	siteURL := service.config.GetSiteURL()
+	feed := &Feed{
+		Title:       service.config.GetSiteName(),
+		Description: "The latest posts of " + service.config.GetSiteName(),
+		Link:        siteURL,
+		URL:         siteURL + path,
+		Items:       []Item{},
+	}
+	if len(tag) > 0 {
+		// The tag is valid since its posts were found.
+		normalized, _ := tags.Normalize(tag, 0)
+		feed.Title += " - #" + normalized
+		feed.Description = "The latest posts about " + normalized
+		feed.Link = tags.PageURL(siteURL, normalized)
+	}
+	if len(username) > 0 {
+		feed.Title += " - " + username
+		feed.Description = "The latest posts of " + username
+		feed.Link = mentions.ProfileURL(siteURL, username)
+	}
+	_, excerpt := service.config.GetFeed()
+	usernames := map[int]map[string]string{}
+	if !excerpt {
+		ids := []int{}
+		for _, post := range latest {
+			ids = append(ids, post.ID)
+		}
+		usernames, err = mentions.Usernames(service.repository, mentions.SourcePost, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, post := range latest {
+		item := Item{
+			ID:        post.ID,
+			Title:     post.Title,
+			Link:      notifications.Link(service.config, post.ID, 0),
+			Summary:   post.Description,
+			Author:    post.Username,
+			AuthorURL: mentions.ProfileURL(siteURL, post.Username),
+			Tags:      []string{},
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+		}
+		if !excerpt {
+			item.Content = renderContent(post.Content, usernames[post.ID], siteURL)
+		}
+		for _, tag := range post.Tags {
+			item.Tags = append(item.Tags, tag)
+		}
+		if post.UpdatedAt.After(feed.Updated) {
+			feed.Updated = post.UpdatedAt
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// renderContent renders the plain text of a post as HTML paragraphs, linking the mentions.
+func renderContent(content string, usernames map[string]string, siteURL string) string {
+	var rendered strings.Builder
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if len(paragraph) == 0 {
+			continue
+		}
+		lines := strings.Split(mentions.Render(paragraph, usernames, siteURL), "\n")
+		rendered.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return rendered.String()
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/jobs"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/migrations"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// emailToken finds the token in the text of a verification email.
+var emailToken = regexp.MustCompile(`token is (\w+)\.`)
+
+// testApp is the whole server, wired like main does, on a new SQLite database. The mails
+// are kept by the mailer instead of being sent, and the jobs run when the test runs the queue.
+type testApp struct {
+	t          *testing.T
+	echo       *echo.Echo
+	repository *db.Repository
+	mailer     *mail.MemoryMailer
+	queue      *jobs.Queue
+	// headers are sent with every request.
+	headers map[string]string
+}
+
+// testUser is a signed up user. AccessToken and RefreshToken are set by signIn.
+type testUser struct {
+	ID           string
+	Email        string
+	Username     string
+	Password     string
+	AccessToken  string
+	RefreshToken string
+}
+
+// newTestApp starts the server with the config of the environment variables, which env overrides.
+func newTestApp(t *testing.T, env map[string]string) *testApp {
+	t.Helper()
+	defaults := map[string]string{
+		"DB_DIALECT":                   "sqlite",
+		"DB_NAME":                      filepath.Join(t.TempDir(), "blog.db"),
+		"JWT_ACCESS_SECRET":            "access-secret",
+		"JWT_REFRESH_SECRET":           "refresh-secret",
+		"SITE_URL":                     "http://localhost:3000",
+		"SPAM_MIN_ACCOUNT_AGE_MINUTES": "0",
+		"AKISMET_API_KEY":              "",
+	}
+	for key, value := range env {
+		defaults[key] = value
+	}
+	for key, value := range defaults {
+		t.Setenv(key, value)
+	}
+	config := config.FromEnv()
+	repository := openTestDatabase(t, config)
+	mailer := mail.NewMemoryMailer()
+	templates, err := mail.NewTemplates(config.GetMailTemplateDirectory(), config.GetSiteURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := jobs.NewQueue(config, repository)
+	return &testApp{
+		t:          t,
+		echo:       newApp(config, repository, mail.NewClient(mailer, templates), queue),
+		repository: repository,
+		mailer:     mailer,
+		queue:      queue,
+	}
+}
+
+// openTestDatabase migrates the database of the tests from scratch. It is the database of the
+// config, a new SQLite one, unless TEST_DIALECT is mysql or postgres. Those run against the
+// databases in TEST_DATABASE_DSN and TEST_POSTGRES_DSN like the store tests do, and every test
+// drops their tables.
+func openTestDatabase(t *testing.T, config *config.Config) *db.Repository {
+	t.Helper()
+	var repository *db.Repository
+	switch dialect := os.Getenv("TEST_DIALECT"); dialect {
+	case "", string(db.SQLite):
+		repository = db.NewRepository(config)
+		if repository == nil {
+			t.Fatal("the database is not opened")
+		}
+	case string(db.MySQL), string(db.PostgreSQL):
+		key := "TEST_DATABASE_DSN"
+		if dialect == string(db.PostgreSQL) {
+			key = "TEST_POSTGRES_DSN"
+		}
+		dsn := os.Getenv(key)
+		if len(dsn) == 0 {
+			t.Skipf("%s is not set", key)
+		}
+		var err error
+		repository, err = db.Open(db.Dialect(dialect), dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("unknown TEST_DIALECT %q", dialect)
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Down(math.MaxInt32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+// call sends the request as the user, who is anonymous when nil, and returns the response.
+// The body is encoded as JSON unless it is nil.
+func (app *testApp) call(user *testUser, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	app.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&payload).Encode(body)
+		if err != nil {
+			app.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	if body != nil {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for key, value := range app.headers {
+		req.Header.Set(key, value)
+	}
+	if user != nil && len(user.AccessToken) > 0 {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+user.AccessToken)
+		req.AddCookie(&http.Cookie{Name: "refreshToken", Value: user.RefreshToken})
+	}
+	rec := httptest.NewRecorder()
+	app.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect calls the route like call does, fails the test unless the response has the status,
+// and decodes the response into out unless it is nil.
+func (app *testApp) expect(status int, user *testUser, method string, path string, body interface{}, out interface{}) {
+	app.t.Helper()
+	rec := app.call(user, method, path, body)
+	if rec.Code != status {
+		app.t.Fatalf("%s %s: got status %d, want %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	if out != nil {
+		err := json.Unmarshal(rec.Body.Bytes(), out)
+		if err != nil {
+			app.t.Fatalf("%s %s: %v: %s", method, path, err, rec.Body.String())
+		}
+	}
+}
+
+// lastMail runs the due jobs and returns the latest mail sent to the receiver.
+func (app *testApp) lastMail(receiver string) mail.Message {
+	app.t.Helper()
+	app.queue.RunDue()
+	messages := app.mailer.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Receiver == receiver {
+			return messages[i]
+		}
+	}
+	app.t.Fatalf("no mail is sent to %s", receiver)
+	return mail.Message{}
+}
+
+// requestEmailToken asks for a verification email and returns the token in it.
+func (app *testApp) requestEmailToken(email string) string {
+	app.t.Helper()
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/email-token", echo.Map{"email": email}, nil)
+	match := emailToken.FindStringSubmatch(app.lastMail(email).Text)
+	if match == nil {
+		app.t.Fatalf("the mail to %s has no token", email)
+	}
+	return match[1]
+}
+
+// verifyEmail verifies the email with the token of a new verification email.
+func (app *testApp) verifyEmail(email string) {
+	app.t.Helper()
+	token := app.requestEmailToken(email)
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/email-verification", echo.Map{"email": email, "token": token}, nil)
+}
+
+// signUp verifies the email of the username at example.com, signs the user up and signs them in.
+func (app *testApp) signUp(username string) *testUser {
+	app.t.Helper()
+	user := &testUser{
+		Email:    username + "@example.com",
+		Username: username,
+		Password: "password-" + username,
+	}
+	app.verifyEmail(user.Email)
+	app.expect(http.StatusOK, nil, http.MethodPost, "/auth/sign-up", echo.Map{
+		"email":           user.Email,
+		"username":        user.Username,
+		"password":        user.Password,
+		"passwordConfirm": user.Password,
+	}, nil)
+	stored, err := store.NewSQLStore(app.repository).Users().ByEmail(user.Email)
+	if err != nil {
+		app.t.Fatal(err)
+	}
+	user.ID = stored.ID
+	app.signIn(user)
+	return user
+}
+
+// signIn signs the user in with their password and keeps the tokens.
+func (app *testApp) signIn(user *testUser) {
+	app.t.Helper()
+	rec := app.call(nil, http.MethodPost, "/auth/sign-in", echo.Map{"email": user.Email, "password": user.Password})
+	if rec.Code != http.StatusOK {
+		app.t.Fatalf("%s did not sign in: %s", user.Username, rec.Body.String())
+	}
+	var res struct {
+		AccessToken string `json:"accessToken"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	if err != nil {
+		app.t.Fatal(err)
+	}
+	user.AccessToken = res.AccessToken
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "refreshToken" {
+			user.RefreshToken = cookie.Value
+		}
+	}
+}
+
+// signUpAdmin signs up a user who is an admin.
+func (app *testApp) signUpAdmin(username string) *testUser {
+	app.t.Helper()
+	user := app.signUp(username)
+	err := store.NewSQLStore(app.repository).Users().SetAdmin(user.ID, true)
+	if err != nil {
+		app.t.Fatal(err)
+	}
+	return user
+}
+
+// createPost creates a post of the admin and returns its id.
+func (app *testApp) createPost(admin *testUser, title string, tags ...string) int {
+	app.t.Helper()
+	var res struct {
+		ID int `json:"id"`
+	}
+	app.expect(http.StatusCreated, admin, http.MethodPost, "/posts", echo.Map{
+		"title":       title,
+		"description": "About " + title,
+		"content":     "The content of " + title,
+		"tags":        tags,
+	}, &res)
+	return res.ID
+}
+
+// postPath is the path of the post by id.
+func postPath(id int) string {
+	return fmt.Sprintf("/posts/id/%d", id)
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+type JobsController struct {
+	*echo.Echo
+	config          *config.Config
+	repository      *db.Repository
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+}
+
+func NewJobsController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc) *JobsController {
+	return &JobsController{
+		Echo:            echo,
+		config:          config,
+		repository:      repository,
+		jwtMiddleware:   jwtMiddleware,
+		adminMiddleware: adminMiddleware,
+	}
+}
+
+func (controller *JobsController) UseRoute() {
+	jobsService := NewJobsService(controller.config, controller.repository)
+	controller.GET("/jobs", func(c echo.Context) error {
+		model := new(ListJobsModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		jobs, err := jobsService.List(model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the jobs is failed.",
+			})
+		}
+		counts, err := jobsService.Counts()
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the jobs is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"jobs":   jobs,
+			"counts": counts,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.GET("/jobs/:id", func(c echo.Context) error {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid job id.",
+			})
+		}
+		job, err := jobsService.Job(jobID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the job is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"job":    job,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/jobs/:id/retry", func(c echo.Context) error {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid job id.",
+			})
+		}
+		err = jobsService.Retry(jobID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Retrying the job is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The job will run again.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	// StatusDead is the status of the jobs which failed every attempt. They run again only when
+	// an admin retries them.
+	StatusDead = "dead"
+)
+
+// Job is a job claimed by a worker. Attempts counts the current run.
+type Job struct {
+	ID       int64
+	Type     string
+	Payload  []byte
+	Attempts int
+}
+
+// Decode decodes the JSON payload of the job into out.
+func (job *Job) Decode(out interface{}) error {
+	return json.Unmarshal(job.Payload, out)
+}
+
+// redactedTypes are the types of the jobs whose payloads are not shown to the admins. See Redact.
+var (
+	redactedMutex sync.RWMutex
+	redactedTypes = map[string]bool{}
+)
+
+// Redact hides the payloads of the jobs of the types from the admins, for the jobs which hold
+// the addresses of the users or the links with their tokens.
+func Redact(jobTypes ...string) {
+	redactedMutex.Lock()
+	defer redactedMutex.Unlock()
+	for _, jobType := range jobTypes {
+		redactedTypes[jobType] = true
+	}
+}
+
+func isRedacted(jobType string) bool {
+	redactedMutex.RLock()
+	defer redactedMutex.RUnlock()
+	return redactedTypes[jobType]
+}
+
+type JobModel struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+	// Payload is null for the jobs of the redacted types.
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	IdempotencyKey *string         `json:"idempotencyKey"`
+	LastError      *string         `json:"lastError"`
+	RunAt          time.Time       `json:"runAt"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	FinishedAt     *time.Time      `json:"finishedAt"`
+}
+
+type ListJobsModel struct {
+	Status string `query:"status"`
+	Type   string `query:"type"`
+	// Before is the id below which the jobs are listed, for the next page.
+	Before int64 `query:"before"`
+	Limit  int   `query:"limit"`
+}
@@ -0,0 +1,227 @@
+// Package jobs runs the slow and failing work, like mails and webhooks, out of the requests. The
+// jobs are kept in the database, so that they survive restarts, and a failing job is retried with
+// an exponential backoff until it is dead.
+package jobs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// lockTimeout is how long a job may run. A running job older than it is taken to be lost with its
+// worker and runs again.
+const lockTimeout = 10 * time.Minute
+
+// maxBackoff is the longest delay between two attempts.
+const maxBackoff = 6 * time.Hour
+
+// maxErrorLength is how much of the error of the last attempt is kept.
+const maxErrorLength = 1000
+
+// Handler runs a job. The job is retried when it returns an error, so it must be safe to run again.
+type Handler func(job *Job) error
+
+// Queue runs the jobs of the database with the handlers of their types.
+type Queue struct {
+	repository  *db.Repository
+	maxAttempts int
+	backoff     time.Duration
+	mutex       sync.RWMutex
+	handlers    map[string]Handler
+}
+
+func NewQueue(config *config.Config, repository *db.Repository) *Queue {
+	maxAttempts, backoff := config.GetJobRetry()
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Queue{
+		repository:  repository,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		handlers:    map[string]Handler{},
+	}
+}
+
+// Handle sets the handler of the jobs of the type.
+func (queue *Queue) Handle(jobType string, handler Handler) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	queue.handlers[jobType] = handler
+}
+
+// Enqueue adds a job to the queue of the database. See Enqueue.
+func (queue *Queue) Enqueue(jobType string, payload interface{}, key string) (int64, error) {
+	return Enqueue(queue.repository, jobType, payload, key)
+}
+
+// Enqueue adds a job of the type with the payload encoded as JSON, which runs as soon as a worker
+// is free. The executor may be a transaction, so that the job is added only with the changes which
+// cause it. When the idempotency key is not empty and a job has the key, no job is added and the
+// id of that job is returned.
+func Enqueue(executor db.Executor, jobType string, payload interface{}, key string) (int64, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", db.ErrInvalid, err)
+	}
+	idempotencyKey := sql.NullString{String: key, Valid: len(key) > 0}
+	now := time.Now().UTC()
+	id, err := db.Insert(executor, executor.Dialect().InsertIgnore(`
+	insert into jobs (type, payload, status, idempotency_key, run_at, created_at)
+	values (?, ?, ?, ?, ?, ?)`), jobType, string(encoded), StatusPending, idempotencyKey, now, now)
+	if errors.Is(err, sql.ErrNoRows) && idempotencyKey.Valid {
+		err = executor.QueryRow(`select id from jobs where idempotency_key = ?`, key).Scan(&id)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return id, nil
+}
+
+// Start runs the due jobs with the workers in the background, and looks for new ones at every interval.
+func (queue *Queue) Start(workers int, interval time.Duration) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				queue.RunDue()
+				<-ticker.C
+			}
+		}()
+	}
+}
+
+// RunDue runs the jobs which are due until none is left, and returns how many ran.
+func (queue *Queue) RunDue() int {
+	count := 0
+	for {
+		ran, err := queue.RunNext()
+		if err != nil || !ran {
+			return count
+		}
+		count++
+	}
+}
+
+// RunNext claims the next due job and runs it. It returns false when no job is due.
+func (queue *Queue) RunNext() (bool, error) {
+	job, err := queue.claim()
+	if err != nil || job == nil {
+		return false, err
+	}
+	err = queue.run(job)
+	queue.finish(job, err)
+	return true, nil
+}
+
+// claim marks the oldest due job as running. The update only matches the job as it was selected,
+// so that two workers never claim the same job.
+func (queue *Queue) claim() (*Job, error) {
+	for {
+		now := time.Now().UTC()
+		job := new(Job)
+		var status, payload string
+		row := queue.repository.QueryRow(`
+		select id, type, payload, status, attempts
+		from jobs
+		where (status = ? and run_at <= ?) or (status = ? and locked_at < ?)
+		order by run_at asc, id asc
+		limit 1`, StatusPending, now, StatusRunning, now.Add(-lockTimeout))
+		err := row.Scan(&job.ID, &job.Type, &payload, &status, &job.Attempts)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		res, err := queue.repository.Exec(`
+		update jobs set status = ?, attempts = attempts + 1, locked_at = ?
+		where id = ? and status = ? and attempts = ?`, StatusRunning, now, job.ID, status, job.Attempts)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		claimed, err := res.RowsAffected()
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		if claimed == 1 {
+			job.Payload = []byte(payload)
+			job.Attempts++
+			return job, nil
+		}
+	}
+}
+
+// run runs the handler of the job, and turns a panic into an error.
+func (queue *Queue) run(job *Job) (err error) {
+	queue.mutex.RLock()
+	handler, ok := queue.handlers[job.Type]
+	queue.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler for the jobs of type %s", job.Type)
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return handler(job)
+}
+
+// finish stores the result of the run. A failed job runs again after the backoff, unless it has
+// no attempts left, in which case it is dead.
+func (queue *Queue) finish(job *Job, runErr error) {
+	now := time.Now().UTC()
+	var err error
+	var message string
+	if runErr != nil {
+		message = runErr.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+	}
+	switch {
+	case runErr == nil:
+		_, err = queue.repository.Exec(`
+		update jobs set status = ?, locked_at = null, last_error = null, finished_at = ?
+		where id = ? and attempts = ?`, StatusSucceeded, now, job.ID, job.Attempts)
+	case job.Attempts >= queue.maxAttempts:
+		log.Printf("error: the job %d of type %s is dead: %v\n", job.ID, job.Type, runErr)
+		_, err = queue.repository.Exec(`
+		update jobs set status = ?, locked_at = null, last_error = ?, finished_at = ?
+		where id = ? and attempts = ?`, StatusDead, message, now, job.ID, job.Attempts)
+	default:
+		_, err = queue.repository.Exec(`
+		update jobs set status = ?, locked_at = null, last_error = ?, run_at = ?
+		where id = ? and attempts = ?`, StatusPending, message, now.Add(Backoff(queue.backoff, job.Attempts)), job.ID, job.Attempts)
+	}
+	if err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// Backoff returns the delay after the attempt of a job: the base delay, doubled at every attempt
+// after the first one, up to six hours.
+func Backoff(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
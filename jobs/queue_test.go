@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/migrations"
+)
+
+// newTestQueue returns a queue on a new SQLite database, whose failed jobs run again at once.
+func newTestQueue(t *testing.T, maxAttempts string) (*Queue, *JobsService) {
+	t.Helper()
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "jobs.db"))
+	t.Setenv("JOB_MAX_ATTEMPTS", maxAttempts)
+	t.Setenv("JOB_BACKOFF_SECONDS", "0")
+	config := config.FromEnv()
+	repository := db.NewRepository(config)
+	if repository == nil {
+		t.Fatal("the database is not opened")
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewQueue(config, repository), NewJobsService(config, repository)
+}
+
+func TestQueue(t *testing.T) {
+	queue, service := newTestQueue(t, "3")
+	var got []string
+	queue.Handle("greet", func(job *Job) error {
+		var name string
+		err := job.Decode(&name)
+		got = append(got, name)
+		return err
+	})
+	first, err := queue.Enqueue("greet", "alice", "greet:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := queue.Enqueue("greet", "alice", "greet:alice")
+	if err != nil || again != first {
+		t.Fatalf("the same key got the job %d, want %d: %v", again, first, err)
+	}
+	_, err = queue.Enqueue("greet", "bob", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran := queue.RunDue(); ran != 2 || strings.Join(got, ",") != "alice,bob" {
+		t.Fatalf("ran %d jobs: %v", ran, got)
+	}
+	job, err := service.Job(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != StatusSucceeded || job.Attempts != 1 || job.FinishedAt == nil {
+		t.Errorf("got the job %+v", job)
+	}
+	// The key keeps the job from being added again after it ran.
+	again, _ = queue.Enqueue("greet", "alice", "greet:alice")
+	if again != first || queue.RunDue() != 0 {
+		t.Errorf("a job with the same key is added again")
+	}
+}
+
+func TestQueueRetriesUntilDead(t *testing.T) {
+	queue, service := newTestQueue(t, "3")
+	calls := 0
+	queue.Handle("flaky", func(job *Job) error {
+		calls++
+		if calls == 2 {
+			panic("boom")
+		}
+		return errors.New("the provider is down")
+	})
+	id, err := queue.Enqueue("flaky", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran := queue.RunDue(); ran != 3 {
+		t.Fatalf("ran %d times, want 3", ran)
+	}
+	job, err := service.Job(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != StatusDead || job.Attempts != 3 || job.LastError == nil || *job.LastError != "the provider is down" {
+		t.Fatalf("got the job %+v", job)
+	}
+	counts, err := service.Counts()
+	if err != nil || counts[StatusDead] != 1 {
+		t.Errorf("got the counts %v: %v", counts, err)
+	}
+	dead, err := service.List(&ListJobsModel{Status: StatusDead})
+	if err != nil || len(dead) != 1 || dead[0].ID != id {
+		t.Errorf("got the dead jobs %+v: %v", dead, err)
+	}
+
+	queue.Handle("flaky", func(job *Job) error { return nil })
+	err = service.Retry(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran := queue.RunDue(); ran != 1 {
+		t.Fatalf("the retried job ran %d times", ran)
+	}
+	job, _ = service.Job(id)
+	if job.Status != StatusSucceeded || job.Attempts != 1 {
+		t.Errorf("got the retried job %+v", job)
+	}
+	if err = service.Retry(id); !errors.Is(err, db.ErrConflict) {
+		t.Errorf("a job which succeeded is retried: %v", err)
+	}
+	if err = service.Retry(id + 100); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("a missing job is retried: %v", err)
+	}
+}
+
+func TestQueueWaitsForBackoff(t *testing.T) {
+	queue, service := newTestQueue(t, "5")
+	queue.backoff = time.Minute
+	queue.Handle("fail", func(job *Job) error { return errors.New("failed") })
+	_, err := queue.Enqueue("unknown", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := queue.Enqueue("fail", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran := queue.RunDue(); ran != 2 {
+		t.Fatalf("ran %d jobs, want 2", ran)
+	}
+	if ran := queue.RunDue(); ran != 0 {
+		t.Errorf("%d jobs ran again before their backoff", ran)
+	}
+	job, _ := service.Job(id)
+	if job.Status != StatusPending || job.RunAt.Before(time.Now().Add(50*time.Second)) {
+		t.Errorf("got the job %+v", job)
+	}
+	jobs, _ := service.List(&ListJobsModel{Type: "unknown"})
+	if len(jobs) != 1 || jobs[0].LastError == nil || !strings.Contains(*jobs[0].LastError, "no handler") {
+		t.Errorf("got the jobs without a handler %+v", jobs)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		20: maxBackoff,
+	}
+	for attempts, want := range cases {
+		if got := Backoff(30*time.Second, attempts); got != want {
+			t.Errorf("Backoff(30s, %d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestRedactedPayloads(t *testing.T) {
+	queue, service := newTestQueue(t, "3")
+	Redact("secret")
+	secretID, err := queue.Enqueue("secret", map[string]string{"to": "alice@example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	greetID, err := queue.Enqueue("greet", "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := service.List(&ListJobsModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloads := map[int64]string{}
+	for _, job := range jobs {
+		payloads[job.ID] = string(job.Payload)
+	}
+	if payloads[secretID] != "" || payloads[greetID] != `"alice"` {
+		t.Errorf("listed the payloads %v", payloads)
+	}
+	job, err := service.Job(secretID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Payload != nil {
+		t.Errorf("the redacted job has the payload %s", job.Payload)
+	}
+}
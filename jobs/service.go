@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+const jobColumns = `id, type, payload, status, attempts, idempotency_key, last_error, run_at, created_at, finished_at`
+
+// JobsService lets the admins see the jobs and run the failed ones again.
+type JobsService struct {
+	config     *config.Config
+	repository *db.Repository
+}
+
+func NewJobsService(config *config.Config, repository *db.Repository) *JobsService {
+	return &JobsService{
+		config:     config,
+		repository: repository,
+	}
+}
+
+// List returns the jobs of the status and the type, which are every one when empty, the newest first.
+func (service *JobsService) List(model *ListJobsModel) ([]JobModel, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if len(model.Status) > 0 {
+		where = append(where, "status = ?")
+		args = append(args, model.Status)
+	}
+	if len(model.Type) > 0 {
+		where = append(where, "type = ?")
+		args = append(args, model.Type)
+	}
+	if model.Before > 0 {
+		where = append(where, "id < ?")
+		args = append(args, model.Before)
+	}
+	pageSize, maxPageSize := service.config.GetPageSize()
+	args = append(args, db.PageLimit(model.Limit, pageSize, maxPageSize))
+	rows, err := service.repository.Query(fmt.Sprintf(`
+	select %s
+	from jobs
+	where %s
+	order by id desc
+	limit ?`, jobColumns, strings.Join(where, " and ")), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	jobs := []JobModel{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// Counts returns how many jobs have each status.
+func (service *JobsService) Counts() (map[string]int, error) {
+	counts := map[string]int{StatusPending: 0, StatusRunning: 0, StatusSucceeded: 0, StatusDead: 0}
+	rows, err := service.repository.Query(`select status, count(*) from jobs group by status`)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var count int
+		err := rows.Scan(&status, &count)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+func (service *JobsService) Job(jobID int64) (*JobModel, error) {
+	row := service.repository.QueryRow(fmt.Sprintf(`select %s from jobs where id = ?`, jobColumns), jobID)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: job %d", db.ErrNotFound, jobID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return job, nil
+}
+
+// Retry runs a dead job, or a pending one which waits for its next attempt, as soon as a worker
+// is free, with all its attempts again.
+func (service *JobsService) Retry(jobID int64) error {
+	job, err := service.Job(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status != StatusDead && job.Status != StatusPending {
+		return fmt.Errorf("%w: the job is %s", db.ErrConflict, job.Status)
+	}
+	res, err := service.repository.Exec(`
+	update jobs set status = ?, attempts = 0, run_at = ?, finished_at = null
+	where id = ? and status = ?`, StatusPending, time.Now().UTC(), jobID, job.Status)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	updated, err := res.RowsAffected()
+	if err == nil && updated == 0 {
+		return fmt.Errorf("%w: the job has changed", db.ErrConflict)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob reads the jobColumns. The payloads of the redacted types are left out.
+func scanJob(row rowScanner) (*JobModel, error) {
+	job := new(JobModel)
+	var payload string
+	var idempotencyKey, lastError sql.NullString
+	var finishedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.Type, &payload, &job.Status, &job.Attempts, &idempotencyKey, &lastError, &job.RunAt, &job.CreatedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !isRedacted(job.Type) {
+		job.Payload = []byte(payload)
+	}
+	if idempotencyKey.Valid {
+		job.IdempotencyKey = &idempotencyKey.String
+	}
+	if lastError.Valid {
+		job.LastError = &lastError.String
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
@@ -1,46 +1,94 @@
 package mail
 
 import (
-	"fmt"
 	"log"
 
-	"github.com/mailjet/mailjet-apiv3-go/v3"
 	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/jobs"
 )
 
+// JobSend is the type of the jobs which send the mails. Their payloads are redacted, since the
+// mails hold the addresses of the users and the links with their tokens.
+const JobSend = "mail.send"
+
+// TokenMinutes is how long the tokens in the verification mails are valid.
+const TokenMinutes = 10
+
+// MailClient sends the mails of the services through a mailer, rendered by the templates. The
+// mails are sent during the call unless the client uses a queue.
 type MailClient struct {
-	*mailjet.Client
-	sender string
+	mailer    Mailer
+	templates *Templates
+	queue     *jobs.Queue
 }
 
+// NewMailClient returns a client which sends through the mailer of the config, with the
+// templates of the config. It returns nil when the mailer or the templates cannot be made.
 func NewMailClient(config *config.Config) *MailClient {
-	apiKey, secretKey := config.GetEmailKey()
-	newClient := mailjet.NewMailjetClient(apiKey, secretKey)
-	return &MailClient{
-		Client: newClient,
-		sender: config.GetEmailAddress(),
+	mailer, err := NewMailer(config)
+	if err != nil {
+		log.Println("error:", err)
+		return nil
+	}
+	templates, err := NewTemplates(config.GetMailTemplateDirectory(), config.GetSiteURL())
+	if err != nil {
+		log.Println("error:", err)
+		return nil
 	}
+	return NewClient(mailer, templates)
+}
+
+// NewClient returns a client which sends through the mailer.
+func NewClient(mailer Mailer, templates *Templates) *MailClient {
+	return &MailClient{mailer: mailer, templates: templates}
+}
+
+// UseQueue makes the client enqueue the mails, which the workers of the queue send, so that a
+// slow or failing provider does not fail the requests. The mails are rendered before they are enqueued.
+func (client *MailClient) UseQueue(queue *jobs.Queue) {
+	jobs.Redact(JobSend)
+	queue.Handle(JobSend, func(job *jobs.Job) error {
+		message := new(Message)
+		err := job.Decode(message)
+		if err != nil {
+			return err
+		}
+		return client.mailer.Send(message)
+	})
+	client.queue = queue
 }
 
-func (client *MailClient) SendToken(emailToken string, receiver string) bool {
-	messagesInfo := []mailjet.InfoMessagesV31{
-		{
-			From: &mailjet.RecipientV31{
-				Email: client.sender,
-			},
-			To: &mailjet.RecipientsV31{
-				mailjet.RecipientV31{
-					Email: receiver,
-				},
-			},
-			Subject:  "Email Verification",
-			TextPart: fmt.Sprintf("The email verification token is %s. Input this code in 10 minutes.", emailToken),
-		},
+// Templates returns the templates of the mails.
+func (client *MailClient) Templates() *Templates {
+	return client.templates
+}
+
+func (client *MailClient) SendToken(emailToken string, receiver string, locale string) bool {
+	return client.SendTemplate(receiver, locale, "verification", map[string]interface{}{
+		"Token":   emailToken,
+		"Minutes": TokenMinutes,
+	})
+}
+
+// SendTemplate renders the mail of the name in the locale of the receiver and sends it.
+func (client *MailClient) SendTemplate(receiver string, locale string, name string, data map[string]interface{}) bool {
+	return client.SendTemplateOnce("", receiver, locale, name, data)
+}
+
+// SendTemplateOnce is SendTemplate for the callers which may run again, like jobs. The mail is
+// enqueued only once for the idempotency key.
+func (client *MailClient) SendTemplateOnce(key string, receiver string, locale string, name string, data map[string]interface{}) bool {
+	message, err := client.templates.Render(name, locale, data)
+	if err != nil {
+		log.Println(err)
+		return false
 	}
-	messages := mailjet.MessagesV31{
-		Info: messagesInfo,
+	message.Receiver = receiver
+	if client.queue != nil {
+		_, err = client.queue.Enqueue(JobSend, message, key)
+		return err == nil
 	}
-	_, err := client.SendMailV31(&messages)
+	err = client.mailer.Send(message)
 	if err != nil {
 		log.Println(err)
 		return false
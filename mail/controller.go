@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// MailController lets the admins see the mail templates rendered with sample data.
+type MailController struct {
+	*echo.Echo
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+	mailClient      *MailClient
+}
+
+func NewMailController(echo *echo.Echo, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc, mailClient *MailClient) *MailController {
+	return &MailController{Echo: echo, jwtMiddleware: jwtMiddleware, adminMiddleware: adminMiddleware, mailClient: mailClient}
+}
+
+func (controller *MailController) UseRoute() {
+	templates := controller.mailClient.Templates()
+	controller.GET("/mail/templates", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":    true,
+			"templates": templates.Names(),
+			"locales":   templates.Locales(),
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	// The preview is the HTML of the mail, or its text when format is text. The locale is the
+	// default one when it is not given.
+	controller.GET("/mail/templates/:name/preview", func(c echo.Context) error {
+		message, err := templates.Preview(c.Param("name"), c.QueryParam("locale"))
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Rendering the mail is failed.",
+			})
+		}
+		c.Response().Header().Set("X-Mail-Subject", message.Subject)
+		switch c.QueryParam("format") {
+		case "", "html":
+			return c.HTML(http.StatusOK, message.HTML)
+		case "text":
+			return c.String(http.StatusOK, message.Text)
+		default:
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "The format is html or text.",
+			})
+		}
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+}
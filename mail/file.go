@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every mail as an .eml file in the directory, for the local development.
+type FileMailer struct {
+	directory string
+	sender    string
+}
+
+func NewFileMailer(directory string, sender string) (*FileMailer, error) {
+	err := os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &FileMailer{
+		directory: directory,
+		sender:    sender,
+	}, nil
+}
+
+// Send writes the mail to a file named by the time it is sent, so that the files sort in order.
+func (mailer *FileMailer) Send(message *Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), uuid.NewString()[:8])
+	return os.WriteFile(filepath.Join(mailer.directory, name), message.encode(mailer.sender, now), 0o644)
+}
+
+// ConsoleMailer prints every mail to the writer, for the local development.
+type ConsoleMailer struct {
+	mu     sync.Mutex
+	writer io.Writer
+	sender string
+}
+
+func NewConsoleMailer(writer io.Writer, sender string) *ConsoleMailer {
+	return &ConsoleMailer{
+		writer: writer,
+		sender: sender,
+	}
+}
+
+func (mailer *ConsoleMailer) Send(message *Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	_, err := fmt.Fprintf(mailer.writer, "----- mail to %s -----\r\n%s\r\n", message.Receiver, message.encode(mailer.sender, time.Now()))
+	return err
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/quavious/blog-factory-server/config"
+)
+
+// Providers of the mailers, which MAIL_PROVIDER chooses from.
+const (
+	ProviderMailjet = "mailjet"
+	ProviderSMTP    = "smtp"
+	ProviderFile    = "file"
+	ProviderConsole = "console"
+)
+
+// Mailer delivers the messages. The sender is set when the mailer is made.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+// Message is a mail to one receiver.
+type Message struct {
+	Receiver string
+	Subject  string
+	Text     string
+	// HTML is the alternative of the text for the clients which show HTML. It may be empty.
+	HTML string
+}
+
+// NewMailer returns the mailer of the provider in the config.
+func NewMailer(config *config.Config) (Mailer, error) {
+	sender := config.GetEmailAddress()
+	switch config.GetMailProvider() {
+	case ProviderMailjet:
+		apiKey, secretKey := config.GetEmailKey()
+		return NewMailjetMailer(apiKey, secretKey, sender)
+	case ProviderSMTP:
+		host, port, user, password, startTLS := config.GetSMTP()
+		return NewSMTPMailer(host, port, user, password, startTLS, sender)
+	case ProviderFile:
+		return NewFileMailer(config.GetMailDirectory(), sender)
+	case ProviderConsole:
+		return NewConsoleMailer(os.Stdout, sender), nil
+	}
+	return nil, fmt.Errorf("unknown mail provider %q", config.GetMailProvider())
+}
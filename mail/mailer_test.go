@@ -0,0 +1,273 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is an SMTP server which takes one session without STARTTLS and keeps what it is sent.
+type fakeSMTP struct {
+	listener net.Listener
+	done     chan struct{}
+	auth     string
+	from     string
+	to       string
+	data     string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTP{listener: listener, done: make(chan struct{})}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (server *fakeSMTP) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *fakeSMTP) serve() {
+	defer close(server.done)
+	conn, err := server.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			server.auth = strings.TrimPrefix(command, "AUTH PLAIN ")
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			server.from = command
+			reply("250 OK")
+		case "RCPT":
+			server.to = command
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			server.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// readMessage parses the encoded mail and returns it with its decoded subject and text.
+func readMessage(t *testing.T, encoded []byte) (*netmail.Message, string, string) {
+	t.Helper()
+	message, err := netmail.ReadMessage(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message, subject, string(text)
+}
+
+func TestEncode(t *testing.T) {
+	message := &Message{
+		Receiver: "alice@example.com",
+		Subject:  "Welcome, Zoë",
+		Text:     "The first line.\nA long second line " + strings.Repeat("=", 100),
+	}
+	encoded := message.encode("blog@example.com", time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC))
+	for _, line := range strings.SplitAfter(string(encoded), "\n") {
+		if strings.HasSuffix(line, "\n") && !strings.HasSuffix(line, "\r\n") {
+			t.Fatalf("the line %q does not end with CRLF", line)
+		}
+		if len(line) > 78 {
+			t.Errorf("the line %q is longer than 78 characters", line)
+		}
+	}
+	parsed, subject, text := readMessage(t, encoded)
+	if subject != message.Subject {
+		t.Errorf("got the subject %q, want %q", subject, message.Subject)
+	}
+	if text != strings.ReplaceAll(message.Text, "\n", "\r\n") {
+		t.Errorf("got the text %q", text)
+	}
+	if parsed.Header.Get("From") != "<blog@example.com>" || parsed.Header.Get("To") != "<alice@example.com>" {
+		t.Errorf("got the addresses %q and %q", parsed.Header.Get("From"), parsed.Header.Get("To"))
+	}
+	if !strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("got the message id %q", parsed.Header.Get("Message-ID"))
+	}
+}
+
+func TestEncodeHTML(t *testing.T) {
+	message := &Message{
+		Receiver: "alice@example.com",
+		Subject:  "Hi",
+		Text:     "Hello",
+		HTML:     "<p>Hello</p>",
+	}
+	parsed, err := netmail.ReadMessage(bytes.NewReader(message.encode("blog@example.com", time.Now())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("got the content type %q", parsed.Header.Get("Content-Type"))
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, want := range [][2]string{{"text/plain", "Hello"}, {"text/html", "<p>Hello</p>"}} {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The reader of the part decodes the quoted-printable body.
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(part.Header.Get("Content-Type"), want[0]) || string(body) != want[1] {
+			t.Errorf("got the part %q: %q", part.Header.Get("Content-Type"), body)
+		}
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "mails")
+	mailer, err := NewFileMailer(directory, "blog@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, subject := range []string{"First", "Second"} {
+		err = mailer.Send(&Message{Receiver: "alice@example.com", Subject: subject, Text: "Hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(directory, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	encoded, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, subject, text := readMessage(t, encoded)
+	if subject != "First" || text != "Hello" {
+		t.Errorf("the first file has the subject %q and the text %q", subject, text)
+	}
+}
+
+func TestConsoleMailer(t *testing.T) {
+	var output bytes.Buffer
+	err := NewConsoleMailer(&output, "blog@example.com").Send(&Message{Receiver: "alice@example.com", Subject: "Hi", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "alice@example.com") || !strings.Contains(output.String(), "Hello") {
+		t.Errorf("got the output %q", output.String())
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	server := newFakeSMTP(t)
+	mailer, err := NewSMTPMailer("127.0.0.1", server.port(), "blog", "secret", false, "blog@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mailer.Send(&Message{Receiver: "alice@example.com", Subject: "Hi", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.done
+	auth, _ := base64.StdEncoding.DecodeString(server.auth)
+	if string(auth) != "\x00blog\x00secret" {
+		t.Errorf("got the credentials %q", auth)
+	}
+	if server.from != "MAIL FROM:<blog@example.com>" || server.to != "RCPT TO:<alice@example.com>" {
+		t.Errorf("got the envelope %q, %q", server.from, server.to)
+	}
+	// The end of the data adds a line break to the text.
+	_, subject, text := readMessage(t, []byte(server.data))
+	if subject != "Hi" || text != "Hello\r\n" {
+		t.Errorf("got the subject %q and the text %q", subject, text)
+	}
+}
+
+func TestSMTPMailerNeedsSTARTTLS(t *testing.T) {
+	server := newFakeSMTP(t)
+	mailer, err := NewSMTPMailer("127.0.0.1", server.port(), "", "", true, "blog@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mailer.Send(&Message{Receiver: "alice@example.com", Subject: "Hi", Text: "Hello"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("a server without STARTTLS got the mail: %v", err)
+	}
+	<-server.done
+	if len(server.data) > 0 {
+		t.Error("the mail is sent in plain text")
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	templates, err := NewTemplates("", "https://blog.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(mailer, templates)
+	if !client.SendToken("123456", "alice@example.com", "") {
+		t.Fatal("the token is not sent")
+	}
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].Receiver != "alice@example.com" || !strings.Contains(messages[0].Text, "123456") {
+		t.Errorf("got the messages %+v", messages)
+	}
+}
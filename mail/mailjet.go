@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"errors"
+
+	"github.com/mailjet/mailjet-apiv3-go/v3"
+)
+
+// MailjetMailer sends the mails with the API of Mailjet.
+type MailjetMailer struct {
+	client *mailjet.Client
+	sender string
+}
+
+func NewMailjetMailer(apiKey string, secretKey string, sender string) (*MailjetMailer, error) {
+	if len(apiKey) == 0 || len(secretKey) == 0 {
+		return nil, errors.New("the keys of mailjet are not set")
+	}
+	return &MailjetMailer{
+		client: mailjet.NewMailjetClient(apiKey, secretKey),
+		sender: sender,
+	}, nil
+}
+
+func (mailer *MailjetMailer) Send(message *Message) error {
+	messagesInfo := []mailjet.InfoMessagesV31{
+		{
+			From: &mailjet.RecipientV31{
+				Email: mailer.sender,
+			},
+			To: &mailjet.RecipientsV31{
+				mailjet.RecipientV31{
+					Email: message.Receiver,
+				},
+			},
+			Subject:  message.Subject,
+			TextPart: message.Text,
+			HTMLPart: message.HTML,
+		},
+	}
+	messages := mailjet.MessagesV31{
+		Info: messagesInfo,
+	}
+	_, err := mailer.client.SendMailV31(&messages)
+	return err
+}
@@ -0,0 +1,27 @@
+package mail
+
+import "sync"
+
+// MemoryMailer keeps the mails instead of sending them, for the tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return new(MemoryMailer)
+}
+
+func (mailer *MemoryMailer) Send(message *Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	mailer.messages = append(mailer.messages, *message)
+	return nil
+}
+
+// Messages returns the kept mails in the order they were sent.
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	return append([]Message{}, mailer.messages...)
+}
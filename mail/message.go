@@ -0,0 +1,72 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// encode returns the message from the sender as a MIME document with CRLF line endings. The
+// text is encoded as quoted-printable, and the subject as an encoded word when it needs one.
+// A message with HTML is a multipart/alternative document of the text and the HTML.
+func (message *Message) encode(sender string, date time.Time) []byte {
+	domain := "localhost"
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		domain = sender[at+1:]
+	}
+	var encoded bytes.Buffer
+	headers := [][2]string{
+		{"From", (&netmail.Address{Address: sender}).String()},
+		{"To", (&netmail.Address{Address: message.Receiver}).String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", uuid.NewString(), domain)},
+		{"MIME-Version", "1.0"},
+	}
+	if len(message.HTML) == 0 {
+		headers = append(headers,
+			[2]string{"Content-Type", "text/plain; charset=utf-8"},
+			[2]string{"Content-Transfer-Encoding", "quoted-printable"},
+		)
+		writeHeaders(&encoded, headers)
+		writeQuotedPrintable(&encoded, message.Text)
+		return encoded.Bytes()
+	}
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	headers = append(headers, [2]string{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()})
+	writeHeaders(&encoded, headers)
+	for _, part := range [][2]string{{"text/plain", message.Text}, {"text/html", message.HTML}} {
+		writer, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part[0] + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		writeQuotedPrintable(writer, part[1])
+	}
+	parts.Close()
+	encoded.Write(body.Bytes())
+	return encoded.Bytes()
+}
+
+func writeHeaders(encoded *bytes.Buffer, headers [][2]string) {
+	for _, header := range headers {
+		fmt.Fprintf(encoded, "%s: %s\r\n", header[0], header[1])
+	}
+	encoded.WriteString("\r\n")
+}
+
+// writeQuotedPrintable writes the text as quoted-printable with CRLF line endings.
+func writeQuotedPrintable(out io.Writer, text string) {
+	writer := quotedprintable.NewWriter(out)
+	writer.Write([]byte(text))
+	writer.Close()
+}
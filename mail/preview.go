@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"fmt"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// samples are the data of the mails shown by Preview.
+var samples = map[string]map[string]interface{}{
+	"verification": {
+		"Token":   "123456",
+		"Minutes": TokenMinutes,
+	},
+	"sign_in_code": {
+		"Code":    "654321",
+		"Minutes": TokenMinutes,
+	},
+	"password_reset": {
+		"Username": "alice",
+	},
+	"notification": {
+		"Type":    "comment",
+		"Message": "bob commented on \"Hello World\".",
+		"Link":    "/posts/1#comment-1",
+	},
+	"comment_pending": {
+		"Title": "Hello World",
+		"Link":  "/posts/1",
+	},
+}
+
+// Preview renders the mail of the name in the locale with sample data, so that the admins see
+// how the mails look without sending them. The links of the samples are made on the site.
+func (templates *Templates) Preview(name string, locale string) (*Message, error) {
+	if _, ok := templates.text[name]; !ok {
+		return nil, fmt.Errorf("%w: mail template %s", db.ErrNotFound, name)
+	}
+	data := map[string]interface{}{}
+	for key, value := range samples[name] {
+		data[key] = value
+	}
+	if link, ok := data["Link"].(string); ok {
+		data["Link"] = templates.siteURL + link
+	}
+	message, err := templates.Render(name, locale, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return message, nil
+}
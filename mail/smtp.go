@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout is how long a mail may take to be sent, from dialing to quitting.
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends the mails to an SMTP server. When startTLS is set, the connection is
+// upgraded before anything else is sent, and a server without STARTTLS is an error.
+// The user signs in with PLAIN authentication when it is set.
+type SMTPMailer struct {
+	address  string
+	host     string
+	user     string
+	password string
+	startTLS bool
+	sender   string
+}
+
+func NewSMTPMailer(host string, port int, user string, password string, startTLS bool, sender string) (*SMTPMailer, error) {
+	if len(host) == 0 {
+		return nil, errors.New("the host of the SMTP server is not set")
+	}
+	return &SMTPMailer{
+		address:  net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		user:     user,
+		password: password,
+		startTLS: startTLS,
+		sender:   sender,
+	}, nil
+}
+
+func (mailer *SMTPMailer) Send(message *Message) error {
+	conn, err := net.DialTimeout("tcp", mailer.address, smtpTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	client, err := smtp.NewClient(conn, mailer.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if mailer.startTLS {
+		ok, _ := client.Extension("STARTTLS")
+		if !ok {
+			return fmt.Errorf("the SMTP server %s does not support STARTTLS", mailer.address)
+		}
+		err = client.StartTLS(&tls.Config{ServerName: mailer.host})
+		if err != nil {
+			return err
+		}
+	}
+	if len(mailer.user) > 0 {
+		err = client.Auth(smtp.PlainAuth("", mailer.user, mailer.password, mailer.host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(mailer.sender)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(message.Receiver)
+	if err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(message.encode(mailer.sender, time.Now()))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,279 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is the language of the mails to the users whose language has no translations.
+const DefaultLocale = "en"
+
+//go:embed templates
+var embedded embed.FS
+
+// Templates renders the transactional mails. Every mail has a text template, which defines
+// its subject and text, and an HTML template, which are put in the shared layouts. The
+// templates call t to translate a key of the locale files into the language of the receiver.
+type Templates struct {
+	siteURL string
+	locales map[string]map[string]string
+	text    map[string]*texttemplate.Template
+	html    map[string]*htmltemplate.Template
+}
+
+// overlay opens the files of the upper file system, and of the lower one when the upper one
+// has no such file.
+type overlay struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (overlay *overlay) Open(name string) (fs.File, error) {
+	file, err := overlay.upper.Open(name)
+	if err == nil {
+		return file, nil
+	}
+	return overlay.lower.Open(name)
+}
+
+// noTranslation stands for t while the templates are parsed. Render replaces it.
+func noTranslation(key string, args ...interface{}) string {
+	return key
+}
+
+// NewTemplates parses the embedded templates. A file in the override directory replaces the
+// embedded one of the same name, and the keys of its locale files replace the embedded keys,
+// so that the operators may change a single translation or add a language.
+func NewTemplates(directory string, siteURL string) (*Templates, error) {
+	base, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	fsys := base
+	layers := []fs.FS{base}
+	if len(directory) > 0 {
+		override := os.DirFS(directory)
+		fsys = &overlay{upper: override, lower: base}
+		layers = append(layers, override)
+	}
+	templates := &Templates{
+		siteURL: siteURL,
+		locales: map[string]map[string]string{},
+		text:    map[string]*texttemplate.Template{},
+		html:    map[string]*htmltemplate.Template{},
+	}
+	names := map[string]bool{}
+	for _, layer := range layers {
+		err = templates.loadLocales(layer)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := fs.Glob(layer, "*.txt")
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			name := strings.TrimSuffix(match, ".txt")
+			if name != "layout" {
+				names[name] = true
+			}
+		}
+	}
+	if _, ok := templates.locales[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("the mail templates have no %s locale", DefaultLocale)
+	}
+	funcs := map[string]interface{}{"t": noTranslation}
+	for name := range names {
+		text, err := texttemplate.New(name).Funcs(funcs).ParseFS(fsys, "layout.txt", name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("the mail template %s: %w", name, err)
+		}
+		html, err := htmltemplate.New(name).Funcs(funcs).ParseFS(fsys, "layout.html", name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("the mail template %s: %w", name, err)
+		}
+		templates.text[name] = text
+		templates.html[name] = html
+	}
+	return templates, nil
+}
+
+// loadLocales reads the locale files of the file system over the loaded ones.
+func (templates *Templates) loadLocales(fsys fs.FS) error {
+	matches, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		content, err := fs.ReadFile(fsys, match)
+		if err != nil {
+			return err
+		}
+		translations := map[string]string{}
+		err = json.Unmarshal(content, &translations)
+		if err != nil {
+			return fmt.Errorf("the locale file %s: %w", match, err)
+		}
+		locale := strings.ToLower(strings.TrimSuffix(path.Base(match), ".json"))
+		if templates.locales[locale] == nil {
+			templates.locales[locale] = map[string]string{}
+		}
+		for key, value := range translations {
+			templates.locales[locale][key] = value
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the mails in order.
+func (templates *Templates) Names() []string {
+	names := []string{}
+	for name := range templates.text {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales returns the languages which have translations in order.
+func (templates *Templates) Locales() []string {
+	locales := []string{}
+	for locale := range templates.locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Match returns the first language of an Accept-Language header, or of a plain language tag,
+// which has translations. It returns the default locale when none has.
+func (templates *Templates) Match(acceptLanguage string) string {
+	for _, locale := range ParseLocales(acceptLanguage) {
+		if _, ok := templates.locales[locale]; ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// ParseLocales returns the languages of an Accept-Language header by preference, without
+// their regions, so that ko-KR is ko.
+func ParseLocales(acceptLanguage string) []string {
+	type weighted struct {
+		locale string
+		weight float64
+	}
+	languages := []weighted{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		locale := strings.ToLower(strings.TrimSpace(fields[0]))
+		locale = strings.SplitN(locale, "-", 2)[0]
+		locale = strings.SplitN(locale, "_", 2)[0]
+		if len(locale) == 0 || locale == "*" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err == nil {
+					weight = parsed
+				}
+			}
+		}
+		if weight > 0 {
+			languages = append(languages, weighted{locale: locale, weight: weight})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].weight > languages[j].weight
+	})
+	locales := []string{}
+	for _, language := range languages {
+		locales = append(locales, language.locale)
+	}
+	return locales
+}
+
+// translate returns the t function of the locale. A key without a translation falls back to
+// the default locale, and then to the key itself. The arguments fill the verbs of the translation.
+func (templates *Templates) translate(locale string) func(key string, args ...interface{}) string {
+	return func(key string, args ...interface{}) string {
+		value, ok := templates.locales[locale][key]
+		if !ok {
+			value, ok = templates.locales[DefaultLocale][key]
+		}
+		if !ok {
+			return key
+		}
+		if len(args) == 0 {
+			return value
+		}
+		return fmt.Sprintf(value, args...)
+	}
+}
+
+// Render returns the mail of the name in the locale, falling back to the default locale when
+// the locale has no translations. The data is given to the templates with the Locale, SiteURL
+// and Subject keys added.
+func (templates *Templates) Render(name string, locale string, data map[string]interface{}) (*Message, error) {
+	text, ok := templates.text[name]
+	if !ok {
+		return nil, fmt.Errorf("no mail template %q", name)
+	}
+	locale = templates.Match(locale)
+	values := map[string]interface{}{}
+	for key, value := range data {
+		values[key] = value
+	}
+	values["Locale"] = locale
+	values["SiteURL"] = templates.siteURL
+	funcs := map[string]interface{}{"t": templates.translate(locale)}
+
+	text, err := text.Clone()
+	if err != nil {
+		return nil, err
+	}
+	text.Funcs(funcs)
+	var subject, body bytes.Buffer
+	err = text.ExecuteTemplate(&subject, "subject", values)
+	if err != nil {
+		return nil, err
+	}
+	values["Subject"] = strings.TrimSpace(subject.String())
+	err = text.ExecuteTemplate(&body, "layout.txt", values)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := templates.html[name].Clone()
+	if err != nil {
+		return nil, err
+	}
+	html.Funcs(funcs)
+	var page bytes.Buffer
+	err = html.ExecuteTemplate(&page, "layout.html", values)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(values["Subject"].(string), "\r\n") {
+		return nil, errors.New("the subject of the mail has a line break")
+	}
+	return &Message{
+		Subject: values["Subject"].(string),
+		Text:    strings.TrimSpace(body.String()),
+		HTML:    page.String(),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := NewTemplates("", "https://blog.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"comment_pending", "notification", "password_reset", "sign_in_code", "verification"}
+	if !reflect.DeepEqual(templates.Names(), want) {
+		t.Fatalf("got the templates %v, want %v", templates.Names(), want)
+	}
+	for _, name := range templates.Names() {
+		for _, locale := range templates.Locales() {
+			message, err := templates.Preview(name, locale)
+			if err != nil {
+				t.Fatalf("%s in %s: %v", name, locale, err)
+			}
+			// A key which is not translated is rendered as itself.
+			for _, part := range []string{message.Subject, message.Text, message.HTML} {
+				if len(part) == 0 || strings.Contains(part, name+".") || strings.Contains(part, "layout.") {
+					t.Errorf("%s in %s has an untranslated or empty part: %q", name, locale, part)
+				}
+			}
+			if !strings.Contains(message.HTML, `lang="`+locale+`"`) || !strings.Contains(message.Text, "https://blog.example.com") {
+				t.Errorf("%s in %s is not in the layout: %q", name, locale, message.HTML)
+			}
+		}
+	}
+
+	message, err := templates.Render("verification", "ko-KR,ko;q=0.9", map[string]interface{}{"Token": "123456", "Minutes": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	korean, _ := templates.Preview("verification", "ko")
+	if message.Subject != korean.Subject {
+		t.Errorf("got the subject %q for ko-KR, want %q", message.Subject, korean.Subject)
+	}
+	message, err = templates.Render("verification", "fr", map[string]interface{}{"Token": "123456", "Minutes": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Subject != "Email Verification" || !strings.Contains(message.Text, "token is 123456.") {
+		t.Errorf("an unknown locale got %q: %q", message.Subject, message.Text)
+	}
+
+	message, err = templates.Render("comment_pending", "", map[string]interface{}{"Title": "<script>", "Link": "https://blog.example.com/posts/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(message.HTML, "<script>") || !strings.Contains(message.Text, "<script>") {
+		t.Errorf("the HTML is not escaped or the text is: %q, %q", message.HTML, message.Text)
+	}
+	if _, err = templates.Render("missing", "", nil); err == nil {
+		t.Error("a missing template is rendered")
+	}
+}
+
+func TestTemplatesOverride(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"password_reset.txt": `{{define "subject"}}{{t "password_reset.subject"}}{{end}}{{define "text"}}Custom reset for {{.Username}}{{end}}`,
+		"locales/en.json":    `{"password_reset.subject": "Custom subject"}`,
+		"locales/de.json":    `{"verification.subject": "E-Mail-Bestätigung"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	templates, err := NewTemplates(directory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := templates.Render("password_reset", "en", map[string]interface{}{"Username": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Subject != "Custom subject" || !strings.HasPrefix(message.Text, "Custom reset for alice") {
+		t.Errorf("the override is not used: %q, %q", message.Subject, message.Text)
+	}
+	// The embedded keys which are not overridden are kept.
+	if !strings.Contains(message.HTML, "The password of your account was just reset.") {
+		t.Errorf("the embedded HTML is not used: %q", message.HTML)
+	}
+	// A new language falls back to the default one for the keys it does not translate.
+	message, err = templates.Render("verification", "de", map[string]interface{}{"Token": "123456", "Minutes": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Subject != "E-Mail-Bestätigung" || !strings.Contains(message.Text, "token is 123456.") {
+		t.Errorf("got %q: %q", message.Subject, message.Text)
+	}
+}
+
+func TestParseLocales(t *testing.T) {
+	cases := map[string][]string{
+		"":                           {},
+		"ko":                         {"ko"},
+		"en-US,en;q=0.9,ko;q=0.8":    {"en", "en", "ko"},
+		"ko;q=0.5, ja-JP, *;q=0.1":   {"ja", "ko"},
+		"fr;q=0, de_DE;q=0.3, EN":    {"en", "de"},
+		"pt-BR;q=invalid, es;q=0.99": {"pt", "es"},
+	}
+	for header, want := range cases {
+		got := ParseLocales(header)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseLocales(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
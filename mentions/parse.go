@@ -0,0 +1,24 @@
+package mentions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pattern matches a mention which does not follow a word character, so that email addresses are not mentions.
+var pattern = regexp.MustCompile(`(^|[^\w@])@([\w.-]*\w)`)
+
+// Parse returns the usernames mentioned in the content, each once, in the order they appear.
+func Parse(content string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		name := match[2]
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names
+}
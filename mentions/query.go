@@ -0,0 +1,125 @@
+package mentions
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// Sources of the mentions.
+const (
+	SourcePost    = "post"
+	SourceComment = "comment"
+)
+
+// Record stores the mentions of the content and returns the users who are mentioned by the source
+// for the first time. The records are kept when an edit removes a mention, so that mentioning the
+// user again does not notify them twice. Authors who mention themselves are skipped.
+func Record(executor db.Executor, source string, sourceID int, authorID string, content string) ([]string, error) {
+	names := Parse(content)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{}
+	for _, name := range names {
+		args = append(args, strings.ToLower(name))
+	}
+	rows, err := executor.Query(fmt.Sprintf(`select id from users where %s in (%s)`, executor.Dialect().Fold("username"), placeholders(len(names))), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+		err := rows.Scan(&userID)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if userID != authorID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	rows.Close()
+	mentioned := []string{}
+	createdAt := time.Now().UTC()
+	for _, userID := range userIDs {
+		res, err := executor.Exec(executor.Dialect().InsertIgnore(`
+		insert into mentions (source_type, source_id, user_id, author_id, created_at)
+		values (?, ?, ?, ?, ?)`), source, sourceID, userID, authorID, createdAt)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		if inserted > 0 {
+			mentioned = append(mentioned, userID)
+		}
+	}
+	return mentioned, nil
+}
+
+// Remove deletes the mentions of the sources.
+func Remove(executor db.Executor, source string, sourceIDs ...int) error {
+	if len(sourceIDs) == 0 {
+		return nil
+	}
+	args := []interface{}{source}
+	for _, id := range sourceIDs {
+		args = append(args, id)
+	}
+	_, err := executor.Exec(fmt.Sprintf(`delete from mentions where source_type = ? and source_id in (%s)`, placeholders(len(sourceIDs))), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// Usernames returns the usernames mentioned by each source, keyed in lower case.
+func Usernames(executor db.Executor, source string, sourceIDs []int) (map[int]map[string]string, error) {
+	usernames := map[int]map[string]string{}
+	if len(sourceIDs) == 0 {
+		return usernames, nil
+	}
+	args := []interface{}{source}
+	for _, id := range sourceIDs {
+		args = append(args, id)
+	}
+	rows, err := executor.Query(fmt.Sprintf(`
+	select m.source_id, u.username
+	from mentions as m
+	join users as u on m.user_id = u.id
+	where m.source_type = ? and m.source_id in (%s)`, placeholders(len(sourceIDs))), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sourceID int
+		var username string
+		err := rows.Scan(&sourceID, &username)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if usernames[sourceID] == nil {
+			usernames[sourceID] = map[string]string{}
+		}
+		usernames[sourceID][strings.ToLower(username)] = username
+	}
+	return usernames, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,51 @@
+package mentions
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type Mention struct {
+	Username string `json:"username"`
+	URL      string `json:"url"`
+}
+
+// ProfileURL returns the address of the profile page of the user on the site.
+func ProfileURL(siteURL string, username string) string {
+	return fmt.Sprintf("%s/users/%s", siteURL, url.PathEscape(username))
+}
+
+// Render escapes the content as HTML and links the mentions of the given usernames, keyed in lower case,
+// to their profiles. The mentions of unknown users are left as text.
+func Render(content string, usernames map[string]string, siteURL string) string {
+	var rendered strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := match[4], match[5]
+		username, ok := usernames[strings.ToLower(content[start:end])]
+		if !ok {
+			continue
+		}
+		// The @ is right before the name.
+		rendered.WriteString(html.EscapeString(content[last : start-1]))
+		fmt.Fprintf(&rendered, `<a href="%s">@%s</a>`, html.EscapeString(ProfileURL(siteURL, username)), html.EscapeString(username))
+		last = end
+	}
+	rendered.WriteString(html.EscapeString(content[last:]))
+	return rendered.String()
+}
+
+// List returns the mentions of the given usernames with their profile addresses, sorted by the names.
+func List(usernames map[string]string, siteURL string) []Mention {
+	mentions := []Mention{}
+	for _, username := range usernames {
+		mentions = append(mentions, Mention{Username: username, URL: ProfileURL(siteURL, username)})
+	}
+	sort.Slice(mentions, func(i, j int) bool {
+		return mentions[i].Username < mentions[j].Username
+	})
+	return mentions
+}
@@ -10,15 +10,15 @@ import (
 func NewAdminMiddleware(repository *db.Repository) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			username, isOK := c.Get("userID").(string)
+			userID, isOK := c.Get("userID").(string)
 			if !isOK {
 				return c.JSON(http.StatusForbidden, &db.BadResponse{
 					Status:  false,
-					Message: "Invalid username.",
+					Message: "Invalid user.",
 				})
 			}
 			var isAdmin bool
-			row := repository.QueryRow("select isAdmin from users where username = ?", username)
+			row := repository.QueryRow("select is_admin from users where id = ?", userID)
 			err := row.Scan(&isAdmin)
 			if err != nil || !isAdmin {
 				return c.JSON(http.StatusForbidden, &db.BadResponse{
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+)
+
+// NewIPExtractor returns how the address of the client is found. Without trusted proxies it is the
+// address of the connection, since the headers can be forged by anyone. Behind them it is the last
+// address of X-Forwarded-For which is not one of the proxies.
+func NewIPExtractor(config *config.Config) echo.IPExtractor {
+	proxies := config.GetTrustedProxies()
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Printf("error: the trusted proxy %q is invalid.\n", proxy)
+			continue
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -27,31 +27,8 @@ func NewJWTMiddleware(config *config.Config) echo.MiddlewareFunc {
 					Message: err.Error(),
 				})
 			}
-			split := strings.Split(header, " ")
-			if len(split) < 2 {
-				err = errors.New("erorr: invalid token string")
-				return c.JSON(http.StatusForbidden, &db.BadResponse{
-					Status:  false,
-					Message: err.Error(),
-				})
-			}
-			accessToken, err := jwt.Parse(split[1], func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, errors.New("error: unexpected signing method")
-				}
-				return []byte(jwtAccessSecret), nil
-			})
-			if !accessToken.Valid || err != nil {
-				err = errors.New("error: invalid tokens")
-				return c.JSON(http.StatusForbidden, &db.BadResponse{
-					Status:  false,
-					Message: err.Error(),
-				})
-			}
-			payload, ok := accessToken.Claims.(jwt.MapClaims)
-
-			if !ok {
-				err = errors.New("error: token parsing error")
+			payload, err := parseAccessToken(header, jwtAccessSecret)
+			if err != nil {
 				return c.JSON(http.StatusForbidden, &db.BadResponse{
 					Status:  false,
 					Message: err.Error(),
@@ -63,3 +40,45 @@ func NewJWTMiddleware(config *config.Config) echo.MiddlewareFunc {
 		}
 	}
 }
+
+// NewOptionalJWTMiddleware sets the user of a valid access token like NewJWTMiddleware,
+// but lets the requests without one through as anonymous.
+func NewOptionalJWTMiddleware(config *config.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		jwtAccessSecret, _ := config.GetJWTSecret()
+
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get("Authorization")
+			if len(header) == 0 {
+				return next(c)
+			}
+			payload, err := parseAccessToken(header, jwtAccessSecret)
+			if err == nil {
+				c.Set("userID", payload["userId"])
+				c.Set("email", payload["email"])
+			}
+			return next(c)
+		}
+	}
+}
+
+func parseAccessToken(header string, jwtAccessSecret string) (jwt.MapClaims, error) {
+	split := strings.Split(header, " ")
+	if len(split) < 2 {
+		return nil, errors.New("erorr: invalid token string")
+	}
+	accessToken, err := jwt.Parse(split[1], func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("error: unexpected signing method")
+		}
+		return []byte(jwtAccessSecret), nil
+	})
+	if err != nil || !accessToken.Valid {
+		return nil, errors.New("error: invalid tokens")
+	}
+	payload, ok := accessToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("error: token parsing error")
+	}
+	return payload, nil
+}
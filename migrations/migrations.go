@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+//go:embed sql/*/*.sql
+var files embed.FS
+
+// statementEnd is a semicolon at the end of a line, which ends a statement.
+var statementEnd = regexp.MustCompile(`;\s*(\n|$)`)
+
+// fileName is the name of a migration file, like 0001_create_base_tables.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema. Up applies it and Down reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads the embedded migrations of the dialect in version order. Every version needs both an
+// up and a down file.
+func Load(dialect db.Dialect) ([]Migration, error) {
+	return load(files, path.Join("sql", string(dialect)))
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if len(migration.Up) == 0 || len(migration.Down) == 0 {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// statements splits a migration into its statements, which end with a semicolon at the end of a line.
+func statements(script string) []string {
+	result := []string{}
+	for _, statement := range statementEnd.Split(script, -1) {
+		statement = strings.TrimSpace(statement)
+		if len(statement) > 0 {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+func TestLoad(t *testing.T) {
+	mysql, err := Load(db.MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mysql) == 0 {
+		t.Fatal("no migrations are embedded")
+	}
+	for _, dialect := range []db.Dialect{db.MySQL, db.SQLite, db.PostgreSQL} {
+		migrations, err := Load(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		if len(migrations) != len(mysql) {
+			t.Errorf("%s has %d migrations, want %d", dialect, len(migrations), len(mysql))
+		}
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("%s migration %d_%s has version %d, want %d", dialect, migration.Version, migration.Name, migration.Version, i+1)
+			}
+			if i < len(mysql) && migration.Name != mysql[i].Name {
+				t.Errorf("%s migration %d is named %q, want %q", dialect, migration.Version, migration.Name, mysql[i].Name)
+			}
+			if len(statements(migration.Up)) == 0 || len(statements(migration.Down)) == 0 {
+				t.Errorf("%s migration %d_%s has no statements", dialect, migration.Version, migration.Name)
+			}
+		}
+	}
+}
+
+func TestUpAndDownOnSQLite(t *testing.T) {
+	repository, err := db.Open(db.SQLite, config.SQLiteAddress(filepath.Join(t.TempDir(), "blog.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+	migrator, err := NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrator.migrations) {
+		t.Fatalf("applied %d migrations, want %d", applied, len(migrator.migrations))
+	}
+	applied, err = migrator.Up()
+	if err != nil || applied != 0 {
+		t.Fatalf("applied %d migrations again, err %v", applied, err)
+	}
+	reverted, err := migrator.Down(len(migrator.migrations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted != len(migrator.migrations) {
+		t.Fatalf("reverted %d migrations, want %d", reverted, len(migrator.migrations))
+	}
+	var tables int
+	err = repository.QueryRow(`select count(*) from sqlite_master where type = 'table' and name <> 'schema_migrations' and name not like 'sqlite_%'`).Scan(&tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("%d tables are left after reverting every migration", tables)
+	}
+}
+
+func TestLoadNeedsBothDirections(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_first.up.sql":   {Data: []byte("create table a (id int);")},
+		"sql/0001_first.down.sql": {Data: []byte("drop table a;")},
+		"sql/0002_second.up.sql":  {Data: []byte("create table b (id int);")},
+	}
+	_, err := load(fsys, "sql")
+	if err == nil {
+		t.Fatal("a migration without a down file is loaded")
+	}
+}
+
+func TestStatements(t *testing.T) {
+	script := "create table a (\n\tid int\n);\n\nalter table a\n\tadd column b int;\n"
+	got := statements(script)
+	if len(got) != 2 {
+		t.Fatalf("got %d statements, want 2: %q", len(got), got)
+	}
+	if got[1] != "alter table a\n\tadd column b int" {
+		t.Errorf("got %q", got[1])
+	}
+}
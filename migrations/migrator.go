@@ -0,0 +1,228 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// lockName is the name of the database lock which keeps concurrent instances from migrating at once.
+const lockName = "blog_factory_migrations"
+
+// lockTimeout is how long an instance waits for another one to finish migrating, in seconds.
+const lockTimeout = 60
+
+// lockKey is the PostgreSQL advisory lock of lockName, whose locks are keyed by numbers.
+const lockKey = 0x626c6f67
+
+// schemaMigrations creates the table of the applied migrations in each dialect.
+var schemaMigrations = map[db.Dialect]string{
+	db.MySQL: `
+	create table if not exists schema_migrations (
+		version int not null primary key,
+		name varchar(255) not null,
+		applied_at datetime(6) not null
+	) engine = InnoDB default charset = utf8mb4`,
+	db.SQLite: `
+	create table if not exists schema_migrations (
+		version integer not null primary key,
+		name varchar(255) not null,
+		applied_at datetime not null
+	)`,
+	db.PostgreSQL: `
+	create table if not exists schema_migrations (
+		version int not null primary key,
+		name varchar(255) not null,
+		applied_at timestamp(6) not null
+	)`,
+}
+
+type Migrator struct {
+	repository *db.Repository
+	dialect    db.Dialect
+	migrations []Migration
+}
+
+// Status is the state of one migration.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt"`
+}
+
+func NewMigrator(repository *db.Repository) (*Migrator, error) {
+	migrations, err := Load(repository.Dialect())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		repository: repository,
+		dialect:    repository.Dialect(),
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies the pending migrations in version order and returns how many were applied.
+func (migrator *Migrator) Up() (int, error) {
+	applied := 0
+	err := migrator.locked(func(conn *sql.Conn) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrator.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			err = migrator.run(conn, migration.Up, `
+			insert into schema_migrations (version, name, applied_at)
+			values (?, ?, ?)`, migration.Version, migration.Name, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("migration %d_%s is applied.\n", migration.Version, migration.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest applied migrations, at most steps of them, and returns how many were reverted.
+func (migrator *Migrator) Down(steps int) (int, error) {
+	reverted := 0
+	err := migrator.locked(func(conn *sql.Conn) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrator.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := migrator.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			err = migrator.run(conn, migration.Down, `delete from schema_migrations where version = ?`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("migration %d_%s is reverted.\n", migration.Version, migration.Name)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns every migration with the time it was applied, which is nil when it is pending.
+func (migrator *Migrator) Status() ([]Status, error) {
+	statuses := []Status{}
+	err := migrator.locked(func(conn *sql.Conn) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrator.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := versions[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// locked runs the function on one connection while it holds the migration lock. The lock is
+// released when the function returns or when the connection is lost. SQLite needs no lock, as
+// its migrations run in write transactions, which lock the whole database.
+func (migrator *Migrator) locked(migrate func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := migrator.repository.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	switch migrator.dialect {
+	case db.MySQL:
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(ctx, `select get_lock(?, ?)`, lockName, lockTimeout).Scan(&acquired)
+		if err != nil {
+			return err
+		}
+		if acquired.Int64 != 1 {
+			return fmt.Errorf("the migration lock is held by another instance")
+		}
+		defer conn.ExecContext(ctx, `select release_lock(?)`, lockName)
+	case db.PostgreSQL:
+		lockCtx, cancel := context.WithTimeout(ctx, lockTimeout*time.Second)
+		defer cancel()
+		_, err = conn.ExecContext(lockCtx, `select pg_advisory_lock($1)`, lockKey)
+		if err != nil {
+			return fmt.Errorf("the migration lock is held by another instance: %w", err)
+		}
+		defer conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, lockKey)
+	}
+	_, err = conn.ExecContext(ctx, schemaMigrations[migrator.dialect])
+	if err != nil {
+		return err
+	}
+	return migrate(conn)
+}
+
+func appliedVersions(conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), `select version, applied_at from schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err := rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+// run executes the statements of a migration one by one, and then the statement which records it.
+// MySQL commits schema changes implicitly, so a failing migration stops at the failing statement.
+// SQLite and PostgreSQL change the schema in a transaction, which rolls the whole migration back.
+func (migrator *Migrator) run(conn *sql.Conn, script string, record string, args ...interface{}) error {
+	ctx := context.Background()
+	if migrator.dialect == db.MySQL {
+		for _, statement := range statements(script) {
+			_, err := conn.ExecContext(ctx, statement)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := conn.ExecContext(ctx, record, args...)
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements(script) {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, migrator.dialect.Rebind(record), args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
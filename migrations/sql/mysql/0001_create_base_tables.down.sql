@@ -0,0 +1,7 @@
+drop table if exists comments;
+drop table if exists posts_and_tags;
+drop table if exists tags;
+drop table if exists posts;
+drop table if exists verified_emails;
+drop table if exists email_tokens;
+drop table if exists users;
@@ -0,0 +1,62 @@
+create table if not exists users (
+	id char(36) not null primary key,
+	email varchar(255) not null,
+	username varchar(50) not null,
+	password varchar(255) not null,
+	is_admin boolean not null default false,
+	hashed_refresh_token varchar(255) null,
+	created_at datetime(6) not null default current_timestamp(6),
+	unique key users_email (email),
+	unique key users_username (username)
+) engine = InnoDB default charset = utf8mb4;
+
+create table if not exists email_tokens (
+	id bigint not null auto_increment primary key,
+	email varchar(255) not null,
+	token varchar(64) not null,
+	expired_at datetime(6) not null,
+	key email_tokens_email (email)
+) engine = InnoDB default charset = utf8mb4;
+
+create table if not exists verified_emails (
+	email varchar(255) not null primary key
+) engine = InnoDB default charset = utf8mb4;
+
+create table if not exists posts (
+	id int not null auto_increment primary key,
+	title varchar(255) not null,
+	description text not null,
+	content mediumtext not null,
+	created_at datetime(6) not null,
+	updated_at datetime(6) not null,
+	user_id char(36) not null,
+	key posts_created_at (created_at, id),
+	constraint posts_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
+
+create table if not exists tags (
+	id int not null auto_increment primary key,
+	tag varchar(64) not null,
+	unique key tags_tag (tag)
+) engine = InnoDB default charset = utf8mb4;
+
+create table if not exists posts_and_tags (
+	post_id int not null,
+	tag_id int not null,
+	primary key (post_id, tag_id),
+	key posts_and_tags_tag (tag_id),
+	constraint posts_and_tags_post foreign key (post_id) references posts (id),
+	constraint posts_and_tags_tag foreign key (tag_id) references tags (id)
+) engine = InnoDB default charset = utf8mb4;
+
+create table if not exists comments (
+	id int not null auto_increment primary key,
+	content text not null,
+	post_id int not null,
+	user_id char(36) not null,
+	created_at datetime(6) not null,
+	updated_at datetime(6) not null,
+	key comments_post (post_id, created_at),
+	constraint comments_post foreign key (post_id) references posts (id),
+	constraint comments_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
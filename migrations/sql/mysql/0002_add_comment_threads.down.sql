@@ -0,0 +1,5 @@
+alter table comments
+	drop key comments_parent,
+	drop column is_deleted,
+	drop column depth,
+	drop column parent_id;
@@ -0,0 +1,5 @@
+alter table comments
+	add column parent_id int null after post_id,
+	add column depth int not null default 0 after parent_id,
+	add column is_deleted boolean not null default false,
+	add key comments_parent (parent_id);
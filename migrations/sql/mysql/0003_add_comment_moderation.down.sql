@@ -0,0 +1,11 @@
+drop table if exists spam_lists;
+
+alter table comments
+	drop key comments_user_created_at,
+	drop key comments_status,
+	drop column user_agent,
+	drop column ip,
+	drop column status;
+
+alter table posts
+	drop column comment_moderation;
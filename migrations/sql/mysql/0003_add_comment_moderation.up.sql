@@ -0,0 +1,19 @@
+alter table posts
+	add column comment_moderation varchar(16) null;
+
+alter table comments
+	add column status varchar(16) not null default 'approved',
+	add column ip varchar(45) null,
+	add column user_agent varchar(255) null,
+	add key comments_status (status, created_at),
+	add key comments_user_created_at (user_id, created_at);
+
+create table spam_lists (
+	id int not null auto_increment primary key,
+	kind varchar(16) not null,
+	field varchar(16) not null,
+	value varchar(255) not null,
+	created_at datetime(6) not null,
+	unique key spam_lists_entry (kind, field, value),
+	key spam_lists_value (field, value)
+) engine = InnoDB default charset = utf8mb4;
@@ -0,0 +1,22 @@
+alter table posts
+	add column is_hidden boolean not null default false;
+
+alter table users
+	add column is_suspended boolean not null default false;
+
+create table reports (
+	id int not null auto_increment primary key,
+	target_type varchar(16) not null,
+	target_id int not null,
+	user_id char(36) not null,
+	reason varchar(32) not null,
+	message text not null,
+	status varchar(16) not null,
+	resolution varchar(16) null,
+	created_at datetime(6) not null,
+	resolved_at datetime(6) null,
+	unique key reports_user_target (user_id, target_type, target_id),
+	key reports_target (target_type, target_id, status),
+	key reports_status (status, created_at),
+	constraint reports_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
@@ -0,0 +1,4 @@
+alter table posts
+	drop column comments_close_days,
+	drop column comments_locked,
+	drop column comments_disabled;
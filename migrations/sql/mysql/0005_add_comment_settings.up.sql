@@ -0,0 +1,4 @@
+alter table posts
+	add column comments_disabled boolean not null default false,
+	add column comments_locked boolean not null default false,
+	add column comments_close_days int null;
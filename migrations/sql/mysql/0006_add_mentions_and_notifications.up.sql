@@ -0,0 +1,34 @@
+create table mentions (
+	id int not null auto_increment primary key,
+	source_type varchar(16) not null,
+	source_id int not null,
+	user_id char(36) not null,
+	author_id char(36) not null,
+	created_at datetime(6) not null,
+	unique key mentions_source_user (source_type, source_id, user_id),
+	constraint mentions_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
+
+create table notifications (
+	id bigint not null auto_increment primary key,
+	user_id char(36) not null,
+	type varchar(16) not null,
+	actor_id char(36) not null,
+	post_id int null,
+	comment_id int null,
+	message text not null,
+	created_at datetime(6) not null,
+	read_at datetime(6) null,
+	key notifications_user (user_id, created_at, id),
+	key notifications_created_at (created_at),
+	constraint notifications_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
+
+create table notification_preferences (
+	user_id char(36) not null,
+	type varchar(16) not null,
+	in_app boolean not null,
+	email boolean not null,
+	primary key (user_id, type),
+	constraint notification_preferences_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
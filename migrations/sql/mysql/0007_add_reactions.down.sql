@@ -0,0 +1,9 @@
+drop table if exists reaction_counts;
+drop table if exists reactions;
+
+alter table comments
+	drop column reaction_count;
+
+alter table posts
+	drop key posts_reaction_count,
+	drop column reaction_count;
@@ -0,0 +1,25 @@
+alter table posts
+	add column reaction_count int not null default 0,
+	add key posts_reaction_count (reaction_count, created_at);
+
+alter table comments
+	add column reaction_count int not null default 0;
+
+create table reactions (
+	id bigint not null auto_increment primary key,
+	target_type varchar(16) not null,
+	target_id int not null,
+	user_id char(36) not null,
+	type varchar(32) not null,
+	created_at datetime(6) not null,
+	unique key reactions_target_user (target_type, target_id, user_id, type),
+	constraint reactions_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4 collate = utf8mb4_bin;
+
+create table reaction_counts (
+	target_type varchar(16) not null,
+	target_id int not null,
+	type varchar(32) not null,
+	count int not null,
+	primary key (target_type, target_id, type)
+) engine = InnoDB default charset = utf8mb4 collate = utf8mb4_bin;
@@ -0,0 +1,22 @@
+create table bookmark_collections (
+	id int not null auto_increment primary key,
+	user_id char(36) not null,
+	name varchar(50) not null,
+	created_at datetime(6) not null,
+	unique key bookmark_collections_name (user_id, name),
+	constraint bookmark_collections_user foreign key (user_id) references users (id)
+) engine = InnoDB default charset = utf8mb4;
+
+create table bookmarks (
+	id int not null auto_increment primary key,
+	user_id char(36) not null,
+	post_id int not null,
+	collection_id int null,
+	created_at datetime(6) not null,
+	unique key bookmarks_user_post (user_id, post_id),
+	key bookmarks_user (user_id, created_at, id),
+	key bookmarks_post (post_id),
+	constraint bookmarks_user foreign key (user_id) references users (id),
+	constraint bookmarks_post foreign key (post_id) references posts (id),
+	constraint bookmarks_collection foreign key (collection_id) references bookmark_collections (id)
+) engine = InnoDB default charset = utf8mb4;
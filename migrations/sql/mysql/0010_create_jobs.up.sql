@@ -0,0 +1,15 @@
+create table jobs (
+	id bigint not null auto_increment primary key,
+	type varchar(64) not null,
+	payload mediumtext not null,
+	status varchar(16) not null default 'pending',
+	attempts int not null default 0,
+	idempotency_key varchar(191) null,
+	last_error text null,
+	run_at datetime(6) not null,
+	locked_at datetime(6) null,
+	created_at datetime(6) not null,
+	finished_at datetime(6) null,
+	unique key jobs_idempotency_key (idempotency_key),
+	key jobs_status_run_at (status, run_at)
+) engine = InnoDB default charset = utf8mb4 collate = utf8mb4_bin;
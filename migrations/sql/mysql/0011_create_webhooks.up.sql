@@ -0,0 +1,25 @@
+create table webhooks (
+	id int not null auto_increment primary key,
+	url varchar(2048) not null,
+	secret varchar(255) not null,
+	events varchar(255) not null,
+	is_active boolean not null default true,
+	created_at datetime(6) not null,
+	updated_at datetime(6) not null
+) engine = InnoDB default charset = utf8mb4 collate = utf8mb4_bin;
+
+create table webhook_deliveries (
+	id bigint not null auto_increment primary key,
+	webhook_id int not null,
+	event varchar(64) not null,
+	payload mediumtext not null,
+	status varchar(16) not null default 'pending',
+	attempts int not null default 0,
+	response_status int null,
+	response_body text null,
+	last_error text null,
+	created_at datetime(6) not null,
+	delivered_at datetime(6) null,
+	key webhook_deliveries_webhook (webhook_id, id),
+	constraint webhook_deliveries_webhook foreign key (webhook_id) references webhooks (id) on delete cascade
+) engine = InnoDB default charset = utf8mb4 collate = utf8mb4_bin;
@@ -0,0 +1,72 @@
+create table if not exists users (
+	id char(36) not null primary key,
+	email varchar(255) not null,
+	username varchar(50) not null,
+	password varchar(255) not null,
+	is_admin boolean not null default false,
+	hashed_refresh_token varchar(255) null,
+	created_at timestamp(6) not null default current_timestamp
+);
+
+create unique index if not exists users_email on users (lower(email));
+
+create unique index if not exists users_username on users (lower(username));
+
+create table if not exists email_tokens (
+	id bigserial primary key,
+	email varchar(255) not null,
+	token varchar(64) not null,
+	expired_at timestamp(6) not null
+);
+
+create index if not exists email_tokens_email on email_tokens (lower(email));
+
+create table if not exists verified_emails (
+	email varchar(255) not null primary key
+);
+
+create unique index if not exists verified_emails_email on verified_emails (lower(email));
+
+create table if not exists posts (
+	id serial primary key,
+	title varchar(255) not null,
+	description text not null,
+	content text not null,
+	created_at timestamp(6) not null,
+	updated_at timestamp(6) not null,
+	user_id char(36) not null,
+	constraint posts_user foreign key (user_id) references users (id)
+);
+
+create index if not exists posts_created_at on posts (created_at, id);
+
+create index if not exists posts_user on posts (user_id);
+
+create table if not exists tags (
+	id serial primary key,
+	tag varchar(64) not null,
+	constraint tags_tag unique (tag)
+);
+
+create table if not exists posts_and_tags (
+	post_id int not null,
+	tag_id int not null,
+	primary key (post_id, tag_id),
+	constraint posts_and_tags_post foreign key (post_id) references posts (id),
+	constraint posts_and_tags_tag foreign key (tag_id) references tags (id)
+);
+
+create index if not exists posts_and_tags_tag on posts_and_tags (tag_id);
+
+create table if not exists comments (
+	id serial primary key,
+	content text not null,
+	post_id int not null,
+	user_id char(36) not null,
+	created_at timestamp(6) not null,
+	updated_at timestamp(6) not null,
+	constraint comments_post foreign key (post_id) references posts (id),
+	constraint comments_user foreign key (user_id) references users (id)
+);
+
+create index if not exists comments_post on comments (post_id, created_at);
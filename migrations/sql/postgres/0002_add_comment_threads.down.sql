@@ -0,0 +1,6 @@
+drop index if exists comments_parent;
+
+alter table comments
+	drop column is_deleted,
+	drop column depth,
+	drop column parent_id;
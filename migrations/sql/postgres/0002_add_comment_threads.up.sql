@@ -0,0 +1,6 @@
+alter table comments
+	add column parent_id int null,
+	add column depth int not null default 0,
+	add column is_deleted boolean not null default false;
+
+create index comments_parent on comments (parent_id);
@@ -0,0 +1,13 @@
+drop table if exists spam_lists;
+
+drop index if exists comments_user_created_at;
+
+drop index if exists comments_status;
+
+alter table comments
+	drop column user_agent,
+	drop column ip,
+	drop column status;
+
+alter table posts
+	drop column comment_moderation;
@@ -0,0 +1,22 @@
+alter table posts
+	add column comment_moderation varchar(16) null;
+
+alter table comments
+	add column status varchar(16) not null default 'approved',
+	add column ip varchar(45) null,
+	add column user_agent varchar(255) null;
+
+create index comments_status on comments (status, created_at);
+
+create index comments_user_created_at on comments (user_id, created_at);
+
+create table spam_lists (
+	id serial primary key,
+	kind varchar(16) not null,
+	field varchar(16) not null,
+	value varchar(255) not null,
+	created_at timestamp(6) not null,
+	constraint spam_lists_entry unique (kind, field, value)
+);
+
+create index spam_lists_value on spam_lists (field, value);
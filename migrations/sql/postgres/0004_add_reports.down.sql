@@ -0,0 +1,7 @@
+drop table if exists reports;
+
+alter table users
+	drop column is_suspended;
+
+alter table posts
+	drop column is_hidden;
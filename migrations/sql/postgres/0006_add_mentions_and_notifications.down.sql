@@ -0,0 +1,3 @@
+drop table if exists notification_preferences;
+drop table if exists notifications;
+drop table if exists mentions;
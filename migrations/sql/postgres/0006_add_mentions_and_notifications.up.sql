@@ -0,0 +1,36 @@
+create table mentions (
+	id serial primary key,
+	source_type varchar(16) not null,
+	source_id int not null,
+	user_id char(36) not null,
+	author_id char(36) not null,
+	created_at timestamp(6) not null,
+	constraint mentions_source_user unique (source_type, source_id, user_id),
+	constraint mentions_user foreign key (user_id) references users (id)
+);
+
+create table notifications (
+	id bigserial primary key,
+	user_id char(36) not null,
+	type varchar(16) not null,
+	actor_id char(36) not null,
+	post_id int null,
+	comment_id int null,
+	message text not null,
+	created_at timestamp(6) not null,
+	read_at timestamp(6) null,
+	constraint notifications_user foreign key (user_id) references users (id)
+);
+
+create index notifications_user on notifications (user_id, created_at, id);
+
+create index notifications_created_at on notifications (created_at);
+
+create table notification_preferences (
+	user_id char(36) not null,
+	type varchar(16) not null,
+	in_app boolean not null,
+	email boolean not null,
+	primary key (user_id, type),
+	constraint notification_preferences_user foreign key (user_id) references users (id)
+);
@@ -0,0 +1,11 @@
+drop table if exists reaction_counts;
+
+drop table if exists reactions;
+
+alter table comments
+	drop column reaction_count;
+
+drop index if exists posts_reaction_count;
+
+alter table posts
+	drop column reaction_count;
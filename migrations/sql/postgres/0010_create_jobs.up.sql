@@ -0,0 +1,16 @@
+create table jobs (
+	id bigserial primary key,
+	type varchar(64) not null,
+	payload text not null,
+	status varchar(16) not null default 'pending',
+	attempts int not null default 0,
+	idempotency_key varchar(191) null,
+	last_error text null,
+	run_at timestamp(6) not null,
+	locked_at timestamp(6) null,
+	created_at timestamp(6) not null,
+	finished_at timestamp(6) null,
+	constraint jobs_idempotency_key unique (idempotency_key)
+);
+
+create index jobs_status_run_at on jobs (status, run_at);
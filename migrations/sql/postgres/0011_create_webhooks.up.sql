@@ -0,0 +1,26 @@
+create table webhooks (
+	id serial primary key,
+	url varchar(2048) not null,
+	secret varchar(255) not null,
+	events varchar(255) not null,
+	is_active boolean not null default true,
+	created_at timestamp(6) not null,
+	updated_at timestamp(6) not null
+);
+
+create table webhook_deliveries (
+	id bigserial primary key,
+	webhook_id int not null,
+	event varchar(64) not null,
+	payload text not null,
+	status varchar(16) not null default 'pending',
+	attempts int not null default 0,
+	response_status int null,
+	response_body text null,
+	last_error text null,
+	created_at timestamp(6) not null,
+	delivered_at timestamp(6) null,
+	constraint webhook_deliveries_webhook foreign key (webhook_id) references webhooks (id) on delete cascade
+);
+
+create index webhook_deliveries_webhook on webhook_deliveries (webhook_id, id);
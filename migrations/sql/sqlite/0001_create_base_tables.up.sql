@@ -0,0 +1,68 @@
+create table if not exists users (
+	id char(36) not null primary key,
+	email varchar(255) not null collate nocase,
+	username varchar(50) not null collate nocase,
+	password varchar(255) not null,
+	is_admin boolean not null default false,
+	hashed_refresh_token varchar(255) null,
+	created_at datetime not null default current_timestamp,
+	constraint users_email unique (email),
+	constraint users_username unique (username)
+);
+
+create table if not exists email_tokens (
+	id integer primary key autoincrement,
+	email varchar(255) not null collate nocase,
+	token varchar(64) not null,
+	expired_at datetime not null
+);
+
+create index if not exists email_tokens_email on email_tokens (email);
+
+create table if not exists verified_emails (
+	email varchar(255) not null collate nocase primary key
+);
+
+create table if not exists posts (
+	id integer primary key autoincrement,
+	title varchar(255) not null,
+	description text not null,
+	content text not null,
+	created_at datetime not null,
+	updated_at datetime not null,
+	user_id char(36) not null,
+	constraint posts_user foreign key (user_id) references users (id)
+);
+
+create index if not exists posts_created_at on posts (created_at, id);
+
+create index if not exists posts_user on posts (user_id);
+
+create table if not exists tags (
+	id integer primary key autoincrement,
+	tag varchar(64) not null collate nocase,
+	constraint tags_tag unique (tag)
+);
+
+create table if not exists posts_and_tags (
+	post_id int not null,
+	tag_id int not null,
+	primary key (post_id, tag_id),
+	constraint posts_and_tags_post foreign key (post_id) references posts (id),
+	constraint posts_and_tags_tag foreign key (tag_id) references tags (id)
+);
+
+create index if not exists posts_and_tags_tag on posts_and_tags (tag_id);
+
+create table if not exists comments (
+	id integer primary key autoincrement,
+	content text not null,
+	post_id int not null,
+	user_id char(36) not null,
+	created_at datetime not null,
+	updated_at datetime not null,
+	constraint comments_post foreign key (post_id) references posts (id),
+	constraint comments_user foreign key (user_id) references users (id)
+);
+
+create index if not exists comments_post on comments (post_id, created_at);
@@ -0,0 +1,7 @@
+drop index comments_parent;
+
+alter table comments drop column is_deleted;
+
+alter table comments drop column depth;
+
+alter table comments drop column parent_id;
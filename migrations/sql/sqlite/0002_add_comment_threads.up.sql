@@ -0,0 +1,7 @@
+alter table comments add column parent_id int null;
+
+alter table comments add column depth int not null default 0;
+
+alter table comments add column is_deleted boolean not null default false;
+
+create index comments_parent on comments (parent_id);
@@ -0,0 +1,13 @@
+drop table if exists spam_lists;
+
+drop index comments_user_created_at;
+
+drop index comments_status;
+
+alter table comments drop column user_agent;
+
+alter table comments drop column ip;
+
+alter table comments drop column status;
+
+alter table posts drop column comment_moderation;
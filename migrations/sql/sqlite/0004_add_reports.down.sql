@@ -0,0 +1,5 @@
+drop table if exists reports;
+
+alter table users drop column is_suspended;
+
+alter table posts drop column is_hidden;
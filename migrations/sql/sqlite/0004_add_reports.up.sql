@@ -0,0 +1,22 @@
+alter table posts add column is_hidden boolean not null default false;
+
+alter table users add column is_suspended boolean not null default false;
+
+create table reports (
+	id integer primary key autoincrement,
+	target_type varchar(16) not null,
+	target_id int not null,
+	user_id char(36) not null,
+	reason varchar(32) not null,
+	message text not null,
+	status varchar(16) not null,
+	resolution varchar(16) null,
+	created_at datetime not null,
+	resolved_at datetime null,
+	constraint reports_user_target unique (user_id, target_type, target_id),
+	constraint reports_user foreign key (user_id) references users (id)
+);
+
+create index reports_target on reports (target_type, target_id, status);
+
+create index reports_status on reports (status, created_at);
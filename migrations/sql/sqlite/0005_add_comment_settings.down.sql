@@ -0,0 +1,5 @@
+alter table posts drop column comments_close_days;
+
+alter table posts drop column comments_locked;
+
+alter table posts drop column comments_disabled;
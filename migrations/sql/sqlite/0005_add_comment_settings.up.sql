@@ -0,0 +1,5 @@
+alter table posts add column comments_disabled boolean not null default false;
+
+alter table posts add column comments_locked boolean not null default false;
+
+alter table posts add column comments_close_days int null;
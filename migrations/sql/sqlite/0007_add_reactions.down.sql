@@ -0,0 +1,9 @@
+drop table if exists reaction_counts;
+
+drop table if exists reactions;
+
+alter table comments drop column reaction_count;
+
+drop index posts_reaction_count;
+
+alter table posts drop column reaction_count;
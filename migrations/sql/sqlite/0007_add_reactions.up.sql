@@ -0,0 +1,24 @@
+alter table posts add column reaction_count int not null default 0;
+
+create index posts_reaction_count on posts (reaction_count, created_at);
+
+alter table comments add column reaction_count int not null default 0;
+
+create table reactions (
+	id integer primary key autoincrement,
+	target_type varchar(16) not null,
+	target_id int not null,
+	user_id char(36) not null,
+	type varchar(32) not null,
+	created_at datetime not null,
+	constraint reactions_target_user unique (target_type, target_id, user_id, type),
+	constraint reactions_user foreign key (user_id) references users (id)
+);
+
+create table reaction_counts (
+	target_type varchar(16) not null,
+	target_id int not null,
+	type varchar(32) not null,
+	count int not null,
+	primary key (target_type, target_id, type)
+);
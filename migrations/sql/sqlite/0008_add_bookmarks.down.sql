@@ -0,0 +1,2 @@
+drop table if exists bookmarks;
+drop table if exists bookmark_collections;
@@ -0,0 +1,24 @@
+create table bookmark_collections (
+	id integer primary key autoincrement,
+	user_id char(36) not null,
+	name varchar(50) not null collate nocase,
+	created_at datetime not null,
+	constraint bookmark_collections_name unique (user_id, name),
+	constraint bookmark_collections_user foreign key (user_id) references users (id)
+);
+
+create table bookmarks (
+	id integer primary key autoincrement,
+	user_id char(36) not null,
+	post_id int not null,
+	collection_id int null,
+	created_at datetime not null,
+	constraint bookmarks_user_post unique (user_id, post_id),
+	constraint bookmarks_user foreign key (user_id) references users (id),
+	constraint bookmarks_post foreign key (post_id) references posts (id),
+	constraint bookmarks_collection foreign key (collection_id) references bookmark_collections (id)
+);
+
+create index bookmarks_user on bookmarks (user_id, created_at, id);
+
+create index bookmarks_post on bookmarks (post_id);
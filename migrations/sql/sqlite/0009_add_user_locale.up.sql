@@ -0,0 +1 @@
+alter table users add column locale varchar(16) not null default '';
@@ -0,0 +1,16 @@
+create table jobs (
+	id integer primary key autoincrement,
+	type varchar(64) not null,
+	payload text not null,
+	status varchar(16) not null default 'pending',
+	attempts int not null default 0,
+	idempotency_key varchar(191) null,
+	last_error text null,
+	run_at datetime not null,
+	locked_at datetime null,
+	created_at datetime not null,
+	finished_at datetime null,
+	constraint jobs_idempotency_key unique (idempotency_key)
+);
+
+create index jobs_status_run_at on jobs (status, run_at);
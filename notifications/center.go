@@ -0,0 +1,154 @@
+package notifications
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// List returns a page of the notifications of the user, the newest first.
+func (service *NotificationsService) List(userID string, model *ListNotificationsModel) ([]NotificationModel, *db.PageInfo, error) {
+	pageSize, maxPageSize := service.config.GetPageSize()
+	limit := db.PageLimit(model.Limit, pageSize, maxPageSize)
+	cursor, err := db.DecodeCursor(model.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	where := []string{"n.user_id = ?"}
+	args := []interface{}{userID}
+	if model.Unread {
+		where = append(where, "n.read_at is null")
+	}
+	if cursor != nil {
+		where = append(where, "(n.created_at < ? or (n.created_at = ? and n.id < ?))")
+		args = append(args, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	args = append(args, limit+1)
+	rows, err := service.repository.Query(fmt.Sprintf(`
+	select n.id, n.type, coalesce(u.username, ''), n.post_id, n.comment_id, n.message, n.read_at, n.created_at
+	from notifications as n
+	left join users as u on n.actor_id = u.id
+	where %s
+	order by n.created_at desc, n.id desc
+	limit ?`, strings.Join(where, " and ")), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	notifications := []NotificationModel{}
+	for rows.Next() {
+		notification := NotificationModel{}
+		var postID, commentID sql.NullInt64
+		var readAt sql.NullTime
+		err := rows.Scan(&notification.ID, &notification.Type, &notification.ActorUsername, &postID, &commentID, &notification.Message, &readAt, &notification.CreatedAt)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if postID.Valid {
+			id := int(postID.Int64)
+			notification.PostID = &id
+		}
+		if commentID.Valid {
+			id := int(commentID.Int64)
+			notification.CommentID = &id
+		}
+		notification.Read = readAt.Valid
+		notification.Link = Link(service.config, int(postID.Int64), int(commentID.Int64))
+		notifications = append(notifications, notification)
+	}
+	pageInfo := &db.PageInfo{Limit: limit}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[len(notifications)-1]
+		pageInfo.NextCursor = (&db.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+	}
+	return notifications, pageInfo, nil
+}
+
+// Unread returns how many notifications of the user are unread.
+func (service *NotificationsService) Unread(userID string) (int, error) {
+	var unread int
+	row := service.repository.QueryRow(`select count(*) from notifications where user_id = ? and read_at is null`, userID)
+	err := row.Scan(&unread)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return unread, nil
+}
+
+// MarkRead marks a notification of the user as read. Marking it again does nothing.
+func (service *NotificationsService) MarkRead(userID string, id int) error {
+	var readAt sql.NullTime
+	row := service.repository.QueryRow(`select read_at from notifications where id = ? and user_id = ?`, id, userID)
+	err := row.Scan(&readAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: notification %d", db.ErrNotFound, id)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	if readAt.Valid {
+		return nil
+	}
+	_, err = service.repository.Exec(`update notifications set read_at = ? where id = ?`, time.Now().UTC(), id)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and returns how many there were.
+func (service *NotificationsService) MarkAllRead(userID string) (int64, error) {
+	res, err := service.repository.Exec(`update notifications set read_at = ? where user_id = ? and read_at is null`, time.Now().UTC(), userID)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	marked, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return marked, nil
+}
+
+// CleanUp deletes the notifications older than the retention period and returns how many were deleted.
+func (service *NotificationsService) CleanUp() (int64, error) {
+	before := time.Now().UTC().Add(-service.config.GetNotificationRetention())
+	res, err := service.repository.Exec(`delete from notifications where created_at < ?`, before)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return deleted, nil
+}
+
+// StartCleanUp runs CleanUp now and then at every interval in the background.
+func (service *NotificationsService) StartCleanUp(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			deleted, err := service.CleanUp()
+			if err == nil && deleted > 0 {
+				log.Printf("%d expired notifications are deleted.\n", deleted)
+			}
+			<-ticker.C
+		}
+	}()
+}
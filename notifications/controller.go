@@ -0,0 +1,173 @@
+package notifications
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+)
+
+type NotificationsController struct {
+	*echo.Echo
+	config          *config.Config
+	repository      *db.Repository
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+	mailClient      *mail.MailClient
+}
+
+func NewNotificationsController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc, mailClient *mail.MailClient) *NotificationsController {
+	return &NotificationsController{
+		Echo:            echo,
+		config:          config,
+		repository:      repository,
+		jwtMiddleware:   jwtMiddleware,
+		adminMiddleware: adminMiddleware,
+		mailClient:      mailClient,
+	}
+}
+
+func (controller *NotificationsController) UseRoute() {
+	notificationsService := NewNotificationsService(controller.config, controller.repository, controller.mailClient)
+	controller.GET("/notifications", func(c echo.Context) error {
+		model := new(ListNotificationsModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		notifications, pageInfo, err := notificationsService.List(userID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the notifications is failed.",
+			})
+		}
+		unread, err := notificationsService.Unread(userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the notifications is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":        true,
+			"notifications": notifications,
+			"unread":        unread,
+			"page":          pageInfo,
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.GET("/notifications/unread", func(c echo.Context) error {
+		userID := c.Get("userID").(string)
+		unread, err := notificationsService.Unread(userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Counting the unread notifications is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"unread": unread,
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.POST("/notifications/read", func(c echo.Context) error {
+		userID := c.Get("userID").(string)
+		marked, err := notificationsService.MarkAllRead(userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Marking the notifications as read is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"marked":  marked,
+			"message": "The notifications are marked as read.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.POST("/notifications/:id/read", func(c echo.Context) error {
+		param := c.Param("id")
+		id, err := strconv.Atoi(param)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid notification id.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		err = notificationsService.MarkRead(userID, id)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Marking the notification as read is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The notification is marked as read.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.DELETE("/notifications/expired", func(c echo.Context) error {
+		deleted, err := notificationsService.CleanUp()
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Deleting the expired notifications is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"deleted": deleted,
+			"message": "The expired notifications are deleted.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.GET("/notifications/preferences", func(c echo.Context) error {
+		userID := c.Get("userID").(string)
+		preferences, err := notificationsService.Preferences(userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the preferences is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":      true,
+			"preferences": preferences,
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.PUT("/notifications/preferences", func(c echo.Context) error {
+		model := new(UpdatePreferencesModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		err = notificationsService.SetPreferences(userID, model.Preferences)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Updating the preferences is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The preferences are updated.",
+		})
+	}, *controller.jwtMiddleware)
+}
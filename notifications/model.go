@@ -0,0 +1,56 @@
+package notifications
+
+import "time"
+
+// Event types.
+const (
+	TypeComment    = "comment"
+	TypeReply      = "reply"
+	TypeMention    = "mention"
+	TypeModeration = "moderation"
+	TypePending    = "pending"
+	TypeRole       = "role"
+)
+
+// Types are the event types which have preferences.
+var Types = []string{TypeComment, TypeReply, TypeMention, TypeModeration, TypePending, TypeRole}
+
+// Event is something which happened to a user, who is notified of it.
+type Event struct {
+	Type    string
+	UserID  string
+	ActorID string
+	// PostID is zero when the event is not about a post.
+	PostID int
+	// CommentID is zero when the event is not about a comment.
+	CommentID int
+	Message   string
+}
+
+type NotificationModel struct {
+	ID            int       `json:"id"`
+	Type          string    `json:"type"`
+	ActorUsername string    `json:"actorUsername"`
+	PostID        *int      `json:"postId"`
+	CommentID     *int      `json:"commentId"`
+	Message       string    `json:"message"`
+	Link          string    `json:"link"`
+	Read          bool      `json:"read"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type ListNotificationsModel struct {
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit"`
+	Unread bool   `query:"unread"`
+}
+
+type PreferenceModel struct {
+	Type  string `json:"type"`
+	InApp bool   `json:"inApp"`
+	Email bool   `json:"email"`
+}
+
+type UpdatePreferencesModel struct {
+	Preferences []PreferenceModel `json:"preferences"`
+}
@@ -0,0 +1,173 @@
+package notifications
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/jobs"
+	"github.com/quavious/blog-factory-server/mail"
+)
+
+type NotificationsService struct {
+	config     *config.Config
+	repository *db.Repository
+	mailClient *mail.MailClient
+}
+
+func NewNotificationsService(config *config.Config, repository *db.Repository, mailClient *mail.MailClient) *NotificationsService {
+	return &NotificationsService{
+		config:     config,
+		repository: repository,
+		mailClient: mailClient,
+	}
+}
+
+// JobDeliver is the type of the jobs which deliver the events.
+const JobDeliver = "notification.deliver"
+
+// Notify enqueues the events, whose jobs store them for the users who want them in-app and mail the
+// users who want them by email. Nobody is notified of their own actions. A failing event is logged
+// and skipped so that it does not fail the action which caused it.
+func (service *NotificationsService) Notify(events ...Event) {
+	for _, event := range events {
+		if event.UserID == event.ActorID {
+			continue
+		}
+		_, err := jobs.Enqueue(service.repository, JobDeliver, event, "")
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
+
+// Deliver is the handler of the jobs of Notify. The mail is sent first and only once for the job,
+// so that a retry after the event failed to be stored does not mail the user again.
+func (service *NotificationsService) Deliver(job *jobs.Job) error {
+	event := new(Event)
+	err := job.Decode(event)
+	if err != nil {
+		return err
+	}
+	preference, err := service.preference(event.UserID, event.Type)
+	if err != nil {
+		return err
+	}
+	if preference.Email {
+		err = service.mail(fmt.Sprintf("job:%d", job.ID), event)
+		if err != nil {
+			return err
+		}
+	}
+	if preference.InApp {
+		return service.store(event)
+	}
+	return nil
+}
+
+func (service *NotificationsService) store(event *Event) error {
+	postID := sql.NullInt64{Int64: int64(event.PostID), Valid: event.PostID > 0}
+	commentID := sql.NullInt64{Int64: int64(event.CommentID), Valid: event.CommentID > 0}
+	_, err := service.repository.Exec(`
+	insert into notifications (user_id, type, actor_id, post_id, comment_id, message, created_at)
+	values (?, ?, ?, ?, ?, ?, ?)`, event.UserID, event.Type, event.ActorID, postID, commentID, event.Message, time.Now().UTC())
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+func (service *NotificationsService) mail(key string, event *Event) error {
+	var email, locale string
+	row := service.repository.QueryRow(`select email, locale from users where id = ?`, event.UserID)
+	err := row.Scan(&email, &locale)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	isOK := service.mailClient.SendTemplateOnce(key, email, locale, "notification", map[string]interface{}{
+		"Type":    event.Type,
+		"Message": event.Message,
+		"Link":    Link(service.config, event.PostID, event.CommentID),
+	})
+	if !isOK {
+		return fmt.Errorf("%w: the mail to %s is not sent", db.ErrInternal, email)
+	}
+	return nil
+}
+
+// Link returns the address of the post or the comment on the site, or of the site itself.
+func Link(config *config.Config, postID int, commentID int) string {
+	if postID == 0 {
+		return config.GetSiteURL()
+	}
+	link := fmt.Sprintf("%s/posts/%d", config.GetSiteURL(), postID)
+	if commentID > 0 {
+		link += fmt.Sprintf("#comment-%d", commentID)
+	}
+	return link
+}
+
+// Preferences returns the preference of the user for every event type. Both channels are on by default.
+func (service *NotificationsService) Preferences(userID string) ([]PreferenceModel, error) {
+	preferences := []PreferenceModel{}
+	for _, eventType := range Types {
+		preference, err := service.preference(userID, eventType)
+		if err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, *preference)
+	}
+	return preferences, nil
+}
+
+func (service *NotificationsService) preference(userID string, eventType string) (*PreferenceModel, error) {
+	preference := &PreferenceModel{Type: eventType, InApp: true, Email: true}
+	row := service.repository.QueryRow(`
+	select in_app, email from notification_preferences
+	where user_id = ? and type = ?`, userID, eventType)
+	err := row.Scan(&preference.InApp, &preference.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return preference, nil
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return preference, nil
+}
+
+// SetPreferences stores the preferences of the user. The event types which are not given are kept.
+func (service *NotificationsService) SetPreferences(userID string, preferences []PreferenceModel) error {
+	for _, preference := range preferences {
+		if !isType(preference.Type) {
+			return fmt.Errorf("%w: unknown event type %q", db.ErrInvalid, preference.Type)
+		}
+	}
+	return service.repository.Transaction(func(tx *db.Tx) error {
+		for _, preference := range preferences {
+			_, err := tx.Exec(tx.Dialect().Upsert(`
+			insert into notification_preferences (user_id, type, in_app, email)
+			values (?, ?, ?, ?)`, []string{"user_id", "type"}, "in_app = excluded.in_app, email = excluded.email"), userID, preference.Type, preference.InApp, preference.Email)
+			if err != nil {
+				log.Println(err.Error())
+				return fmt.Errorf("%w: %v", db.ErrInternal, err)
+			}
+		}
+		return nil
+	})
+}
+
+func isType(eventType string) bool {
+	for _, known := range Types {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -3,32 +3,44 @@ package posts
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
 )
 
 type PostsController struct {
 	*echo.Echo
-	config          *config.Config
-	repository      *db.Repository
-	jwtMiddleware   *echo.MiddlewareFunc
-	adminMiddleware *echo.MiddlewareFunc
+	config                *config.Config
+	repository            *db.Repository
+	stores                store.Store
+	jwtMiddleware         *echo.MiddlewareFunc
+	optionalJWTMiddleware *echo.MiddlewareFunc
+	adminMiddleware       *echo.MiddlewareFunc
+	index                 *search.Index
+	mailClient            *mail.MailClient
 }
 
-func NewPostsController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc) *PostsController {
+func NewPostsController(echo *echo.Echo, config *config.Config, repository *db.Repository, stores store.Store, jwtMiddleware *echo.MiddlewareFunc, optionalJWTMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc, index *search.Index, mailClient *mail.MailClient) *PostsController {
 	return &PostsController{
-		Echo:            echo,
-		repository:      repository,
-		config:          config,
-		jwtMiddleware:   jwtMiddleware,
-		adminMiddleware: adminMiddleware,
+		Echo:                  echo,
+		repository:            repository,
+		stores:                stores,
+		config:                config,
+		jwtMiddleware:         jwtMiddleware,
+		optionalJWTMiddleware: optionalJWTMiddleware,
+		adminMiddleware:       adminMiddleware,
+		index:                 index,
+		mailClient:            mailClient,
 	}
 }
 
 func (controller *PostsController) UseRoute() {
-	postsService := NewPostsService(controller.config, controller.repository)
+	postsService := NewPostsService(controller.config, controller.repository, controller.stores, controller.index, controller.mailClient)
 	controller.POST("/posts", func(c echo.Context) error {
 		model := new(CreatePostModel)
 		err := c.Bind(model)
@@ -39,19 +51,44 @@ func (controller *PostsController) UseRoute() {
 				Message: "Invalid data form.",
 			})
 		}
-		isCreated := postsService.Create(model, userID)
-		if !isCreated {
-			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+		postID, err := postsService.Create(model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
 				Status:  false,
 				Message: "Creating new post is failed.",
 			})
 		}
 		return c.JSON(http.StatusCreated, echo.Map{
 			"status":  true,
+			"id":      postID,
 			"message": "New post is created.",
 		})
 	}, *controller.jwtMiddleware, *controller.adminMiddleware)
 
+	controller.GET("/posts", func(c echo.Context) error {
+		model := new(ListPostsModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		userID, _ := c.Get("userID").(string)
+		posts, pageInfo, err := postsService.List(model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading posts is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"posts":  posts,
+			"page":   pageInfo,
+		})
+	}, *controller.optionalJWTMiddleware)
+
 	controller.GET("/posts/:page", func(c echo.Context) error {
 		param := c.Param("page")
 		page, err := strconv.Atoi(param)
@@ -77,13 +114,13 @@ func (controller *PostsController) UseRoute() {
 				Message: "Invalid post id.",
 			})
 		}
-		post, comments := postsService.Post(id)
+		userID, _ := c.Get("userID").(string)
+		post := postsService.Post(id, userID)
 		return c.JSON(http.StatusOK, echo.Map{
-			"status":   true,
-			"post":     post,
-			"comments": comments,
+			"status": true,
+			"post":   post,
 		})
-	})
+	}, *controller.optionalJWTMiddleware)
 
 	controller.PUT("/posts/id/:id", func(c echo.Context) error {
 		model := new(UpdatePostModel)
@@ -103,9 +140,9 @@ func (controller *PostsController) UseRoute() {
 				Message: "Invalid post id.",
 			})
 		}
-		isUpdated := postsService.Update(model, postID, userID)
-		if !isUpdated {
-			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+		err = postsService.Update(model, postID, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
 				Status:  false,
 				Message: "Updating the post is failed.",
 			})
@@ -126,9 +163,9 @@ func (controller *PostsController) UseRoute() {
 				Message: "Invalid post id.",
 			})
 		}
-		isDeleted := postsService.Delete(postID, userID)
-		if !isDeleted {
-			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+		err = postsService.Delete(postID, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
 				Status:  false,
 				Message: "Deleting the post is failed.",
 			})
@@ -139,11 +176,35 @@ func (controller *PostsController) UseRoute() {
 		})
 	}, *controller.jwtMiddleware, *controller.adminMiddleware)
 
+	controller.GET("/posts/tag/:tag", func(c echo.Context) error {
+		model := new(ListPostsModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		userID, _ := c.Get("userID").(string)
+		posts, pageInfo, err := postsService.ListByTag(c.Param("tag"), model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading posts is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"posts":  posts,
+			"page":   pageInfo,
+		})
+	}, *controller.optionalJWTMiddleware)
+
 	controller.GET("/posts/tag/:tag/:page", func(c echo.Context) error {
 		tag := c.Param("tag")
 		param := c.Param("page")
 		page, err := strconv.Atoi(param)
-		if err != nil {
+		if err != nil || page < 1 {
 			return c.JSON(http.StatusNotFound, &db.BadResponse{
 				Status:  false,
 				Message: "Invalid page.",
@@ -160,7 +221,7 @@ func (controller *PostsController) UseRoute() {
 		keyword := c.Param("keyword")
 		param := c.Param("page")
 		page, err := strconv.Atoi(param)
-		if err != nil {
+		if err != nil || page < 1 {
 			return c.JSON(http.StatusNotFound, &db.BadResponse{
 				Status:  false,
 				Message: "Invalid page.",
@@ -172,4 +233,28 @@ func (controller *PostsController) UseRoute() {
 			"posts":  posts,
 		})
 	})
+
+	controller.GET("/posts/search", func(c echo.Context) error {
+		model := new(SearchPostModel)
+		err := c.Bind(model)
+		if err != nil || len(strings.TrimSpace(model.Query)) == 0 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid search query.",
+			})
+		}
+		userID, _ := c.Get("userID").(string)
+		results, pageInfo, err := postsService.Search(model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Searching posts is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"results": results,
+			"page":    pageInfo,
+		})
+	}, *controller.optionalJWTMiddleware)
 }
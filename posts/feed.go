@@ -0,0 +1,35 @@
+package posts
+
+import (
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/tags"
+)
+
+// Feed returns the latest posts for the feeds, of the tag or of the author when they are not
+// empty. The author is found by the username regardless of its case, and is returned with the
+// stored one. It returns a not found error for an unknown tag or author.
+func (service *PostsService) Feed(tag string, username string) ([]PostModel, string, error) {
+	query := &store.PostQuery{}
+	if len(tag) > 0 {
+		var err error
+		query, err = service.tagQuery(tag)
+		if err != nil {
+			return nil, "", err
+		}
+		_, err = tags.Find(service.repository, query.Tag)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if len(username) > 0 {
+		author, err := service.stores.Users().ByUsername(username)
+		if err != nil {
+			return nil, "", err
+		}
+		query.UserID = author.ID
+		username = author.Username
+	}
+	query.Limit, _ = service.config.GetFeed()
+	posts, err := service.queryPosts(query, "")
+	return posts, username, err
+}
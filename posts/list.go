@@ -0,0 +1,196 @@
+package posts
+
+import (
+	"fmt"
+
+	cm "github.com/quavious/blog-factory-server/comments"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/tags"
+)
+
+func (service *PostsService) tagQuery(tag string) (*store.PostQuery, error) {
+	tag, ok := tags.Normalize(tag, service.config.GetTagMaxLength())
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid tag", db.ErrInvalid)
+	}
+	return &store.PostQuery{Tag: tag}, nil
+}
+
+// Posts returns the page of the latest posts. It is kept for the page number routes.
+func (service *PostsService) Posts(page int) []PostModel {
+	posts, err := service.postsAt(&store.PostQuery{}, page)
+	if err != nil {
+		return nil
+	}
+	return posts
+}
+
+func (service *PostsService) PostsByTag(tag string, page int) []PostModel {
+	query, err := service.tagQuery(tag)
+	if err != nil {
+		return nil
+	}
+	posts, err := service.postsAt(query, page)
+	if err != nil {
+		return nil
+	}
+	return posts
+}
+
+// List returns the page of the posts after the cursor, the latest or the most liked first.
+func (service *PostsService) List(model *ListPostsModel, userID string) ([]PostModel, *db.PageInfo, error) {
+	return service.list(&store.PostQuery{}, model, userID)
+}
+
+func (service *PostsService) ListByTag(tag string, model *ListPostsModel, userID string) ([]PostModel, *db.PageInfo, error) {
+	query, err := service.tagQuery(tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.list(query, model, userID)
+}
+
+func (service *PostsService) postsAt(query *store.PostQuery, page int) ([]PostModel, error) {
+	pageSize, _ := service.config.GetPageSize()
+	query.Limit = pageSize
+	query.Offset = (page - 1) * pageSize
+	return service.queryPosts(query, "")
+}
+
+// list pages the posts by the (created_at, id) key, the newest first. The most liked posts
+// are ranked, so that sort pages by offset.
+func (service *PostsService) list(query *store.PostQuery, model *ListPostsModel, viewerID string) ([]PostModel, *db.PageInfo, error) {
+	cursor, err := db.DecodeCursor(model.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	pageSize, maxPageSize := service.config.GetPageSize()
+	limit := db.PageLimit(model.Limit, pageSize, maxPageSize)
+	switch model.Sort {
+	case "", SortLatest:
+	case SortLiked:
+		return service.listLiked(query, model, cursor, limit, viewerID)
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown sort %q", db.ErrInvalid, model.Sort)
+	}
+	keyset := *query
+	keyset.Limit = limit + 1
+	if cursor != nil {
+		keyset.After = &store.Key{CreatedAt: cursor.CreatedAt, ID: cursor.ID}
+		keyset.Backward = cursor.Backward
+	}
+	posts, err := service.queryPosts(&keyset, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+
+	pageInfo := &db.PageInfo{Limit: limit}
+	if len(posts) > 0 {
+		first, last := posts[0], posts[len(posts)-1]
+		if (!backward && hasMore) || backward {
+			pageInfo.NextCursor = (&db.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+		}
+		if (backward && hasMore) || (!backward && cursor != nil) {
+			pageInfo.PrevCursor = (&db.Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Backward: true}).Encode()
+		}
+	}
+	if model.Total {
+		total, err := service.stores.Posts().Count(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageInfo.Total = &total
+	}
+	return posts, pageInfo, nil
+}
+
+func (service *PostsService) listLiked(query *store.PostQuery, model *ListPostsModel, cursor *db.Cursor, limit int, viewerID string) ([]PostModel, *db.PageInfo, error) {
+	offset := 0
+	if cursor != nil {
+		offset = cursor.Offset
+	}
+	ranked := *query
+	ranked.Liked = true
+	ranked.Limit = limit + 1
+	ranked.Offset = offset
+	posts, err := service.queryPosts(&ranked, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pageInfo := &db.PageInfo{Limit: limit}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		pageInfo.NextCursor = (&db.Cursor{Offset: offset + limit}).Encode()
+	}
+	if offset > 0 {
+		previous := offset - limit
+		if previous < 0 {
+			previous = 0
+		}
+		pageInfo.PrevCursor = (&db.Cursor{Offset: previous, Backward: true}).Encode()
+	}
+	if model.Total {
+		total, err := service.stores.Posts().Count(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageInfo.Total = &total
+	}
+	return posts, pageInfo, nil
+}
+
+// queryPosts lists the posts of the query with their relations. The viewer is the user whose
+// reactions and bookmarks are marked.
+func (service *PostsService) queryPosts(query *store.PostQuery, viewerID string) ([]PostModel, error) {
+	stored, err := service.stores.Posts().List(query)
+	if err != nil {
+		return nil, err
+	}
+	posts := []PostModel{}
+	for i := range stored {
+		posts = append(posts, *service.postModel(&stored[i]))
+	}
+	err = service.loadRelations(posts)
+	if err != nil {
+		return nil, err
+	}
+	err = service.loadReactions(posts, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	err = service.loadBookmarks(posts, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// postModel returns the stored post without its relations.
+func (service *PostsService) postModel(stored *store.Post) *PostModel {
+	post := &PostModel{
+		ID:          stored.ID,
+		Title:       stored.Title,
+		Description: stored.Description,
+		Content:     stored.Content,
+		CreatedAt:   stored.CreatedAt,
+		UpdatedAt:   stored.UpdatedAt,
+		UserID:      stored.UserID,
+	}
+	post.CommentStatus = service.commentStatus(post, &cm.Settings{
+		Disabled:  stored.CommentsDisabled,
+		Locked:    stored.CommentsLocked,
+		CloseDays: stored.CommentsCloseDays,
+	})
+	return post
+}
@@ -0,0 +1,103 @@
+package posts
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	cm "github.com/quavious/blog-factory-server/comments"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/reactions"
+	"github.com/quavious/blog-factory-server/tags"
+)
+
+// loadRelations fills the tags, comment counts and authors of the posts.
+// It makes the same three queries however many posts there are.
+func (service *PostsService) loadRelations(posts []PostModel) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	postIDs := make([]int, len(posts))
+	userIDs := []string{}
+	seen := map[string]bool{}
+	for i, post := range posts {
+		postIDs[i] = post.ID
+		if !seen[post.UserID] {
+			seen[post.UserID] = true
+			userIDs = append(userIDs, post.UserID)
+		}
+	}
+	loaded, err := tags.Load(service.repository, postIDs)
+	if err != nil {
+		return err
+	}
+	counts, err := service.stores.Comments().CountByPost(postIDs, cm.StatusApproved)
+	if err != nil {
+		return err
+	}
+	usernames, err := service.stores.Users().Usernames(userIDs)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Tags = append(tagArray{}, loaded[posts[i].ID]...)
+		posts[i].CommentCount = counts[posts[i].ID]
+		posts[i].Username = usernames[posts[i].UserID]
+	}
+	return nil
+}
+
+// placeholders returns "?, ?, ..." for an "in" clause of count values.
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// loadReactions loads the reaction counts of the posts and marks the reactions of the viewer.
+func (service *PostsService) loadReactions(posts []PostModel, viewerID string) error {
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	loaded, err := reactions.Load(service.repository, reactions.TargetPost, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Reactions = loaded[posts[i].ID]
+		if posts[i].Reactions == nil {
+			posts[i].Reactions = []reactions.ReactionModel{}
+		}
+	}
+	return nil
+}
+
+// loadBookmarks marks the posts which the viewer has bookmarked.
+func (service *PostsService) loadBookmarks(posts []PostModel, viewerID string) error {
+	if len(posts) == 0 || len(viewerID) == 0 {
+		return nil
+	}
+	args := []interface{}{viewerID}
+	positions := map[int]int{}
+	for i, post := range posts {
+		args = append(args, post.ID)
+		positions[post.ID] = i
+	}
+	rows, err := service.repository.Query(fmt.Sprintf(`
+	select post_id from bookmarks
+	where user_id = ? and post_id in (%s)`, placeholders(len(posts))), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID int
+		err := rows.Scan(&postID)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		posts[positions[postID]].Bookmarked = true
+	}
+	return nil
+}
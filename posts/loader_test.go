@@ -0,0 +1,185 @@
+package posts
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// roundTrip is the latency every query of the fake database takes.
+const roundTrip = 200 * time.Microsecond
+
+var queries int64
+
+// fakeDriver answers the relation queries of the loader with generated rows.
+type fakeDriver struct{}
+
+type fakeConn struct{}
+
+type fakeStmt struct {
+	query string
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func init() {
+	sql.Register("posts-fake", fakeDriver{})
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{query: query}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (stmt *fakeStmt) Close() error {
+	return nil
+}
+
+func (stmt *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (stmt *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (stmt *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	atomic.AddInt64(&queries, 1)
+	time.Sleep(roundTrip)
+	rows := new(fakeRows)
+	switch {
+	case strings.Contains(stmt.query, "select pt.post_id, t.tag"):
+		rows.columns = []string{"post_id", "tag"}
+		for _, arg := range args {
+			rows.values = append(rows.values, []driver.Value{arg, "go"}, []driver.Value{arg, "sql"})
+		}
+	case strings.Contains(stmt.query, "select t.tag"):
+		rows.columns = []string{"tag"}
+		rows.values = [][]driver.Value{{"go"}, {"sql"}}
+	case strings.Contains(stmt.query, "select post_id, count(*)"):
+		rows.columns = []string{"post_id", "count"}
+		for _, arg := range args {
+			if _, ok := arg.(int64); ok {
+				rows.values = append(rows.values, []driver.Value{arg, int64(3)})
+			}
+		}
+	case strings.Contains(stmt.query, "select count(*)"):
+		rows.columns = []string{"count"}
+		rows.values = [][]driver.Value{{int64(3)}}
+	case strings.Contains(stmt.query, "select id, username"):
+		rows.columns = []string{"id", "username"}
+		for _, arg := range args {
+			rows.values = append(rows.values, []driver.Value{arg, "writer"})
+		}
+	case strings.Contains(stmt.query, "select username"):
+		rows.columns = []string{"username"}
+		rows.values = [][]driver.Value{{"writer"}}
+	default:
+		return nil, fmt.Errorf("unexpected query: %s", stmt.query)
+	}
+	return rows, nil
+}
+
+func (rows *fakeRows) Columns() []string {
+	return rows.columns
+}
+
+func (rows *fakeRows) Close() error {
+	return nil
+}
+
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if len(rows.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, rows.values[0])
+	rows.values = rows.values[1:]
+	return nil
+}
+
+func newFakeService(tb testing.TB) (*PostsService, []PostModel) {
+	conn, err := sql.Open("posts-fake", "")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	repository := &db.Repository{DB: conn}
+	service := &PostsService{repository: repository, stores: store.NewSQLStore(repository)}
+	posts := make([]PostModel, 10)
+	for i := range posts {
+		posts[i] = PostModel{ID: i + 1, UserID: fmt.Sprintf("user-%d", i%3)}
+	}
+	return service, posts
+}
+
+// loadRelationsOneByOne is how the relations were loaded before, a few queries for every post.
+func loadRelationsOneByOne(service *PostsService, posts []PostModel) {
+	for i := range posts {
+		posts[i].Tags = *service.Tags(posts[i].ID)
+		row := service.repository.QueryRow(`select count(*) from comments where post_id = ?`, posts[i].ID)
+		row.Scan(&posts[i].CommentCount)
+		row = service.repository.QueryRow(`select username from users where id = ?`, posts[i].UserID)
+		row.Scan(&posts[i].Username)
+	}
+}
+
+func TestLoadRelations(t *testing.T) {
+	service, posts := newFakeService(t)
+	before := atomic.LoadInt64(&queries)
+	err := service.loadRelations(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if made := atomic.LoadInt64(&queries) - before; made != 3 {
+		t.Errorf("expected 3 queries, got %d", made)
+	}
+	for _, post := range posts {
+		if len(post.Tags) != 2 || post.CommentCount != 3 || post.Username != "writer" {
+			t.Errorf("relations of post %d are not loaded: %+v", post.ID, post)
+		}
+	}
+}
+
+func BenchmarkLoadRelations(b *testing.B) {
+	service, posts := newFakeService(b)
+	b.Run("OneByOne", func(b *testing.B) {
+		before := atomic.LoadInt64(&queries)
+		for i := 0; i < b.N; i++ {
+			loadRelationsOneByOne(service, posts)
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&queries)-before)/float64(b.N), "queries/op")
+	})
+	b.Run("Batched", func(b *testing.B) {
+		before := atomic.LoadInt64(&queries)
+		for i := 0; i < b.N; i++ {
+			err := service.loadRelations(posts)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(atomic.LoadInt64(&queries)-before)/float64(b.N), "queries/op")
+	})
+}
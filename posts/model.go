@@ -2,30 +2,85 @@ package posts
 
 import (
 	"time"
+
+	"github.com/quavious/blog-factory-server/mentions"
+	"github.com/quavious/blog-factory-server/reactions"
 )
 
 type tagArray []string
 
 type PostModel struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Content     string    `json:"content"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	Username    string    `json:"username"`
-	Tags        tagArray  `json:"tags,omitempty"`
+	ID           int       `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Content      string    `json:"content"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	Username     string    `json:"username"`
+	Tags         tagArray  `json:"tags,omitempty"`
+	CommentCount int       `json:"commentCount"`
+	// CommentStatus is open, locked, closed or disabled.
+	CommentStatus string                    `json:"commentStatus"`
+	Reactions     []reactions.ReactionModel `json:"reactions"`
+	// Bookmarked tells whether the current user has bookmarked the post.
+	Bookmarked bool `json:"bookmarked"`
+	// Mentions are the users mentioned in the content. They are loaded only with a single post.
+	Mentions []mentions.Mention `json:"mentions,omitempty"`
+	UserID   string             `json:"-"`
 }
 
 type CreatePostModel struct {
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Content     string   `json:"content"`
-	Tags        []string `json:"tags"`
+	Title             string   `json:"title"`
+	Description       string   `json:"description"`
+	Content           string   `json:"content"`
+	Tags              []string `json:"tags"`
+	CommentModeration string   `json:"commentModeration"`
+	CommentsDisabled  bool     `json:"commentsDisabled"`
+	CommentsLocked    bool     `json:"commentsLocked"`
+	// CommentsCloseDays follows the site setting when it is nil. Zero never closes the comments.
+	CommentsCloseDays *int `json:"commentsCloseDays"`
 }
 
 type UpdatePostModel struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Content     string `json:"content"`
+	// Tags is nil when the request has no tags field, then the tags are kept.
+	Tags []string `json:"tags"`
+	// CommentModeration is kept when it is nil and reset to the site mode when it is empty.
+	CommentModeration *string `json:"commentModeration"`
+	CommentsDisabled  *bool   `json:"commentsDisabled"`
+	CommentsLocked    *bool   `json:"commentsLocked"`
+	// CommentsCloseDays is kept when it is nil and reset to the site setting when it is negative.
+	CommentsCloseDays *int `json:"commentsCloseDays"`
+}
+
+// Sorts of the post lists.
+const (
+	SortLatest = "latest"
+	SortLiked  = "liked"
+)
+
+type ListPostsModel struct {
+	// Sort is latest by default, or liked to put the posts with the most reactions first.
+	Sort   string `query:"sort"`
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit"`
+	Total  bool   `query:"total"`
+}
+
+type SearchPostModel struct {
+	Query  string `query:"q"`
+	Tag    string `query:"tag"`
+	Author string `query:"author"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Page   int    `query:"page"`
+	ListPostsModel
+}
+
+type SearchResultModel struct {
+	Post    PostModel `json:"post"`
+	Score   float64   `json:"score"`
+	Snippet string    `json:"snippet"`
 }
@@ -0,0 +1,144 @@
+package posts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/tags"
+	"github.com/quavious/blog-factory-server/webhooks"
+)
+
+// Search finds the posts matching the query in the search index, the most relevant first.
+// The results are ranked, so the cursor keeps the offset of the next page.
+func (service *PostsService) Search(model *SearchPostModel, userID string) ([]SearchResultModel, *db.PageInfo, error) {
+	cursor, err := db.DecodeCursor(model.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	pageSize, maxPageSize := service.config.GetPageSize()
+	limit := db.PageLimit(model.Limit, pageSize, maxPageSize)
+	start := 0
+	if cursor != nil {
+		start = cursor.Offset
+	} else if model.Page > 1 {
+		start = (model.Page - 1) * limit
+	}
+
+	filter := search.Filter{Author: model.Author}
+	if len(model.Tag) > 0 {
+		var ok bool
+		filter.Tag, ok = tags.Normalize(model.Tag, service.config.GetTagMaxLength())
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: invalid tag", db.ErrInvalid)
+		}
+	}
+	if from, err := time.Parse("2006-01-02", model.From); err == nil {
+		filter.From = from
+	}
+	if to, err := time.Parse("2006-01-02", model.To); err == nil {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	hits := service.index.Search(model.Query, filter)
+	total := len(hits)
+	pageInfo := &db.PageInfo{Limit: limit, Total: &total}
+	if start >= len(hits) {
+		return []SearchResultModel{}, pageInfo, nil
+	}
+	end := start + limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+	if end < len(hits) {
+		pageInfo.NextCursor = (&db.Cursor{Offset: end}).Encode()
+	}
+	if start > 0 {
+		previous := start - limit
+		if previous < 0 {
+			previous = 0
+		}
+		pageInfo.PrevCursor = (&db.Cursor{Offset: previous, Backward: true}).Encode()
+	}
+
+	pageHits := hits[start:end]
+	ids := make([]int, len(pageHits))
+	for i, hit := range pageHits {
+		ids[i] = hit.ID
+	}
+	posts := service.PostsByIDs(ids, userID)
+	results := []SearchResultModel{}
+	for _, hit := range pageHits {
+		post, ok := posts[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResultModel{
+			Post:    *post,
+			Score:   hit.Score,
+			Snippet: hit.Snippet,
+		})
+	}
+	return results, pageInfo, nil
+}
+
+// PostsByIDs returns the visible posts with the ids, keyed by the ids.
+func (service *PostsService) PostsByIDs(ids []int, userID string) map[int]*PostModel {
+	posts := map[int]*PostModel{}
+	if len(ids) == 0 {
+		return posts
+	}
+	loaded, err := service.queryPosts(&store.PostQuery{IDs: ids}, userID)
+	if err != nil {
+		return posts
+	}
+	for i := range loaded {
+		posts[loaded[i].ID] = &loaded[i]
+	}
+	return posts
+}
+
+// SetHidden hides the post from the readers or shows it again. The bookmarks of a hidden post
+// are kept, and are left out of the lists until it is shown again.
+func (service *PostsService) SetHidden(postID int, hidden bool) error {
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		err := stores.Posts().SetHidden(postID, hidden)
+		if err != nil {
+			return err
+		}
+		post, err := stores.Posts().ByID(postID)
+		if err != nil {
+			return err
+		}
+		return webhooks.Dispatch(tx, webhooks.EventPostUpdated, service.webhookData(post))
+	})
+	if err != nil {
+		return err
+	}
+	service.indexPost(postID)
+	return nil
+}
+
+// indexPost puts the current state of the post into the search index. Hidden posts are removed.
+func (service *PostsService) indexPost(postID int) {
+	post, err := service.stores.Posts().ByID(postID)
+	if err != nil || post.IsHidden {
+		service.index.Remove(postID)
+		return
+	}
+	author, err := service.stores.Users().ByID(post.UserID)
+	if err != nil {
+		service.index.Remove(postID)
+		return
+	}
+	service.index.Put(search.Document{
+		ID:          postID,
+		Title:       post.Title,
+		Description: post.Description,
+		Content:     post.Content,
+		Tags:        *service.Tags(postID),
+		Username:    author.Username,
+		CreatedAt:   post.CreatedAt,
+	})
+}
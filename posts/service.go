@@ -1,6 +1,7 @@
 package posts
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"time"
@@ -8,246 +9,358 @@ import (
 	cm "github.com/quavious/blog-factory-server/comments"
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/mentions"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/reactions"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/tags"
+	"github.com/quavious/blog-factory-server/webhooks"
 )
 
 type PostsService struct {
-	config     *config.Config
-	repository *db.Repository
+	config        *config.Config
+	repository    *db.Repository
+	stores        store.Store
+	index         *search.Index
+	notifications *notifications.NotificationsService
 }
 
-func NewPostsService(config *config.Config, repository *db.Repository) *PostsService {
+func NewPostsService(config *config.Config, repository *db.Repository, stores store.Store, index *search.Index, mailClient *mail.MailClient) *PostsService {
 	return &PostsService{
-		config:     config,
-		repository: repository,
+		config:        config,
+		repository:    repository,
+		stores:        stores,
+		index:         index,
+		notifications: notifications.NewNotificationsService(config, repository, mailClient),
 	}
 }
 
-func (service *PostsService) Posts(page int) []PostModel {
-	rows, err := service.repository.Query(`
-	select p.id, p.title, p.description, p.content, p.created_at, p.updated_at, u.username 
-	from posts as p 
-	join users as u on p.user_id = u.id
-	order by created_at desc 
-	limit ? 
-	offset ?`, (page)*10, (page-1)*10)
+// Post returns the post without its comments, which are listed by the comments endpoint.
+func (service *PostsService) Post(postID int, userID string) *PostModel {
+	stored, err := service.stores.Posts().ByID(postID)
 	if err != nil {
 		return nil
 	}
-	posts := []PostModel{}
-	for rows.Next() {
-		post := new(PostModel)
-		err := rows.Scan(&post.ID, &post.Title, &post.Description, &post.Content, &post.CreatedAt, &post.UpdatedAt, &post.Username)
+	if stored.IsHidden && !cm.LookupViewer(service.stores.Users(), userID).IsModerator {
+		return nil
+	}
+	loaded := []PostModel{*service.postModel(stored)}
+	err = service.loadRelations(loaded)
+	if err != nil {
+		return nil
+	}
+	err = service.loadReactions(loaded, userID)
+	if err != nil {
+		return nil
+	}
+	err = service.loadBookmarks(loaded, userID)
+	if err != nil {
+		return nil
+	}
+	usernames, err := mentions.Usernames(service.repository, mentions.SourcePost, []int{postID})
+	if err != nil {
+		return nil
+	}
+	loaded[0].Mentions = mentions.List(usernames[postID], service.config.GetSiteURL())
+	return &loaded[0]
+}
+
+func (service *PostsService) Create(model *CreatePostModel, userID string) (int, error) {
+	if len(model.Title) == 0 {
+		return 0, fmt.Errorf("%w: the title is empty", db.ErrInvalid)
+	}
+	moderation, err := moderationMode(&model.CommentModeration)
+	if err != nil {
+		return 0, err
+	}
+	closeDays, err := closeDays(model.CommentsCloseDays)
+	if err != nil {
+		return 0, err
+	}
+	var postID int
+	err = store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		createdAt := time.Now().UTC()
+		post := &store.Post{
+			Title:             model.Title,
+			Description:       model.Description,
+			Content:           model.Content,
+			UserID:            userID,
+			CommentModeration: moderation,
+			CommentsDisabled:  model.CommentsDisabled,
+			CommentsLocked:    model.CommentsLocked,
+			CommentsCloseDays: closeDays,
+			CreatedAt:         createdAt,
+			UpdatedAt:         createdAt,
+		}
+		postID, err = stores.Posts().Create(post)
 		if err != nil {
-			log.Println(err.Error())
+			return err
+		}
+		post.ID = postID
+		tagIDs, err := tags.Resolve(tx, model.Tags, service.config.GetTagMaxLength())
+		if err != nil {
+			return err
 		}
-		tags := service.Tags(post.ID)
-		post.Tags = *tags
-		posts = append(posts, *post)
+		err = tags.Link(tx, postID, tagIDs)
+		if err != nil {
+			return err
+		}
+		return webhooks.Dispatch(tx, webhooks.EventPostPublished, service.webhookData(post))
+	})
+	if err != nil {
+		return 0, err
 	}
-	return posts
+	service.indexPost(postID)
+	service.mention(postID)
+	return postID, nil
 }
 
-func (service *PostsService) Post(postID int) (*PostModel, *cm.CommentArray) {
-	res := service.repository.QueryRow(`
-	select p.id, p.title, p.description, p.content, p.created_at, p.updated_at, u.username
-	from posts as p
-	join users as u on p.user_id = u.id
-	where p.id = ?;`, postID)
-	post := new(PostModel)
-	err := res.Scan(&post.ID, &post.Title, &post.Description, &post.Content, &post.CreatedAt, &post.UpdatedAt, &post.Username)
-	if err != nil {
-		log.Println(err.Error())
-		return nil, nil
-	}
-	fmt.Println(post.ID, post.CreatedAt)
-	rows, err := service.repository.Query(`
-	select c.id, c.content, c.created_at, c.updated_at, u.username
-	from comments as c
-	join posts as p on c.post_id = p.id
-	join users as u on c.user_id = u.id
-	where c.post_id = ?
-	order by c.created_at asc
-	`, post.ID)
-	comments := new(cm.CommentArray)
-	if err != nil {
-		log.Println(err.Error())
-	} else {
-		for rows.Next() {
-			comment := new(cm.CommentModel)
-			err := rows.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt, &comment.Username)
+func (service *PostsService) Update(model *UpdatePostModel, postID int, userID string) error {
+	if len(model.Title) == 0 {
+		return fmt.Errorf("%w: the title is empty", db.ErrInvalid)
+	}
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		post, err := checkOwner(stores.Posts(), postID, userID)
+		if err != nil {
+			return err
+		}
+		post.Title = model.Title
+		post.Description = model.Description
+		post.Content = model.Content
+		post.UpdatedAt = time.Now().UTC()
+		if model.CommentModeration != nil {
+			post.CommentModeration, err = moderationMode(model.CommentModeration)
 			if err != nil {
-				log.Println(err.Error())
-			} else {
-				*comments = append(*comments, *comment)
+				return err
 			}
 		}
+		updateCommentSettings(post, model)
+		err = stores.Posts().Update(post)
+		if err != nil {
+			return err
+		}
+		err = webhooks.Dispatch(tx, webhooks.EventPostUpdated, service.webhookData(post))
+		if err != nil {
+			return err
+		}
+		if model.Tags == nil {
+			return nil
+		}
+		tagIDs, err := tags.Resolve(tx, model.Tags, service.config.GetTagMaxLength())
+		if err != nil {
+			return err
+		}
+		err = tags.Unlink(tx, postID)
+		if err != nil {
+			return err
+		}
+		err = tags.Link(tx, postID, tagIDs)
+		if err != nil {
+			return err
+		}
+		_, err = tags.DeleteUnused(tx)
+		return err
+	})
+	if err != nil {
+		return err
 	}
-	post.Tags = *service.Tags(post.ID)
-	return post, comments
+	service.indexPost(postID)
+	service.mention(postID)
+	return nil
 }
 
-func (service *PostsService) Create(model *CreatePostModel, userID string) bool {
-	tags := []int{}
-	for _, tag := range model.Tags {
-		res, err := service.repository.Exec(`insert ignore into tags (tag) values (?)`, tag)
+func (service *PostsService) Delete(postID int, userID string) error {
+	return service.delete(postID, &userID)
+}
+
+// Remove deletes the post of any author. It is used by the moderators.
+func (service *PostsService) Remove(postID int) error {
+	return service.delete(postID, nil)
+}
+
+func (service *PostsService) delete(postID int, userID *string) error {
+	err := store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		var post *store.Post
+		var err error
+		if userID != nil {
+			post, err = checkOwner(stores.Posts(), postID, *userID)
+		} else {
+			post, err = stores.Posts().ByID(postID)
+		}
 		if err != nil {
-			log.Println(err.Error())
-			continue
+			return err
+		}
+		err = webhooks.Dispatch(tx, webhooks.EventPostDeleted, service.webhookData(post))
+		if err != nil {
+			return err
+		}
+		err = tags.Unlink(tx, postID)
+		if err != nil {
+			return err
+		}
+		comments, err := stores.Comments().List(&store.CommentQuery{PostID: postID})
+		if err != nil {
+			return err
+		}
+		commentIDs := []int{}
+		for _, comment := range comments {
+			commentIDs = append(commentIDs, comment.ID)
 		}
-		inserted, err := res.LastInsertId()
+		err = mentions.Remove(tx, mentions.SourcePost, postID)
+		if err != nil {
+			return err
+		}
+		err = mentions.Remove(tx, mentions.SourceComment, commentIDs...)
+		if err != nil {
+			return err
+		}
+		err = reactions.Remove(tx, reactions.TargetPost, postID)
+		if err != nil {
+			return err
+		}
+		err = reactions.Remove(tx, reactions.TargetComment, commentIDs...)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`delete from bookmarks where post_id = ?`, postID)
 		if err != nil {
 			log.Println(err.Error())
-			continue
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
 		}
-		if inserted == 0 {
-			var tagID int
-			row := service.repository.QueryRow(`select id from tags where tag = ?`, tag)
-			err := row.Scan(&tagID)
-			if err != nil {
-				log.Println(err.Error())
-			} else {
-				tags = append(tags, tagID)
-			}
-		} else {
-			tags = append(tags, int(inserted))
+		err = stores.Comments().DeleteByPost(postID)
+		if err != nil {
+			return err
 		}
-	}
-	createdAt := time.Now().UTC()
-	res, err := service.repository.Exec(`
-	insert into posts (title, description, content, created_at, updated_at, user_id) 
-	values (?, ?, ?, ?, ?, ?)
-	`, model.Title, model.Description, model.Content, createdAt, createdAt, userID)
+		err = stores.Posts().Delete(postID)
+		if err != nil {
+			return err
+		}
+		_, err = tags.DeleteUnused(tx)
+		return err
+	})
 	if err != nil {
-		log.Println(err.Error())
-		return false
+		return err
 	}
-	created, err := res.LastInsertId()
+	service.index.Remove(postID)
+	return nil
+}
+
+func (service *PostsService) PostsByKeyword(keyword string, page int) []PostModel {
+	results, _, err := service.Search(&SearchPostModel{Query: keyword, Page: page}, "")
 	if err != nil {
-		log.Println("error: id is not integer.")
-		return true
+		return nil
 	}
-	log.Println(created)
-
-	for _, tagID := range tags {
-		_, err = service.repository.Exec(`insert into posts_and_tags (post_id, tag_id) values (?, ?)`, int(created), tagID)
-		if err != nil {
-			log.Println(err.Error())
-		}
+	posts := []PostModel{}
+	for _, result := range results {
+		posts = append(posts, result.Post)
 	}
-	return true
+	return posts
 }
 
-func (service *PostsService) Update(model *UpdatePostModel, postID int, userID string) bool {
-	updatedAt := time.Now().UTC()
-	res, err := service.repository.Exec(`
-	update posts 
-	set title = ?, description = ?, content = ?, updated_at = ? where id = ? and user_id = ?
-	`, model.Title, model.Description, model.Content, updatedAt, postID, userID)
+func (service *PostsService) Tags(postID int) *tagArray {
+	loaded, err := tags.Load(service.repository, []int{postID})
 	if err != nil {
-		log.Println(err.Error())
-		return false
+		return new(tagArray)
 	}
-	updated, err := res.RowsAffected()
+	tags := tagArray(loaded[postID])
+	return &tags
+}
+
+// checkOwner returns the post unless it is missing or was not written by the user.
+func checkOwner(posts store.PostStore, postID int, userID string) (*store.Post, error) {
+	post, err := posts.ByID(postID)
 	if err != nil {
-		log.Println("error: id is not integer.")
-		return true
+		return nil, err
+	}
+	if post.UserID != userID {
+		return nil, fmt.Errorf("%w: post %d is not written by this user", db.ErrForbidden, postID)
 	}
-	log.Println(updated)
-	return true
+	return post, nil
 }
 
-func (service *PostsService) Delete(postID int, userID string) bool {
-	res, err := service.repository.Exec(`
-	delete from posts 
-	where id = ? and user_id = ?
-	`, postID, userID)
-	if err != nil {
-		log.Println(err.Error())
-		return false
+// moderationMode validates the comment moderation mode of a post. An empty mode is
+// stored as null so that the post follows the site mode.
+func moderationMode(mode *string) (sql.NullString, error) {
+	if mode == nil || len(*mode) == 0 {
+		return sql.NullString{}, nil
 	}
-	updated, err := res.RowsAffected()
-	if err != nil {
-		log.Println("error: id is not integer.")
-		return true
+	if !cm.IsModerationMode(*mode) {
+		return sql.NullString{}, fmt.Errorf("%w: unknown comment moderation mode %q", db.ErrInvalid, *mode)
 	}
-	log.Println(updated)
-	return true
+	return sql.NullString{String: *mode, Valid: true}, nil
 }
 
-func (service *PostsService) PostsByTag(tag string, page int) []PostModel {
-	var tagID int
-	row := service.repository.QueryRow(`select id from tags where tag = ?`, tag)
-	err := row.Scan(&tagID)
-	if err != nil {
-		return nil
+// updateCommentSettings changes the comment settings which are present in the request.
+func updateCommentSettings(post *store.Post, model *UpdatePostModel) {
+	if model.CommentsDisabled != nil {
+		post.CommentsDisabled = *model.CommentsDisabled
 	}
-	res, err := service.repository.Query(`
-	select p.id, p.title, p.description, p.content, p.created_at, p.updated_at, u.username
-	from posts as p
-	join users as u on p.user_id = u.id
-	join posts_and_tags as pt on p.id = pt.post_id
-	join tags as t on pt.tag_id = t.id
-	where t.tag = ?
-	order by created_at desc 
-	limit ?
-	offset ?`, tag, (page)*10, (page-1)*10)
-	if err != nil {
-		return nil
+	if model.CommentsLocked != nil {
+		post.CommentsLocked = *model.CommentsLocked
 	}
-	posts := []PostModel{}
-	for res.Next() {
-		post := new(PostModel)
-		res.Scan(&post.ID, &post.Title, &post.Description, &post.Content, &post.CreatedAt, &post.UpdatedAt, &post.Username)
-		tags := service.Tags(post.ID)
-		post.Tags = *tags
-		posts = append(posts, *post)
+	if model.CommentsCloseDays != nil {
+		post.CommentsCloseDays = sql.NullInt64{}
+		if *model.CommentsCloseDays >= 0 {
+			post.CommentsCloseDays = sql.NullInt64{Int64: int64(*model.CommentsCloseDays), Valid: true}
+		}
 	}
-	return posts
 }
 
-func (service *PostsService) PostsByKeyword(keyword string, page int) []PostModel {
-	res, err := service.repository.Query(`
-	select p.id, p.title, p.description, p.content, p.created_at, p.updated_at, u.username
-	from posts as p
-	join users as u on p.user_id = u.id
-	where p.title like ? 
-	order by created_at desc 
-	limit ? 
-	offset ?`, "%"+keyword+"%", (page)*10, (page-1)*10)
-	if err != nil {
-		return nil
+// closeDays validates the number of days after which the comments of a new post close.
+func closeDays(days *int) (sql.NullInt64, error) {
+	if days == nil {
+		return sql.NullInt64{}, nil
 	}
-	posts := []PostModel{}
-	for res.Next() {
-		post := new(PostModel)
-		res.Scan(&post.ID, &post.Title, &post.Description, &post.Content, &post.CreatedAt, &post.UpdatedAt, &post.Username)
-		tags := service.Tags(post.ID)
-		post.Tags = *tags
-		posts = append(posts, *post)
+	if *days < 0 {
+		return sql.NullInt64{}, fmt.Errorf("%w: the number of days is negative", db.ErrInvalid)
 	}
-	return posts
+	return sql.NullInt64{Int64: int64(*days), Valid: true}, nil
 }
 
-func (service *PostsService) Tags(postID int) *tagArray {
-	rows, err := service.repository.Query(`
-		select t.tag 
-		from tags as t
-		join posts_and_tags as pt on pt.tag_id = t.id
-		join posts as p on p.id = pt.post_id
-		where p.id = ?`, postID)
+func (service *PostsService) commentStatus(post *PostModel, settings *cm.Settings) string {
+	settings.CreatedAt = post.CreatedAt
+	return settings.Status(service.config.GetCommentAutoCloseDays(), time.Now().UTC())
+}
+
+// mention records the mentions of the post and notifies the users who are mentioned for the first time.
+func (service *PostsService) mention(postID int) {
+	post, err := service.stores.Posts().ByID(postID)
 	if err != nil {
-		return new(tagArray)
-	} else {
-		tags := new(tagArray)
-		for rows.Next() {
-			var tag string
-			err := rows.Scan(&tag)
-			if err != nil {
-				log.Println(err.Error())
-			} else {
-				*tags = append(*tags, tag)
-			}
-		}
-		return tags
+		return
+	}
+	author, err := service.stores.Users().ByID(post.UserID)
+	if err != nil {
+		return
+	}
+	mentioned, err := mentions.Record(service.repository, mentions.SourcePost, postID, post.UserID, post.Content)
+	if err != nil {
+		return
+	}
+	events := []notifications.Event{}
+	for _, mentionedID := range mentioned {
+		events = append(events, notifications.Event{
+			Type:    notifications.TypeMention,
+			UserID:  mentionedID,
+			ActorID: post.UserID,
+			PostID:  postID,
+			Message: fmt.Sprintf("%s mentioned you in \"%s\".", author.Username, post.Title),
+		})
+	}
+	service.notifications.Notify(events...)
+}
+
+// webhookData returns the post as the data of the webhooks.
+func (service *PostsService) webhookData(post *store.Post) *webhooks.PostData {
+	return &webhooks.PostData{
+		ID:          post.ID,
+		Title:       post.Title,
+		Description: post.Description,
+		URL:         notifications.Link(service.config, post.ID, 0),
+		UserID:      post.UserID,
+		IsHidden:    post.IsHidden,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
 	}
 }
@@ -0,0 +1,234 @@
+package posts
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cm "github.com/quavious/blog-factory-server/comments"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/migrations"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// newTestService returns the service on a memory store with the users alice, bob and the
+// moderator mod. The tables of the other domains are in a new SQLite database.
+func newTestService(t *testing.T) (*PostsService, store.Store) {
+	t.Helper()
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "posts.db"))
+	config := config.FromEnv()
+	repository := db.NewRepository(config)
+	if repository == nil {
+		t.Fatal("the database is not opened")
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := store.NewMemoryStore()
+	for _, name := range []string{"alice", "bob", "mod"} {
+		err := stores.Users().Create(&store.User{ID: name, Email: name + "@example.com", Username: name, IsAdmin: name == "mod"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewPostsService(config, repository, stores, search.NewIndex(), nil), stores
+}
+
+func createTestPost(t *testing.T, service *PostsService, title string) int {
+	t.Helper()
+	postID, err := service.Create(&CreatePostModel{Title: title, Content: "Content of " + title}, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return postID
+}
+
+func TestCreateAndUpdate(t *testing.T) {
+	service, stores := newTestService(t)
+	_, err := service.Create(&CreatePostModel{Title: ""}, "alice")
+	if !errors.Is(err, db.ErrInvalid) {
+		t.Errorf("creating a post without a title got %v", err)
+	}
+	_, err = service.Create(&CreatePostModel{Title: "Post", CommentModeration: "never"}, "alice")
+	if !errors.Is(err, db.ErrInvalid) {
+		t.Errorf("creating a post with an unknown moderation mode got %v", err)
+	}
+
+	postID := createTestPost(t, service, "First")
+	post := service.Post(postID, "")
+	if post == nil {
+		t.Fatal("the created post is not found")
+	}
+	if post.Title != "First" || post.Username != "alice" || post.CommentStatus != cm.CommentsOpen {
+		t.Errorf("got the post %+v", post)
+	}
+
+	tests := []struct {
+		name   string
+		postID int
+		userID string
+		want   error
+	}{
+		{name: "another user", postID: postID, userID: "bob", want: db.ErrForbidden},
+		{name: "a missing post", postID: postID + 1, userID: "alice", want: db.ErrNotFound},
+		{name: "the author", postID: postID, userID: "alice"},
+	}
+	for _, test := range tests {
+		locked := true
+		err := service.Update(&UpdatePostModel{Title: "Edited", CommentsLocked: &locked}, test.postID, test.userID)
+		if !errors.Is(err, test.want) || (test.want == nil && err != nil) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+	stored, err := stores.Posts().ByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "Edited" || !stored.CommentsLocked || stored.Content != "" {
+		t.Errorf("got the updated post %+v", stored)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	service, stores := newTestService(t)
+	postID := createTestPost(t, service, "Post")
+	otherID := createTestPost(t, service, "Other")
+	now := time.Now().UTC()
+	parentID, err := stores.Comments().Create(&store.Comment{PostID: postID, UserID: "bob", Status: cm.StatusApproved, CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replyID, err := stores.Comments().Create(&store.Comment{PostID: postID, ParentID: &parentID, UserID: "alice", Status: cm.StatusApproved, CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCommentID, err := stores.Comments().Create(&store.Comment{PostID: otherID, UserID: "bob", Status: cm.StatusApproved, CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.Delete(postID, "bob")
+	if !errors.Is(err, db.ErrForbidden) {
+		t.Errorf("deleting the post of another user got %v", err)
+	}
+	err = service.Delete(postID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stores.Posts().ByID(postID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("the deleted post is found: %v", err)
+	}
+	for _, id := range []int{parentID, replyID} {
+		if _, err := stores.Comments().ByID(id); !errors.Is(err, db.ErrNotFound) {
+			t.Errorf("comment %d of the deleted post is found: %v", id, err)
+		}
+	}
+	if _, err := stores.Comments().ByID(otherCommentID); err != nil {
+		t.Errorf("the comment of another post is deleted: %v", err)
+	}
+
+	err = service.Remove(otherID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stores.Posts().ByID(otherID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("the removed post is found: %v", err)
+	}
+	err = service.Remove(otherID)
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("removing a missing post got %v", err)
+	}
+}
+
+func TestListAndHide(t *testing.T) {
+	service, stores := newTestService(t)
+	ids := []int{}
+	for _, title := range []string{"First", "Second", "Third"} {
+		ids = append(ids, createTestPost(t, service, title))
+	}
+	now := time.Now().UTC()
+	for _, status := range []string{cm.StatusApproved, cm.StatusApproved, cm.StatusPending} {
+		_, err := stores.Comments().Create(&store.Comment{PostID: ids[0], UserID: "bob", Status: status, CreatedAt: now, UpdatedAt: now})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := service.SetHidden(ids[1], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if service.Post(ids[1], "bob") != nil {
+		t.Error("the hidden post is shown to a reader")
+	}
+	if service.Post(ids[1], "mod") == nil {
+		t.Error("the hidden post is not shown to a moderator")
+	}
+
+	listed := []int{}
+	model := &ListPostsModel{Limit: 1, Total: true}
+	for page := 0; page < 3; page++ {
+		posts, pageInfo, err := service.List(model, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, post := range posts {
+			listed = append(listed, post.ID)
+			if post.ID == ids[0] && post.CommentCount != 2 {
+				t.Errorf("the first post has %d comments, want 2", post.CommentCount)
+			}
+		}
+		if pageInfo.Total == nil || *pageInfo.Total != 2 {
+			t.Errorf("got the total %v, want 2", pageInfo.Total)
+		}
+		if len(pageInfo.NextCursor) == 0 {
+			break
+		}
+		model.Cursor = pageInfo.NextCursor
+	}
+	if len(listed) != 2 || listed[0] != ids[2] || listed[1] != ids[0] {
+		t.Errorf("listed %v, want %v", listed, []int{ids[2], ids[0]})
+	}
+
+	err = service.SetHidden(ids[1], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, _, err := service.List(&ListPostsModel{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 3 {
+		t.Errorf("listed %d posts after showing the hidden one, want 3", len(posts))
+	}
+	_, _, err = service.List(&ListPostsModel{Sort: "oldest"}, "")
+	if !errors.Is(err, db.ErrInvalid) {
+		t.Errorf("an unknown sort got %v", err)
+	}
+}
+
+func TestSearchRejectsInvalidTags(t *testing.T) {
+	service, _ := newTestService(t)
+	createTestPost(t, service, "Searched")
+	for _, tag := range []string{" ", strings.Repeat("a", service.config.GetTagMaxLength()+1)} {
+		_, _, err := service.Search(&SearchPostModel{Query: "searched", Tag: tag}, "")
+		if !errors.Is(err, db.ErrInvalid) {
+			t.Errorf("searching the tag %q got %v", tag, err)
+		}
+	}
+	results, _, err := service.Search(&SearchPostModel{Query: "searched"}, "")
+	if err != nil || len(results) != 1 {
+		t.Errorf("searching without a tag found %d posts: %v", len(results), err)
+	}
+}
@@ -0,0 +1,71 @@
+package reactions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+type ReactionsController struct {
+	*echo.Echo
+	config        *config.Config
+	repository    *db.Repository
+	jwtMiddleware *echo.MiddlewareFunc
+}
+
+func NewReactionsController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc) *ReactionsController {
+	return &ReactionsController{
+		Echo:          echo,
+		config:        config,
+		repository:    repository,
+		jwtMiddleware: jwtMiddleware,
+	}
+}
+
+func (controller *ReactionsController) UseRoute() {
+	reactionsService := NewReactionsService(controller.config, controller.repository)
+	controller.GET("/reactions", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"types":  reactionsService.Types(),
+		})
+	})
+
+	for path, target := range map[string]string{"/posts/:id/reactions": TargetPost, "/comments/:id/reactions": TargetComment} {
+		target := target
+		controller.POST(path, func(c echo.Context) error {
+			param := c.Param("id")
+			id, err := strconv.Atoi(param)
+			if err != nil || id < 1 {
+				return c.JSON(http.StatusBadRequest, &db.BadResponse{
+					Status:  false,
+					Message: "Invalid " + target + " id.",
+				})
+			}
+			model := new(ToggleReactionModel)
+			err = c.Bind(model)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, &db.BadResponse{
+					Status:  false,
+					Message: "Invalid data form.",
+				})
+			}
+			userID := c.Get("userID").(string)
+			reacted, reactions, err := reactionsService.Toggle(target, id, userID, model)
+			if err != nil {
+				return c.JSON(db.StatusCode(err), &db.BadResponse{
+					Status:  false,
+					Message: "Reacting is failed.",
+				})
+			}
+			return c.JSON(http.StatusOK, echo.Map{
+				"status":    true,
+				"reacted":   reacted,
+				"reactions": reactions,
+			})
+		}, *controller.jwtMiddleware)
+	}
+}
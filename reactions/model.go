@@ -0,0 +1,19 @@
+package reactions
+
+// Targets of the reactions.
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+type ToggleReactionModel struct {
+	Type string `json:"type"`
+}
+
+// ReactionModel is the count of one reaction type on a post or a comment.
+type ReactionModel struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+	// Reacted tells whether the current user has reacted with this type.
+	Reacted bool `json:"reacted"`
+}
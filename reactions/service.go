@@ -0,0 +1,240 @@
+package reactions
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// tables are the tables of the targets. They keep the total reaction count for the "most liked" sorts.
+var tables = map[string]string{
+	TargetPost:    "posts",
+	TargetComment: "comments",
+}
+
+type ReactionsService struct {
+	config     *config.Config
+	repository *db.Repository
+}
+
+func NewReactionsService(config *config.Config, repository *db.Repository) *ReactionsService {
+	return &ReactionsService{
+		config:     config,
+		repository: repository,
+	}
+}
+
+// Types returns the reaction types of the site.
+func (service *ReactionsService) Types() []string {
+	return service.config.GetReactionTypes()
+}
+
+// Toggle adds the reaction of the user, or removes it when the user has already reacted with the type.
+// It returns whether the user has the reaction now and the counts of the target. The reaction row is
+// unique per user and type, so concurrent toggles are serialized by the database and the counts are
+// changed in the same transaction.
+func (service *ReactionsService) Toggle(target string, targetID int, userID string, model *ToggleReactionModel) (bool, []ReactionModel, error) {
+	if !service.isType(model.Type) {
+		return false, nil, fmt.Errorf("%w: unknown reaction %q", db.ErrInvalid, model.Type)
+	}
+	table, ok := tables[target]
+	if !ok {
+		return false, nil, fmt.Errorf("%w: unknown target %q", db.ErrInvalid, target)
+	}
+	reacted := false
+	err := service.repository.Transaction(func(tx *db.Tx) error {
+		err := checkUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		err = checkTarget(tx, target, targetID)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(tx.Dialect().InsertIgnore(`
+		insert into reactions (target_type, target_id, user_id, type, created_at)
+		values (?, ?, ?, ?, ?)`), target, targetID, userID, model.Type, time.Now().UTC())
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		change := 1
+		if inserted == 0 {
+			change = -1
+			_, err = tx.Exec(`
+			delete from reactions
+			where target_type = ? and target_id = ? and user_id = ? and type = ?`, target, targetID, userID, model.Type)
+			if err != nil {
+				log.Println(err.Error())
+				return fmt.Errorf("%w: %v", db.ErrInternal, err)
+			}
+		}
+		reacted = change > 0
+		_, err = tx.Exec(tx.Dialect().Upsert(`
+		insert into reaction_counts (target_type, target_id, type, count)
+		values (?, ?, ?, ?)`, []string{"target_type", "target_id", "type"}, "count = reaction_counts.count + excluded.count"),
+			target, targetID, model.Type, change)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		_, err = tx.Exec(fmt.Sprintf(`update %s set reaction_count = reaction_count + ? where id = ?`, table), change, targetID)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	counts, err := Load(service.repository, target, []int{targetID}, userID)
+	if err != nil {
+		return false, nil, err
+	}
+	return reacted, counts[targetID], nil
+}
+
+func (service *ReactionsService) isType(reaction string) bool {
+	for _, known := range service.config.GetReactionTypes() {
+		if known == reaction {
+			return true
+		}
+	}
+	return false
+}
+
+func checkUser(executor db.Executor, userID string) error {
+	var isSuspended bool
+	row := executor.QueryRow(`select is_suspended from users where id = ?`, userID)
+	err := row.Scan(&isSuspended)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: user %s", db.ErrNotFound, userID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	if isSuspended {
+		return fmt.Errorf("%w: the user is suspended", db.ErrForbidden)
+	}
+	return nil
+}
+
+// checkTarget returns an error unless the readers can see the target. Only visible posts and
+// approved comments take reactions.
+func checkTarget(executor db.Executor, target string, targetID int) error {
+	var visible bool
+	var row *sql.Row
+	if target == TargetPost {
+		row = executor.QueryRow(`select not is_hidden from posts where id = ?`, targetID)
+	} else {
+		row = executor.QueryRow(`select status = 'approved' and not is_deleted from comments where id = ?`, targetID)
+	}
+	err := row.Scan(&visible)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !visible) {
+		return fmt.Errorf("%w: %s %d", db.ErrNotFound, target, targetID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// Load returns the reaction counts of the targets, the most used first, and marks the types
+// which the user has reacted with. The user is empty for anonymous readers.
+func Load(executor db.Executor, target string, ids []int, userID string) (map[int][]ReactionModel, error) {
+	reactions := map[int][]ReactionModel{}
+	if len(ids) == 0 {
+		return reactions, nil
+	}
+	args := []interface{}{target}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := executor.Query(fmt.Sprintf(`
+	select target_id, type, count from reaction_counts
+	where target_type = ? and target_id in (%s) and count > 0`, in), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	for rows.Next() {
+		var targetID int
+		reaction := ReactionModel{}
+		err := rows.Scan(&targetID, &reaction.Type, &reaction.Count)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		reactions[targetID] = append(reactions[targetID], reaction)
+	}
+	rows.Close()
+	if len(userID) > 0 {
+		rows, err = executor.Query(fmt.Sprintf(`
+		select target_id, type from reactions
+		where target_type = ? and target_id in (%s) and user_id = ?`, in), append(args, userID)...)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		for rows.Next() {
+			var targetID int
+			var reaction string
+			err := rows.Scan(&targetID, &reaction)
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+			for i := range reactions[targetID] {
+				if reactions[targetID][i].Type == reaction {
+					reactions[targetID][i].Reacted = true
+				}
+			}
+		}
+		rows.Close()
+	}
+	for _, counts := range reactions {
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Type < counts[j].Type
+		})
+	}
+	return reactions, nil
+}
+
+// Remove deletes the reactions of the targets.
+func Remove(executor db.Executor, target string, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := []interface{}{target}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	for _, table := range []string{"reactions", "reaction_counts"} {
+		_, err := executor.Exec(fmt.Sprintf(`delete from %s where target_type = ? and target_id in (%s)`, table, in), args...)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+	}
+	return nil
+}
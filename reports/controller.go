@@ -0,0 +1,113 @@
+package reports
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+type ReportsController struct {
+	*echo.Echo
+	config          *config.Config
+	repository      *db.Repository
+	stores          store.Store
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+	index           *search.Index
+	mailClient      *mail.MailClient
+}
+
+func NewReportsController(echo *echo.Echo, config *config.Config, repository *db.Repository, stores store.Store, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc, index *search.Index, mailClient *mail.MailClient) *ReportsController {
+	return &ReportsController{
+		Echo:            echo,
+		config:          config,
+		repository:      repository,
+		stores:          stores,
+		jwtMiddleware:   jwtMiddleware,
+		adminMiddleware: adminMiddleware,
+		index:           index,
+		mailClient:      mailClient,
+	}
+}
+
+func (controller *ReportsController) UseRoute() {
+	reportsService := NewReportsService(controller.config, controller.repository, controller.stores, controller.index, controller.mailClient)
+	controller.POST("/reports", func(c echo.Context) error {
+		model := new(CreateReportModel)
+		err := c.Bind(model)
+		userID, ok := c.Get("userID").(string)
+		if err != nil || !ok {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		err = reportsService.Create(model, userID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Reporting is failed.",
+			})
+		}
+		return c.JSON(http.StatusCreated, echo.Map{
+			"status":  true,
+			"message": "The report is sent.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.GET("/reports", func(c echo.Context) error {
+		status := c.QueryParam("status")
+		if len(status) == 0 {
+			status = StatusOpen
+		}
+		pageSize, maxPageSize := controller.config.GetPageSize()
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		reports, err := reportsService.Reports(status, db.PageLimit(limit, pageSize, maxPageSize))
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading reports is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"reports": reports,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/reports/:id/resolve", func(c echo.Context) error {
+		model := new(ResolveReportModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		param := c.Param("id")
+		reportID, err := strconv.Atoi(param)
+		if err != nil || reportID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid report id.",
+			})
+		}
+		err = reportsService.Resolve(reportID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Resolving the report is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The report is resolved.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+}
@@ -0,0 +1,53 @@
+package reports
+
+import "time"
+
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+const (
+	ReasonSpam       = "spam"
+	ReasonAbuse      = "abuse"
+	ReasonHarassment = "harassment"
+	ReasonOffTopic   = "off-topic"
+	ReasonOther      = "other"
+)
+
+const (
+	StatusOpen     = "open"
+	StatusResolved = "resolved"
+)
+
+const (
+	ActionDismiss = "dismiss"
+	ActionDelete  = "delete"
+	ActionSuspend = "suspend"
+)
+
+type CreateReportModel struct {
+	TargetType string `json:"targetType"`
+	TargetID   int    `json:"targetId"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message"`
+}
+
+type ResolveReportModel struct {
+	Action string `json:"action"`
+}
+
+type ReportModel struct {
+	ID         int        `json:"id"`
+	TargetType string     `json:"targetType"`
+	TargetID   int        `json:"targetId"`
+	Reason     string     `json:"reason"`
+	Message    string     `json:"message"`
+	Username   string     `json:"username"`
+	Status     string     `json:"status"`
+	Resolution string     `json:"resolution,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	// Reports is how many open reports the target has.
+	Reports int `json:"reports"`
+}
@@ -0,0 +1,216 @@
+package reports
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	cm "github.com/quavious/blog-factory-server/comments"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/posts"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+	"github.com/quavious/blog-factory-server/users"
+)
+
+const maxMessageLength = 1000
+
+type ReportsService struct {
+	config       *config.Config
+	repository   *db.Repository
+	stores       store.Store
+	postsService *posts.PostsService
+	usersService *users.UsersService
+}
+
+func NewReportsService(config *config.Config, repository *db.Repository, stores store.Store, index *search.Index, mailClient *mail.MailClient) *ReportsService {
+	return &ReportsService{
+		config:       config,
+		repository:   repository,
+		stores:       stores,
+		postsService: posts.NewPostsService(config, repository, stores, index, mailClient),
+		usersService: users.NewUsersService(config, repository, stores, mailClient),
+	}
+}
+
+func isReason(reason string) bool {
+	switch reason {
+	case ReasonSpam, ReasonAbuse, ReasonHarassment, ReasonOffTopic, ReasonOther:
+		return true
+	}
+	return false
+}
+
+// Create files a report of the user. A user reports the same target once, and the
+// target is hidden when its open reports reach the threshold. Suspended users cannot report.
+func (service *ReportsService) Create(model *CreateReportModel, userID string) error {
+	if cm.LookupViewer(service.stores.Users(), userID).IsSuspended {
+		return fmt.Errorf("%w: the user is suspended", db.ErrForbidden)
+	}
+	if model.TargetType != TargetPost && model.TargetType != TargetComment {
+		return fmt.Errorf("%w: unknown target type %q", db.ErrInvalid, model.TargetType)
+	}
+	if !isReason(model.Reason) {
+		return fmt.Errorf("%w: unknown reason %q", db.ErrInvalid, model.Reason)
+	}
+	if len(model.Message) > maxMessageLength {
+		return fmt.Errorf("%w: the message is too long", db.ErrInvalid)
+	}
+	_, err := service.author(model.TargetType, model.TargetID)
+	if err != nil {
+		return err
+	}
+	res, err := service.repository.Exec(service.repository.Dialect().InsertIgnore(`
+	insert into reports (target_type, target_id, user_id, reason, message, status, created_at)
+	values (?, ?, ?, ?, ?, ?, ?)`), model.TargetType, model.TargetID, userID, model.Reason, model.Message, StatusOpen, time.Now().UTC())
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	if inserted == 0 {
+		return fmt.Errorf("%w: the user already reported it", db.ErrConflict)
+	}
+
+	var open int
+	row := service.repository.QueryRow(`
+	select count(*) from reports
+	where target_type = ? and target_id = ? and status = ?`, model.TargetType, model.TargetID, StatusOpen)
+	err = row.Scan(&open)
+	if err != nil {
+		log.Println(err.Error())
+		return nil
+	}
+	threshold := service.config.GetReportHideThreshold()
+	if threshold > 0 && open >= threshold {
+		err = service.setHidden(model.TargetType, model.TargetID, true)
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+	return nil
+}
+
+// Reports returns the reports with the status, the oldest first.
+func (service *ReportsService) Reports(status string, limit int) ([]ReportModel, error) {
+	if status != StatusOpen && status != StatusResolved {
+		return nil, fmt.Errorf("%w: unknown status %q", db.ErrInvalid, status)
+	}
+	rows, err := service.repository.Query(`
+	select r.id, r.target_type, r.target_id, r.reason, r.message, u.username, r.status, r.resolution, r.created_at, r.resolved_at,
+		(select count(*) from reports as o where o.target_type = r.target_type and o.target_id = r.target_id and o.status = ?)
+	from reports as r
+	join users as u on r.user_id = u.id
+	where r.status = ?
+	order by r.created_at asc
+	limit ?`, StatusOpen, status, limit)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	reports := []ReportModel{}
+	for rows.Next() {
+		report := new(ReportModel)
+		var resolution sql.NullString
+		var resolvedAt sql.NullTime
+		err := rows.Scan(&report.ID, &report.TargetType, &report.TargetID, &report.Reason, &report.Message, &report.Username, &report.Status, &resolution, &report.CreatedAt, &resolvedAt, &report.Reports)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		report.Resolution = resolution.String
+		if resolvedAt.Valid {
+			report.ResolvedAt = &resolvedAt.Time
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+// Resolve closes every open report of the reported target with the action.
+func (service *ReportsService) Resolve(reportID int, model *ResolveReportModel) error {
+	var targetType string
+	var targetID int
+	row := service.repository.QueryRow(`select target_type, target_id from reports where id = ?`, reportID)
+	err := row.Scan(&targetType, &targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: report %d", db.ErrNotFound, reportID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+
+	switch model.Action {
+	case ActionDismiss:
+		err = service.setHidden(targetType, targetID, false)
+	case ActionDelete:
+		err = service.delete(targetType, targetID)
+	case ActionSuspend:
+		var authorID string
+		authorID, err = service.author(targetType, targetID)
+		if err != nil {
+			break
+		}
+		if !service.usersService.Suspend(authorID) {
+			err = fmt.Errorf("%w: suspending the user is failed", db.ErrInternal)
+			break
+		}
+		err = service.setHidden(targetType, targetID, true)
+	default:
+		err = fmt.Errorf("%w: unknown action %q", db.ErrInvalid, model.Action)
+	}
+	if err != nil && !(model.Action == ActionDelete && errors.Is(err, db.ErrNotFound)) {
+		return err
+	}
+
+	_, err = service.repository.Exec(`
+	update reports set status = ?, resolution = ?, resolved_at = ?
+	where target_type = ? and target_id = ? and status = ?`, StatusResolved, model.Action, time.Now().UTC(), targetType, targetID, StatusOpen)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// author returns the author of the reported target, or an error when it does not exist.
+func (service *ReportsService) author(targetType string, targetID int) (string, error) {
+	if targetType == TargetComment {
+		_, userID, err := cm.Author(service.stores.Comments(), targetID)
+		return userID, err
+	}
+	post, err := service.stores.Posts().ByID(targetID)
+	if err != nil {
+		return "", err
+	}
+	return post.UserID, nil
+}
+
+func (service *ReportsService) setHidden(targetType string, targetID int, hidden bool) error {
+	if targetType == TargetPost {
+		return service.postsService.SetHidden(targetID, hidden)
+	}
+	if hidden {
+		return cm.Hide(service.stores.Comments(), targetID)
+	}
+	return cm.Restore(service.stores.Comments(), targetID)
+}
+
+func (service *ReportsService) delete(targetType string, targetID int) error {
+	if targetType == TargetPost {
+		return service.postsService.Remove(targetID)
+	}
+	return store.Transaction(service.stores, service.repository, func(tx db.Executor, stores store.Store) error {
+		return cm.Remove(tx, stores.Comments(), targetID)
+	})
+}
@@ -0,0 +1,181 @@
+package reports
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/comments"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/migrations"
+	"github.com/quavious/blog-factory-server/search"
+	"github.com/quavious/blog-factory-server/store"
+)
+
+// newTestService returns the service on a new SQLite database with the users alice, bob, carol
+// and the suspended dave, a post of alice and a comment of bob on it. Two reports hide a target.
+func newTestService(t *testing.T) (*ReportsService, store.Store, int, int) {
+	t.Helper()
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "reports.db"))
+	t.Setenv("REPORT_HIDE_THRESHOLD", "2")
+	config := config.FromEnv()
+	repository := db.NewRepository(config)
+	if repository == nil {
+		t.Fatal("the database is not opened")
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := store.NewSQLStore(repository)
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		err := stores.Users().Create(&store.User{ID: name, Email: name + "@example.com", Username: name, IsSuspended: name == "dave"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC()
+	postID, err := stores.Posts().Create(&store.Post{Title: "Post", UserID: "alice", CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commentID, err := stores.Comments().Create(&store.Comment{PostID: postID, UserID: "bob", Content: "Comment", Status: comments.StatusApproved, CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewReportsService(config, repository, stores, search.NewIndex(), nil), stores, postID, commentID
+}
+
+func TestCreate(t *testing.T) {
+	service, _, postID, _ := newTestService(t)
+	tests := []struct {
+		name   string
+		model  CreateReportModel
+		userID string
+		want   error
+	}{
+		{name: "unknown target type", model: CreateReportModel{TargetType: "user", TargetID: 1, Reason: ReasonSpam}, userID: "bob", want: db.ErrInvalid},
+		{name: "unknown reason", model: CreateReportModel{TargetType: TargetPost, TargetID: postID, Reason: "boring"}, userID: "bob", want: db.ErrInvalid},
+		{name: "missing post", model: CreateReportModel{TargetType: TargetPost, TargetID: postID + 100, Reason: ReasonSpam}, userID: "bob", want: db.ErrNotFound},
+		{name: "missing comment", model: CreateReportModel{TargetType: TargetComment, TargetID: 100, Reason: ReasonSpam}, userID: "bob", want: db.ErrNotFound},
+		{name: "suspended user", model: CreateReportModel{TargetType: TargetPost, TargetID: postID, Reason: ReasonSpam}, userID: "dave", want: db.ErrForbidden},
+		{name: "first report", model: CreateReportModel{TargetType: TargetPost, TargetID: postID, Reason: ReasonSpam}, userID: "bob"},
+		{name: "same report again", model: CreateReportModel{TargetType: TargetPost, TargetID: postID, Reason: ReasonAbuse}, userID: "bob", want: db.ErrConflict},
+	}
+	for _, test := range tests {
+		err := service.Create(&test.model, test.userID)
+		if (test.want == nil && err != nil) || !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+	reports, err := service.Reports(StatusOpen, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Username != "bob" || reports[0].Reports != 1 {
+		t.Errorf("got the reports %+v", reports)
+	}
+}
+
+func TestThresholdHidesTargets(t *testing.T) {
+	service, stores, postID, commentID := newTestService(t)
+	report := func(targetType string, targetID int, userID string) {
+		t.Helper()
+		err := service.Create(&CreateReportModel{TargetType: targetType, TargetID: targetID, Reason: ReasonSpam}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	report(TargetPost, postID, "bob")
+	post, err := stores.Posts().ByID(postID)
+	if err != nil || post.IsHidden {
+		t.Fatalf("a report under the threshold hid the post: %+v, %v", post, err)
+	}
+	report(TargetPost, postID, "carol")
+	post, err = stores.Posts().ByID(postID)
+	if err != nil || !post.IsHidden {
+		t.Errorf("the reports at the threshold did not hide the post: %+v, %v", post, err)
+	}
+
+	report(TargetComment, commentID, "alice")
+	report(TargetComment, commentID, "carol")
+	comment, err := stores.Comments().ByID(commentID)
+	if err != nil || comment.Status != comments.StatusHidden {
+		t.Errorf("the reports at the threshold did not hide the comment: %+v, %v", comment, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		action string
+		check  func(t *testing.T, stores store.Store, postID int, commentID int)
+	}{
+		{
+			action: ActionDismiss,
+			check: func(t *testing.T, stores store.Store, postID int, commentID int) {
+				comment, err := stores.Comments().ByID(commentID)
+				if err != nil || comment.Status != comments.StatusApproved {
+					t.Errorf("the dismissed comment is not shown again: %+v, %v", comment, err)
+				}
+			},
+		},
+		{
+			action: ActionDelete,
+			check: func(t *testing.T, stores store.Store, postID int, commentID int) {
+				if _, err := stores.Comments().ByID(commentID); !errors.Is(err, db.ErrNotFound) {
+					t.Errorf("the comment is not deleted: %v", err)
+				}
+			},
+		},
+		{
+			action: ActionSuspend,
+			check: func(t *testing.T, stores store.Store, postID int, commentID int) {
+				user, err := stores.Users().ByID("bob")
+				if err != nil || !user.IsSuspended {
+					t.Errorf("the author is not suspended: %+v, %v", user, err)
+				}
+				comment, err := stores.Comments().ByID(commentID)
+				if err != nil || comment.Status != comments.StatusHidden {
+					t.Errorf("the comment is not hidden: %+v, %v", comment, err)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.action, func(t *testing.T) {
+			service, stores, postID, commentID := newTestService(t)
+			for _, userID := range []string{"alice", "carol"} {
+				err := service.Create(&CreateReportModel{TargetType: TargetComment, TargetID: commentID, Reason: ReasonAbuse}, userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			open, err := service.Reports(StatusOpen, 10)
+			if err != nil || len(open) != 2 {
+				t.Fatalf("got the open reports %+v, %v", open, err)
+			}
+			err = service.Resolve(open[0].ID, &ResolveReportModel{Action: test.action})
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.check(t, stores, postID, commentID)
+			resolved, err := service.Reports(StatusResolved, 10)
+			if err != nil || len(resolved) != 2 || resolved[1].Resolution != test.action {
+				t.Errorf("every report of the comment is not resolved: %+v, %v", resolved, err)
+			}
+		})
+	}
+
+	service, _, _, _ := newTestService(t)
+	if err := service.Resolve(100, &ResolveReportModel{Action: ActionDismiss}); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("a missing report got %v", err)
+	}
+}
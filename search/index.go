@@ -0,0 +1,314 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fieldTitle = iota
+	fieldTags
+	fieldDescription
+	fieldContent
+	fieldCount
+)
+
+var fieldWeights = [fieldCount]float64{3, 2, 1.5, 1}
+
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Document is a post as it is stored in the index.
+type Document struct {
+	ID          int
+	Title       string
+	Description string
+	Content     string
+	Tags        []string
+	Username    string
+	CreatedAt   time.Time
+}
+
+// Filter narrows the matched documents. Zero values are ignored.
+type Filter struct {
+	Tag    string
+	Author string
+	From   time.Time
+	To     time.Time
+}
+
+// Hit is a matched document with its relevance score and a highlighted snippet.
+type Hit struct {
+	ID      int     `json:"id"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+type entry struct {
+	Document
+	fields [fieldCount][]string
+}
+
+// Index is an in-memory inverted index of posts, safe for concurrent use.
+type Index struct {
+	mutex       sync.RWMutex
+	entries     map[int]*entry
+	postings    map[string]map[int]*[fieldCount]int
+	totalLength [fieldCount]int
+	generation  uint64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		entries:  map[int]*entry{},
+		postings: map[string]map[int]*[fieldCount]int{},
+	}
+}
+
+// Put adds the document to the index or replaces the indexed document with the same id.
+func (index *Index) Put(document Document) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	index.generation++
+	index.remove(document.ID)
+	item := &entry{Document: document}
+	item.fields[fieldTitle] = terms(document.Title)
+	item.fields[fieldTags] = terms(strings.Join(document.Tags, " "))
+	item.fields[fieldDescription] = terms(document.Description)
+	item.fields[fieldContent] = terms(document.Content)
+	for field, words := range item.fields {
+		index.totalLength[field] += len(words)
+		for _, word := range words {
+			documents, ok := index.postings[word]
+			if !ok {
+				documents = map[int]*[fieldCount]int{}
+				index.postings[word] = documents
+			}
+			frequency, ok := documents[document.ID]
+			if !ok {
+				frequency = new([fieldCount]int)
+				documents[document.ID] = frequency
+			}
+			frequency[field]++
+		}
+	}
+	index.entries[document.ID] = item
+}
+
+func (index *Index) Remove(id int) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	index.generation++
+	index.remove(id)
+}
+
+// Generation counts the changes of the index, so a cache of the published posts can tell
+// whether they changed since it was made.
+func (index *Index) Generation() uint64 {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return index.generation
+}
+
+func (index *Index) Len() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return len(index.entries)
+}
+
+func (index *Index) remove(id int) {
+	item, ok := index.entries[id]
+	if !ok {
+		return
+	}
+	for field, words := range item.fields {
+		index.totalLength[field] -= len(words)
+		for _, word := range words {
+			documents := index.postings[word]
+			delete(documents, id)
+			if len(documents) == 0 {
+				delete(index.postings, word)
+			}
+		}
+	}
+	delete(index.entries, id)
+}
+
+// Search returns the documents matching every clause of the query, the most relevant first.
+func (index *Index) Search(query string, filter Filter) []Hit {
+	clauses := parseQuery(query)
+	if len(clauses) == 0 {
+		return []Hit{}
+	}
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	var scores map[int]float64
+	highlights := map[string]bool{}
+	for _, clause := range clauses {
+		matched := index.match(clause, highlights)
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for id, score := range scores {
+			other, ok := matched[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] = score + other
+		}
+	}
+
+	hits := []Hit{}
+	for id, score := range scores {
+		item := index.entries[id]
+		if !filter.accepts(item) {
+			continue
+		}
+		hits = append(hits, Hit{
+			ID:      id,
+			Score:   math.Round(score*1000) / 1000,
+			Snippet: snippet(item, highlights),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	return hits
+}
+
+// match scores the documents matching the clause and records the matched terms in highlights.
+func (index *Index) match(clause clause, highlights map[string]bool) map[int]float64 {
+	scores := map[int]float64{}
+	if len(clause.terms) == 1 {
+		for _, term := range index.expand(clause.terms[0], clause.prefix) {
+			highlights[term] = true
+			for id, frequency := range index.postings[term] {
+				scores[id] += index.score(term, id, frequency)
+			}
+		}
+		return scores
+	}
+	last := len(clause.terms) - 1
+	candidates := index.postings[clause.terms[0]]
+	for id := range candidates {
+		item := index.entries[id]
+		if !containsPhrase(item, clause.terms, clause.prefix) {
+			continue
+		}
+		score := 0.0
+		for i, term := range clause.terms {
+			if i == last && clause.prefix {
+				continue
+			}
+			score += index.score(term, id, index.postings[term][id])
+		}
+		scores[id] = score
+	}
+	if len(scores) > 0 {
+		for i, term := range clause.terms {
+			if i == last && clause.prefix {
+				for _, expanded := range index.expand(term, true) {
+					highlights[expanded] = true
+				}
+				continue
+			}
+			highlights[term] = true
+		}
+	}
+	return scores
+}
+
+// expand returns the indexed terms starting with the term, or the term itself.
+func (index *Index) expand(term string, prefix bool) []string {
+	if !prefix {
+		return []string{term}
+	}
+	expanded := []string{}
+	for indexed := range index.postings {
+		if strings.HasPrefix(indexed, term) {
+			expanded = append(expanded, indexed)
+		}
+	}
+	return expanded
+}
+
+// score is the BM25F relevance of the term for the document.
+func (index *Index) score(term string, id int, frequency *[fieldCount]int) float64 {
+	if frequency == nil {
+		return 0
+	}
+	count := float64(len(index.entries))
+	documentFrequency := float64(len(index.postings[term]))
+	idf := math.Log(1 + (count-documentFrequency+0.5)/(documentFrequency+0.5))
+	item := index.entries[id]
+	weighted := 0.0
+	for field := 0; field < fieldCount; field++ {
+		if frequency[field] == 0 {
+			continue
+		}
+		average := float64(index.totalLength[field]) / count
+		length := float64(len(item.fields[field]))
+		normalized := 1 - b
+		if average > 0 {
+			normalized += b * length / average
+		}
+		weighted += fieldWeights[field] * float64(frequency[field]) / normalized
+	}
+	return idf * weighted / (k1 + weighted)
+}
+
+func containsPhrase(item *entry, phrase []string, prefix bool) bool {
+	last := len(phrase) - 1
+	for _, words := range item.fields {
+		for start := 0; start+last < len(words); start++ {
+			matched := true
+			for i, term := range phrase {
+				word := words[start+i]
+				if i == last && prefix {
+					matched = strings.HasPrefix(word, term)
+				} else {
+					matched = word == term
+				}
+				if !matched {
+					break
+				}
+			}
+			if matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (filter Filter) accepts(item *entry) bool {
+	if len(filter.Author) > 0 && item.Username != filter.Author {
+		return false
+	}
+	if !filter.From.IsZero() && item.CreatedAt.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !item.CreatedAt.Before(filter.To) {
+		return false
+	}
+	if len(filter.Tag) == 0 {
+		return true
+	}
+	for _, tag := range item.Tags {
+		if tag == filter.Tag {
+			return true
+		}
+	}
+	return false
+}
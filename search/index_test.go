@@ -0,0 +1,117 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func testIndex() *Index {
+	index := NewIndex()
+	created := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, document := range []Document{
+		{ID: 1, Title: "Go generics", Content: "Type parameters arrive in Go 1.18.", Tags: []string{"go"}, Username: "alice", CreatedAt: created},
+		{ID: 2, Title: "Cooking pasta", Content: "Boil the water, then cook the pasta. Generics are not used in cooking.", Tags: []string{"food"}, Username: "bob", CreatedAt: created.AddDate(0, 1, 0)},
+		{ID: 3, Title: "Concurrency", Description: "Channels and goroutines", Content: "Go makes concurrency simple with goroutines and channels.", Tags: []string{"go"}, Username: "alice", CreatedAt: created.AddDate(0, 2, 0)},
+	} {
+		index.Put(document)
+	}
+	return index
+}
+
+func ids(hits []Hit) []int {
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func equal(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearch(t *testing.T) {
+	index := testIndex()
+	tests := []struct {
+		query  string
+		filter Filter
+		want   []int
+	}{
+		// The title weighs more than the content.
+		{query: "generics", want: []int{1, 2}},
+		{query: "go channels", want: []int{3}},
+		{query: `"cook the pasta"`, want: []int{2}},
+		{query: `"the pasta cook"`, want: []int{}},
+		{query: "gorout*", want: []int{3}},
+		{query: `"with gor*"`, want: []int{3}},
+		{query: "go", filter: Filter{Author: "alice", From: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)}, want: []int{3}},
+		{query: "generics", filter: Filter{Tag: "food"}, want: []int{2}},
+		{query: "rust", want: []int{}},
+		{query: "  ", want: []int{}},
+	}
+	for _, test := range tests {
+		got := ids(index.Search(test.query, test.filter))
+		if !equal(got, test.want) {
+			t.Errorf("%q got %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchRanksShorterFieldsFirst(t *testing.T) {
+	index := NewIndex()
+	index.Put(Document{ID: 1, Content: "search engines rank the documents by many signals, and search is one of them"})
+	index.Put(Document{ID: 2, Content: "search"})
+	hits := index.Search("search", Filter{})
+	if got := ids(hits); !equal(got, []int{2, 1}) {
+		t.Fatalf("got %v", got)
+	}
+	if hits[0].Score <= hits[1].Score {
+		t.Errorf("got the scores %v and %v", hits[0].Score, hits[1].Score)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	index := testIndex()
+	tests := []struct {
+		query  string
+		filter Filter
+		want   string
+	}{
+		{query: "pasta", want: "Boil the water, then cook the <mark>pasta</mark>. Generics are not used in cooking."},
+		{query: "cook*", want: "Boil the water, then <mark>cook</mark> the pasta. Generics are not used in <mark>cooking</mark>."},
+		// The title is used when neither the content nor the description matches.
+		{query: "generics", filter: Filter{Tag: "go"}, want: "Go <mark>generics</mark>"},
+	}
+	for _, test := range tests {
+		hits := index.Search(test.query, test.filter)
+		if len(hits) != 1 || hits[0].Snippet != test.want {
+			t.Errorf("%q got %+v, want %q", test.query, hits, test.want)
+		}
+	}
+
+	index.Put(Document{ID: 4, Title: "Markup", Content: "Use <b> & escape"})
+	hits := index.Search("escape", Filter{})
+	if len(hits) != 1 || hits[0].Snippet != "Use &lt;b&gt; &amp; <mark>escape</mark>" {
+		t.Errorf("got %+v", hits)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	index := testIndex()
+	generation := index.Generation()
+	index.Remove(1)
+	if got := ids(index.Search("generics", Filter{})); !equal(got, []int{2}) {
+		t.Errorf("got %v after the removal", got)
+	}
+	if index.Len() != 2 || index.Generation() == generation {
+		t.Errorf("got %d documents at the generation %d", index.Len(), index.Generation())
+	}
+}
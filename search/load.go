@@ -0,0 +1,101 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// Load indexes every post of the repository. It is called once on startup.
+func (index *Index) Load(repository *db.Repository) error {
+	documents, err := readDocuments(repository, "", nil)
+	if err != nil {
+		return err
+	}
+	for _, document := range documents {
+		index.Put(*document)
+	}
+	log.Printf("search: %d posts are indexed.\n", len(documents))
+	return nil
+}
+
+// Reload indexes the posts again, after a change which is not made by the posts service like
+// renaming their tags. The posts which are hidden or deleted are removed from the index.
+func (index *Index) Reload(executor db.Executor, postIDs []int) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(postIDs)), ", ")
+	documents, err := readDocuments(executor, fmt.Sprintf("p.id in (%s)", placeholders), args)
+	if err != nil {
+		return err
+	}
+	for _, id := range postIDs {
+		if document, ok := documents[id]; ok {
+			index.Put(*document)
+		} else {
+			index.Remove(id)
+		}
+	}
+	return nil
+}
+
+// readDocuments reads the published posts matching the condition with their tags.
+func readDocuments(executor db.Executor, where string, args []interface{}) (map[int]*Document, error) {
+	condition := ""
+	if len(where) > 0 {
+		condition = " and " + where
+	}
+	documents := map[int]*Document{}
+	rows, err := executor.Query(`
+	select p.id, p.title, p.description, p.content, p.created_at, u.username
+	from posts as p
+	join users as u on p.user_id = u.id
+	where not p.is_hidden`+condition, args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		document := new(Document)
+		err := rows.Scan(&document.ID, &document.Title, &document.Description, &document.Content, &document.CreatedAt, &document.Username)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		documents[document.ID] = document
+	}
+	rows.Close()
+
+	tagRows, err := executor.Query(`
+	select pt.post_id, t.tag
+	from posts_and_tags as pt
+	join tags as t on pt.tag_id = t.id
+	join posts as p on pt.post_id = p.id
+	where not p.is_hidden`+condition, args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var postID int
+		var tag string
+		err := tagRows.Scan(&postID, &tag)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if document, ok := documents[postID]; ok {
+			document.Tags = append(document.Tags, tag)
+		}
+	}
+	return documents, nil
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+const snippetLength = 30
+
+// snippet cuts a window of the content around the first matched term and wraps
+// the matched terms with <mark>. The rest of the text is HTML-escaped.
+func snippet(item *entry, highlights map[string]bool) string {
+	text := item.Content
+	tokens := tokenize(text)
+	first := firstMatch(tokens, highlights)
+	for _, fallback := range []string{item.Description, item.Title} {
+		if first >= 0 {
+			break
+		}
+		text = fallback
+		tokens = tokenize(text)
+		first = firstMatch(tokens, highlights)
+	}
+	if len(tokens) == 0 {
+		return ""
+	}
+	if first < 0 {
+		first = 0
+	}
+	start := first - snippetLength/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(tokens) {
+		end = len(tokens)
+	}
+
+	builder := new(strings.Builder)
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	offset := tokens[start].start
+	for _, token := range tokens[start:end] {
+		builder.WriteString(html.EscapeString(text[offset:token.start]))
+		if highlights[token.term] {
+			builder.WriteString("<mark>")
+			builder.WriteString(html.EscapeString(text[token.start:token.end]))
+			builder.WriteString("</mark>")
+		} else {
+			builder.WriteString(html.EscapeString(text[token.start:token.end]))
+		}
+		offset = token.end
+	}
+	if end < len(tokens) {
+		builder.WriteString("…")
+	} else {
+		builder.WriteString(html.EscapeString(text[offset:]))
+	}
+	return builder.String()
+}
+
+func firstMatch(tokens []token, highlights map[string]bool) int {
+	for i, token := range tokens {
+		if highlights[token.term] {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// tokenize splits the text into lower-cased words of letters and digits and keeps their byte offsets.
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+func terms(text string) []string {
+	tokens := tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.term
+	}
+	return terms
+}
+
+type clause struct {
+	terms  []string
+	prefix bool
+}
+
+// parseQuery splits the query into clauses. A quoted text is a phrase and a word ending
+// with * matches every term starting with it. Every clause must match.
+func parseQuery(query string) []clause {
+	clauses := []clause{}
+	for len(query) > 0 {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		if len(query) == 0 {
+			break
+		}
+		if query[0] == '"' {
+			rest := query[1:]
+			end := strings.IndexByte(rest, '"')
+			phrase := rest
+			query = ""
+			if end >= 0 {
+				phrase, query = rest[:end], rest[end+1:]
+			}
+			words := terms(phrase)
+			if len(words) > 0 {
+				prefix := strings.HasSuffix(strings.TrimSpace(phrase), "*")
+				clauses = append(clauses, clause{terms: words, prefix: prefix})
+			}
+			continue
+		}
+		end := strings.IndexFunc(query, unicode.IsSpace)
+		word := query
+		query = ""
+		if end >= 0 {
+			word, query = word[:end], word[end:]
+		}
+		prefix := strings.HasSuffix(word, "*")
+		words := terms(word)
+		if len(words) > 0 {
+			clauses = append(clauses, clause{terms: words, prefix: prefix})
+		}
+	}
+	return clauses
+}
@@ -0,0 +1,67 @@
+package sitemap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/search"
+)
+
+type SitemapController struct {
+	*echo.Echo
+	config     *config.Config
+	repository *db.Repository
+	index      *search.Index
+}
+
+func NewSitemapController(echo *echo.Echo, config *config.Config, repository *db.Repository, index *search.Index) *SitemapController {
+	return &SitemapController{
+		Echo:       echo,
+		config:     config,
+		repository: repository,
+		index:      index,
+	}
+}
+
+func (controller *SitemapController) UseRoute() {
+	sitemapService := NewSitemapService(controller.config, controller.repository, controller.index)
+	serve := func(c echo.Context, page int) error {
+		document, err := sitemapService.Document(page)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the sitemap is failed.",
+			})
+		}
+		return c.Blob(http.StatusOK, "application/xml; charset=UTF-8", document)
+	}
+	controller.GET("/sitemap.xml", func(c echo.Context) error {
+		return serve(c, 0)
+	})
+
+	controller.GET("/sitemaps/:page", func(c echo.Context) error {
+		page, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+		if err != nil || page < 1 {
+			return c.JSON(http.StatusNotFound, &db.BadResponse{
+				Status:  false,
+				Message: "Loading the sitemap is failed.",
+			})
+		}
+		return serve(c, page)
+	})
+
+	controller.GET("/robots.txt", func(c echo.Context) error {
+		robots, err := sitemapService.Robots()
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the robots.txt is failed.",
+			})
+		}
+		return c.String(http.StatusOK, robots)
+	})
+}
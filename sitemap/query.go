@@ -0,0 +1,95 @@
+package sitemap
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mentions"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/tags"
+)
+
+// URLs returns the pages of every published post, of the tags and of the authors of the
+// published posts. A tag or author page was last changed when its latest post was.
+func URLs(executor db.Executor, config *config.Config) ([]URL, error) {
+	urls := []URL{}
+	rows, err := executor.Query(`
+	select id, updated_at from posts
+	where not is_hidden
+	order by id`)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var updatedAt time.Time
+		err := rows.Scan(&id, &updatedAt)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		urls = append(urls, URL{Loc: notifications.Link(config, id, 0), LastMod: updatedAt})
+	}
+	rows.Close()
+
+	pages := []struct {
+		query string
+		link  func(siteURL string, name string) string
+	}{
+		{`
+		select t.tag, p.updated_at
+		from tags as t
+		join posts_and_tags as pt on t.id = pt.tag_id
+		join posts as p on pt.post_id = p.id
+		where not p.is_hidden
+		order by t.tag`, tags.PageURL},
+		{`
+		select u.username, p.updated_at
+		from users as u
+		join posts as p on u.id = p.user_id
+		where not p.is_hidden
+		order by u.username`, mentions.ProfileURL},
+	}
+	for _, page := range pages {
+		pageURLs, err := queryPages(executor, page.query, page.link, config.GetSiteURL())
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, pageURLs...)
+	}
+	return urls, nil
+}
+
+// queryPages links the names of the rows, which are ordered by the names, to their pages.
+// The times are not aggregated by the query since SQLite returns them as text then.
+func queryPages(executor db.Executor, query string, link func(siteURL string, name string) string, siteURL string) ([]URL, error) {
+	rows, err := executor.Query(query)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	urls := []URL{}
+	for rows.Next() {
+		var name string
+		var updatedAt time.Time
+		err := rows.Scan(&name, &updatedAt)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		loc := link(siteURL, name)
+		last := len(urls) - 1
+		if last < 0 || urls[last].Loc != loc {
+			urls = append(urls, URL{Loc: loc, LastMod: updatedAt})
+		} else if updatedAt.After(urls[last].LastMod) {
+			urls[last].LastMod = updatedAt
+		}
+	}
+	return urls, nil
+}
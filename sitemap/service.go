@@ -0,0 +1,80 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/search"
+)
+
+// SitemapService keeps the sitemap built until the published posts change, which it learns from
+// the generation of the search index.
+type SitemapService struct {
+	config     *config.Config
+	repository *db.Repository
+	index      *search.Index
+	size       int
+
+	mutex      sync.Mutex
+	built      bool
+	generation uint64
+	documents  [][]byte
+}
+
+func NewSitemapService(config *config.Config, repository *db.Repository, index *search.Index) *SitemapService {
+	return &SitemapService{
+		config:     config,
+		repository: repository,
+		index:      index,
+		size:       MaxURLs,
+	}
+}
+
+// Document returns the document of the sitemap at the page, where 0 is /sitemap.xml and the
+// others are the pages of a split sitemap.
+func (service *SitemapService) Document(page int) ([]byte, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	generation := service.index.Generation()
+	if !service.built || service.generation != generation {
+		urls, err := URLs(service.repository, service.config)
+		if err != nil {
+			return nil, err
+		}
+		documents, err := Build(urls, service.size, service.config.GetSiteURL())
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		service.built = true
+		service.generation = generation
+		service.documents = documents
+	}
+	if page < 0 || page >= len(service.documents) {
+		return nil, fmt.Errorf("%w: sitemap page %d", db.ErrNotFound, page)
+	}
+	return service.documents[page], nil
+}
+
+// Robots returns the robots.txt of the config, or one which allows every page and points to the
+// sitemap when it has none.
+func (service *SitemapService) Robots() (string, error) {
+	file := service.config.GetRobotsFile()
+	if len(file) == 0 {
+		return fmt.Sprintf("User-agent: *\nDisallow:\n\nSitemap: %s/sitemap.xml\n", service.config.GetSiteURL()), nil
+	}
+	robots, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %v", db.ErrNotFound, err)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return "", fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return string(robots), nil
+}
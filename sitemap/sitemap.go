@@ -0,0 +1,101 @@
+// Package sitemap lists the published pages of the site for the search engines.
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// MaxURLs is the most URLs a sitemap may have. A site with more is split into several sitemaps,
+// which a sitemap index lists.
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is a page of the site and the time it was last changed.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []encodedURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []encodedURL `xml:"sitemap"`
+}
+
+type encodedURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func encodeURLs(urls []URL) []encodedURL {
+	encoded := []encodedURL{}
+	for _, url := range urls {
+		item := encodedURL{Loc: url.Loc}
+		if !url.LastMod.IsZero() {
+			item.LastMod = url.LastMod.UTC().Format(time.RFC3339)
+		}
+		encoded = append(encoded, item)
+	}
+	return encoded
+}
+
+func encode(document interface{}) ([]byte, error) {
+	encoded, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), encoded...), nil
+}
+
+// PagePath returns the path of the page of a split sitemap, counted from 1.
+func PagePath(page int) string {
+	return fmt.Sprintf("/sitemaps/%d.xml", page)
+}
+
+// Build encodes the URLs as the documents of the sitemap. The first is /sitemap.xml, which has
+// every URL when there are no more than size of them. Otherwise it is a sitemap index of the
+// other documents, which have size URLs each and are served at PagePath.
+func Build(urls []URL, size int, siteURL string) ([][]byte, error) {
+	if len(urls) <= size {
+		document, err := encode(&urlSet{Xmlns: namespace, URLs: encodeURLs(urls)})
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{document}, nil
+	}
+	documents := [][]byte{nil}
+	pages := []URL{}
+	for start := 0; start < len(urls); start += size {
+		end := start + size
+		if end > len(urls) {
+			end = len(urls)
+		}
+		document, err := encode(&urlSet{Xmlns: namespace, URLs: encodeURLs(urls[start:end])})
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+		page := URL{Loc: siteURL + PagePath(len(documents)-1)}
+		for _, url := range urls[start:end] {
+			if url.LastMod.After(page.LastMod) {
+				page.LastMod = url.LastMod
+			}
+		}
+		pages = append(pages, page)
+	}
+	index, err := encode(&sitemapIndex{Xmlns: namespace, Sitemaps: encodeURLs(pages)})
+	if err != nil {
+		return nil, err
+	}
+	documents[0] = index
+	return documents, nil
+}
@@ -0,0 +1,80 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testURLSet struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+}
+
+func testURLs(n int) []URL {
+	urls := []URL{}
+	for i := 1; i <= n; i++ {
+		urls = append(urls, URL{
+			Loc:     fmt.Sprintf("https://blog.example.com/posts/%d", i),
+			LastMod: time.Date(2022, 5, i, 9, 0, 0, 0, time.UTC),
+		})
+	}
+	return urls
+}
+
+func TestBuild(t *testing.T) {
+	documents, err := Build(testURLs(3), 3, "https://blog.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 1 {
+		t.Fatalf("got %d documents, want 1", len(documents))
+	}
+	var set testURLSet
+	err = xml.Unmarshal(documents[0], &set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.URLs) != 3 || set.URLs[2].Loc != "https://blog.example.com/posts/3" || set.URLs[2].LastMod != "2022-05-03T09:00:00Z" {
+		t.Errorf("got the urls %+v", set.URLs)
+	}
+}
+
+func TestBuildSplitsIntoIndex(t *testing.T) {
+	documents, err := Build(testURLs(5), 2, "https://blog.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 4 {
+		t.Fatalf("got %d documents, want the index and 3 sitemaps", len(documents))
+	}
+	var index struct {
+		XMLName  xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+		Sitemaps []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"sitemap"`
+	}
+	err = xml.Unmarshal(documents[0], &index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Sitemaps) != 3 {
+		t.Fatalf("got the index %+v", index)
+	}
+	if index.Sitemaps[1].Loc != "https://blog.example.com/sitemaps/2.xml" || index.Sitemaps[1].LastMod != "2022-05-04T09:00:00Z" {
+		t.Errorf("got the sitemap %+v", index.Sitemaps[1])
+	}
+	var last testURLSet
+	err = xml.Unmarshal(documents[3], &last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last.URLs) != 1 || last.URLs[0].Loc != "https://blog.example.com/posts/5" {
+		t.Errorf("got the last sitemap %+v", last.URLs)
+	}
+}
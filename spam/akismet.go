@@ -0,0 +1,78 @@
+package spam
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Akismet checks submissions with an Akismet-compatible HTTP API.
+type Akismet struct {
+	BaseURL string
+	APIKey  string
+	Blog    string
+	Client  *http.Client
+}
+
+func NewAkismet(baseURL string, apiKey string, blog string) *Akismet {
+	return &Akismet{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		APIKey:  apiKey,
+		Blog:    blog,
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (akismet *Akismet) Check(submission *Submission) (*Verdict, error) {
+	body, err := akismet.post("comment-check", submission)
+	if err != nil {
+		return nil, err
+	}
+	switch body {
+	case "true":
+		return &Verdict{Spam: true, Reasons: []string{"akismet"}}, nil
+	case "false":
+		return &Verdict{}, nil
+	default:
+		return nil, fmt.Errorf("error: unexpected akismet response %q", body)
+	}
+}
+
+func (akismet *Akismet) MarkSpam(submission *Submission) error {
+	_, err := akismet.post("submit-spam", submission)
+	return err
+}
+
+func (akismet *Akismet) MarkHam(submission *Submission) error {
+	_, err := akismet.post("submit-ham", submission)
+	return err
+}
+
+func (akismet *Akismet) post(method string, submission *Submission) (string, error) {
+	form := url.Values{}
+	form.Set("api_key", akismet.APIKey)
+	form.Set("blog", akismet.Blog)
+	form.Set("user_ip", submission.IP)
+	form.Set("user_agent", submission.UserAgent)
+	form.Set("permalink", submission.Permalink)
+	form.Set("comment_type", "comment")
+	form.Set("comment_author", submission.Username)
+	form.Set("comment_author_email", submission.Email)
+	form.Set("comment_content", submission.Content)
+	res, err := akismet.Client.PostForm(fmt.Sprintf("%s/1.1/%s", akismet.BaseURL, method), form)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error: akismet responded %d", res.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
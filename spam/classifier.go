@@ -0,0 +1,26 @@
+package spam
+
+import (
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+)
+
+// RateWindow is the period in which Submission.RecentComments is counted.
+const RateWindow = 10 * time.Minute
+
+// NewClassifier builds the heuristics, followed by Akismet when an API key is configured.
+func NewClassifier(config *config.Config) Classifier {
+	maxLinks, blockedWords, rateLimit, minAccountAge := config.GetSpamHeuristics()
+	chain := Chain{&Heuristics{
+		MaxLinks:      maxLinks,
+		BlockedWords:  blockedWords,
+		RateLimit:     rateLimit,
+		MinAccountAge: minAccountAge,
+	}}
+	akismetURL, akismetAPIKey := config.GetAkismet()
+	if len(akismetAPIKey) > 0 {
+		chain = append(chain, NewAkismet(akismetURL, akismetAPIKey, config.GetSiteURL()))
+	}
+	return chain
+}
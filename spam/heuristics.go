@@ -0,0 +1,70 @@
+package spam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Scores of the heuristics. A submission is spam when the sum reaches spamScore,
+// so a new account alone is fine but a new account posting links is not.
+const (
+	spamScore        = 5
+	honeypotScore    = 10
+	blockedScore     = 10
+	blockedWordScore = 5
+	rateScore        = 5
+	linkScore        = 4
+	newAccountScore  = 1
+)
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// Heuristics classifies submissions with simple rules which need no external service.
+type Heuristics struct {
+	MaxLinks      int
+	BlockedWords  []string
+	RateLimit     int
+	MinAccountAge time.Duration
+	now           func() time.Time
+}
+
+func (heuristics *Heuristics) Check(submission *Submission) (*Verdict, error) {
+	if submission.Allowed {
+		return &Verdict{Reasons: []string{"allowed author"}}, nil
+	}
+	score := 0
+	reasons := []string{}
+	add := func(points int, reason string) {
+		score += points
+		reasons = append(reasons, reason)
+	}
+	if len(submission.Honeypot) > 0 {
+		add(honeypotScore, "honeypot field is filled")
+	}
+	if submission.Blocked {
+		add(blockedScore, "blocked author")
+	}
+	content := strings.ToLower(submission.Content)
+	for _, word := range heuristics.BlockedWords {
+		if len(word) > 0 && strings.Contains(content, strings.ToLower(word)) {
+			add(blockedWordScore, fmt.Sprintf("blocked word %q", word))
+			break
+		}
+	}
+	if links := len(linkPattern.FindAllString(submission.Content, -1)); links > heuristics.MaxLinks {
+		add(linkScore, fmt.Sprintf("%d links", links))
+	}
+	if heuristics.RateLimit > 0 && submission.RecentComments >= heuristics.RateLimit {
+		add(rateScore, "posting too fast")
+	}
+	now := time.Now
+	if heuristics.now != nil {
+		now = heuristics.now
+	}
+	if heuristics.MinAccountAge > 0 && now().Sub(submission.AccountCreatedAt) < heuristics.MinAccountAge {
+		add(newAccountScore, "new account")
+	}
+	return &Verdict{Spam: score >= spamScore, Reasons: reasons}, nil
+}
@@ -0,0 +1,74 @@
+package spam
+
+import (
+	"log"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+const (
+	listAllow = "allow"
+	listBlock = "block"
+
+	fieldUser = "user"
+	fieldIP   = "ip"
+)
+
+// Lookup reports whether the author is on the allow list or on the block list.
+func Lookup(executor db.Executor, userID string, ip string) (bool, bool, error) {
+	rows, err := executor.Query(`
+	select kind from spam_lists
+	where (field = ? and value = ?) or (field = ? and value = ?)`, fieldUser, userID, fieldIP, ip)
+	if err != nil {
+		log.Println(err.Error())
+		return false, false, err
+	}
+	defer rows.Close()
+	allowed, blocked := false, false
+	for rows.Next() {
+		var kind string
+		err := rows.Scan(&kind)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		allowed = allowed || kind == listAllow
+		blocked = blocked || kind == listBlock
+	}
+	return allowed, blocked && !allowed, nil
+}
+
+// Allow puts the user on the allow list and takes the user off the block list.
+func Allow(executor db.Executor, userID string) error {
+	_, err := executor.Exec(`delete from spam_lists where kind = ? and field = ? and value = ?`, listBlock, fieldUser, userID)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	return add(executor, listAllow, fieldUser, userID)
+}
+
+// Block puts the user and the address on the block list and takes the user off the allow list.
+func Block(executor db.Executor, userID string, ip string) error {
+	_, err := executor.Exec(`delete from spam_lists where kind = ? and field = ? and value = ?`, listAllow, fieldUser, userID)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	err = add(executor, listBlock, fieldUser, userID)
+	if err != nil || len(ip) == 0 {
+		return err
+	}
+	return add(executor, listBlock, fieldIP, ip)
+}
+
+func add(executor db.Executor, kind string, field string, value string) error {
+	_, err := executor.Exec(executor.Dialect().InsertIgnore(`
+	insert into spam_lists (kind, field, value, created_at)
+	values (?, ?, ?, ?)`), kind, field, value, time.Now().UTC())
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package spam
+
+import (
+	"log"
+	"time"
+)
+
+// Submission is a comment with the facts about its author which the classifiers need.
+type Submission struct {
+	Content   string
+	Honeypot  string
+	Username  string
+	Email     string
+	IP        string
+	UserAgent string
+	// Permalink is the address of the post the comment is written on.
+	Permalink string
+
+	AccountCreatedAt time.Time
+	// RecentComments is how many comments the author wrote in the rate window.
+	RecentComments int
+	Allowed        bool
+	Blocked        bool
+}
+
+type Verdict struct {
+	Spam    bool     `json:"spam"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Classifier decides whether a submission is spam.
+type Classifier interface {
+	Check(submission *Submission) (*Verdict, error)
+}
+
+// Trainer is implemented by the classifiers which learn from the decisions of moderators.
+type Trainer interface {
+	MarkSpam(submission *Submission) error
+	MarkHam(submission *Submission) error
+}
+
+// Chain asks every classifier in order and stops at the first one which finds spam.
+// A classifier which fails is skipped so that an unavailable service does not block comments.
+type Chain []Classifier
+
+func (chain Chain) Check(submission *Submission) (*Verdict, error) {
+	verdict := &Verdict{}
+	for _, classifier := range chain {
+		result, err := classifier.Check(submission)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		verdict.Reasons = append(verdict.Reasons, result.Reasons...)
+		if result.Spam {
+			verdict.Spam = true
+			return verdict, nil
+		}
+	}
+	return verdict, nil
+}
+
+func (chain Chain) MarkSpam(submission *Submission) error {
+	for _, classifier := range chain {
+		if trainer, ok := classifier.(Trainer); ok {
+			err := trainer.MarkSpam(submission)
+			if err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func (chain Chain) MarkHam(submission *Submission) error {
+	for _, classifier := range chain {
+		if trainer, ok := classifier.(Trainer); ok {
+			err := trainer.MarkHam(submission)
+			if err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package spam
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeuristics(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	heuristics := &Heuristics{
+		MaxLinks:      2,
+		BlockedWords:  []string{"casino"},
+		RateLimit:     5,
+		MinAccountAge: 10 * time.Minute,
+		now:           func() time.Time { return now },
+	}
+	old := now.Add(-24 * time.Hour)
+	cases := []struct {
+		name       string
+		submission Submission
+		spam       bool
+	}{
+		{"plain comment", Submission{Content: "Nice post!", AccountCreatedAt: old}, false},
+		{"honeypot", Submission{Content: "Nice post!", Honeypot: "http://spam.example", AccountCreatedAt: old}, true},
+		{"blocked word", Submission{Content: "Best CASINO bonus", AccountCreatedAt: old}, true},
+		{"links", Submission{Content: "http://a.example http://b.example www.c.example", AccountCreatedAt: old}, false},
+		{"links from new account", Submission{Content: "http://a.example http://b.example www.c.example", AccountCreatedAt: now}, true},
+		{"new account", Submission{Content: "Hello", AccountCreatedAt: now}, false},
+		{"rate", Submission{Content: "Hello", RecentComments: 5, AccountCreatedAt: old}, true},
+		{"blocked author", Submission{Content: "Hello", Blocked: true, AccountCreatedAt: old}, true},
+		{"allowed author", Submission{Content: "casino", Allowed: true, AccountCreatedAt: old}, false},
+	}
+	for _, c := range cases {
+		verdict, err := heuristics.Check(&c.submission)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verdict.Spam != c.spam {
+			t.Errorf("%s: expected spam %v, got %v %v", c.name, c.spam, verdict.Spam, verdict.Reasons)
+		}
+	}
+}
+
+func TestAkismet(t *testing.T) {
+	trained := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("api_key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/1.1/comment-check":
+			if strings.Contains(r.Form.Get("comment_content"), "viagra") {
+				w.Write([]byte("true"))
+			} else {
+				w.Write([]byte("false"))
+			}
+		case "/1.1/submit-spam", "/1.1/submit-ham":
+			trained[r.URL.Path] = r.Form.Get("comment_author")
+			w.Write([]byte("Thanks for making the web a better place."))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	akismet := NewAkismet(server.URL, "key", "https://blog.example")
+	verdict, err := akismet.Check(&Submission{Content: "cheap viagra"})
+	if err != nil || !verdict.Spam {
+		t.Errorf("expected spam, got %v %v", verdict, err)
+	}
+	verdict, err = akismet.Check(&Submission{Content: "Thanks for the post"})
+	if err != nil || verdict.Spam {
+		t.Errorf("expected ham, got %v %v", verdict, err)
+	}
+	err = akismet.MarkSpam(&Submission{Username: "bot"})
+	if err != nil || trained["/1.1/submit-spam"] != "bot" {
+		t.Errorf("submit-spam is not sent: %v", err)
+	}
+
+	chain := Chain{NewAkismet(server.URL, "wrong", "")}
+	verdict, err = chain.Check(&Submission{Content: "cheap viagra"})
+	if err != nil || verdict.Spam {
+		t.Errorf("a failing classifier should be skipped, got %v %v", verdict, err)
+	}
+}
@@ -0,0 +1,653 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+type emailToken struct {
+	email     string
+	token     string
+	expiredAt time.Time
+}
+
+// memoryData is the state of a memory store. The records are kept by value, so that
+// the callers never share them with the store.
+type memoryData struct {
+	users       map[string]User
+	posts       map[int]Post
+	comments    map[int]Comment
+	tokens      []emailToken
+	verified    map[string]bool
+	lastPost    int
+	lastComment int
+}
+
+func (data *memoryData) copy() *memoryData {
+	copied := &memoryData{
+		users:       map[string]User{},
+		posts:       map[int]Post{},
+		comments:    map[int]Comment{},
+		tokens:      append([]emailToken{}, data.tokens...),
+		verified:    map[string]bool{},
+		lastPost:    data.lastPost,
+		lastComment: data.lastComment,
+	}
+	for id, user := range data.users {
+		copied.users[id] = user
+	}
+	for id, post := range data.posts {
+		copied.posts[id] = post
+	}
+	for id, comment := range data.comments {
+		copied.comments[id] = comment
+	}
+	for email := range data.verified {
+		copied.verified[email] = true
+	}
+	return copied
+}
+
+type memoryState struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+type memoryStore struct {
+	state *memoryState
+	// inTx is set on the store given to a transaction, which already holds the lock.
+	inTx bool
+}
+
+// NewMemoryStore returns empty stores kept in memory. Transactions run one at a time
+// and hold off every other call until they finish.
+func NewMemoryStore() Store {
+	data := &memoryData{
+		users:    map[string]User{},
+		posts:    map[int]Post{},
+		comments: map[int]Comment{},
+		verified: map[string]bool{},
+	}
+	return &memoryStore{state: &memoryState{data: data}}
+}
+
+func (store *memoryStore) Users() UserStore {
+	return &memoryUsers{store}
+}
+
+func (store *memoryStore) Posts() PostStore {
+	return &memoryPosts{store}
+}
+
+func (store *memoryStore) Comments() CommentStore {
+	return &memoryComments{store}
+}
+
+func (store *memoryStore) Tokens() TokenStore {
+	return &memoryTokens{store}
+}
+
+// Transaction keeps a copy of the state and puts it back when fn fails.
+func (store *memoryStore) Transaction(fn func(tx Store) error) error {
+	if store.inTx {
+		return fn(store)
+	}
+	store.state.mu.Lock()
+	defer store.state.mu.Unlock()
+	saved := store.state.data.copy()
+	committed := false
+	defer func() {
+		if !committed {
+			store.state.data = saved
+		}
+	}()
+	err := fn(&memoryStore{state: store.state, inTx: true})
+	committed = err == nil
+	return err
+}
+
+// with runs fn on the state under the lock, unless the store is in a transaction which holds it.
+func (store *memoryStore) with(fn func(data *memoryData) error) error {
+	if !store.inTx {
+		store.state.mu.Lock()
+		defer store.state.mu.Unlock()
+	}
+	return fn(store.state.data)
+}
+
+type memoryUsers struct {
+	store *memoryStore
+}
+
+func (users *memoryUsers) Create(user *User) error {
+	return users.store.with(func(data *memoryData) error {
+		if _, ok := data.users[user.ID]; ok {
+			return fmt.Errorf("%w: the user already exists", db.ErrConflict)
+		}
+		for _, other := range data.users {
+			if other.Email == user.Email || other.Username == user.Username {
+				return fmt.Errorf("%w: the user already exists", db.ErrConflict)
+			}
+		}
+		created := *user
+		created.CreatedAt = createdAt(user.CreatedAt)
+		data.users[user.ID] = created
+		return nil
+	})
+}
+
+func (users *memoryUsers) ByID(id string) (*User, error) {
+	var found *User
+	err := users.store.with(func(data *memoryData) error {
+		user, ok := data.users[id]
+		if !ok {
+			return fmt.Errorf("%w: user %s", db.ErrNotFound, id)
+		}
+		found = &user
+		return nil
+	})
+	return found, err
+}
+
+func (users *memoryUsers) ByEmail(email string) (*User, error) {
+	var found *User
+	err := users.store.with(func(data *memoryData) error {
+		for _, user := range data.users {
+			if user.Email == email {
+				found = &user
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: user %s", db.ErrNotFound, email)
+	})
+	return found, err
+}
+
+func (users *memoryUsers) ByUsername(username string) (*User, error) {
+	var found *User
+	err := users.store.with(func(data *memoryData) error {
+		for _, user := range data.users {
+			if strings.EqualFold(user.Username, username) {
+				found = &user
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: user %s", db.ErrNotFound, username)
+	})
+	return found, err
+}
+
+func (users *memoryUsers) Usernames(ids []string) (map[string]string, error) {
+	usernames := map[string]string{}
+	err := users.store.with(func(data *memoryData) error {
+		for _, id := range ids {
+			if user, ok := data.users[id]; ok {
+				usernames[id] = user.Username
+			}
+		}
+		return nil
+	})
+	return usernames, err
+}
+
+// update changes the user when it exists.
+func (users *memoryUsers) update(id string, fn func(user *User)) error {
+	return users.store.with(func(data *memoryData) error {
+		user, ok := data.users[id]
+		if ok {
+			fn(&user)
+			data.users[id] = user
+		}
+		return nil
+	})
+}
+
+func (users *memoryUsers) SetPassword(id string, hash string) error {
+	return users.update(id, func(user *User) { user.Password = hash })
+}
+
+func (users *memoryUsers) SetRefreshToken(id string, hash string) error {
+	return users.update(id, func(user *User) { user.RefreshToken = hash })
+}
+
+func (users *memoryUsers) SetAdmin(id string, isAdmin bool) error {
+	return users.update(id, func(user *User) { user.IsAdmin = isAdmin })
+}
+
+func (users *memoryUsers) SetLocale(id string, locale string) error {
+	return users.update(id, func(user *User) { user.Locale = locale })
+}
+
+func (users *memoryUsers) Suspend(id string) error {
+	return users.update(id, func(user *User) { user.IsSuspended = true })
+}
+
+type memoryPosts struct {
+	store *memoryStore
+}
+
+func (posts *memoryPosts) Create(post *Post) (int, error) {
+	var id int
+	err := posts.store.with(func(data *memoryData) error {
+		if _, ok := data.users[post.UserID]; !ok {
+			return fmt.Errorf("%w: the post refers to a missing record", db.ErrNotFound)
+		}
+		data.lastPost++
+		id = data.lastPost
+		created := *post
+		created.ID = id
+		data.posts[id] = created
+		return nil
+	})
+	return id, err
+}
+
+func (posts *memoryPosts) ByID(id int) (*Post, error) {
+	var found *Post
+	err := posts.store.with(func(data *memoryData) error {
+		post, ok := data.posts[id]
+		if !ok {
+			return fmt.Errorf("%w: post %d", db.ErrNotFound, id)
+		}
+		found = &post
+		return nil
+	})
+	return found, err
+}
+
+func (posts *memoryPosts) Update(post *Post) error {
+	return posts.store.with(func(data *memoryData) error {
+		stored, ok := data.posts[post.ID]
+		if !ok {
+			return nil
+		}
+		stored.Title = post.Title
+		stored.Description = post.Description
+		stored.Content = post.Content
+		stored.CommentModeration = post.CommentModeration
+		stored.CommentsDisabled = post.CommentsDisabled
+		stored.CommentsLocked = post.CommentsLocked
+		stored.CommentsCloseDays = post.CommentsCloseDays
+		stored.UpdatedAt = post.UpdatedAt
+		data.posts[post.ID] = stored
+		return nil
+	})
+}
+
+func (posts *memoryPosts) SetHidden(id int, hidden bool) error {
+	return posts.store.with(func(data *memoryData) error {
+		post, ok := data.posts[id]
+		if ok {
+			post.IsHidden = hidden
+			data.posts[id] = post
+		}
+		return nil
+	})
+}
+
+func (posts *memoryPosts) Delete(id int) error {
+	return posts.store.with(func(data *memoryData) error {
+		delete(data.posts, id)
+		return nil
+	})
+}
+
+// matching returns the posts of the query without its page, in no order.
+func (posts *memoryPosts) matching(data *memoryData, query *PostQuery) []Post {
+	ids := map[int]bool{}
+	for _, id := range query.IDs {
+		ids[id] = true
+	}
+	matched := []Post{}
+	for _, post := range data.posts {
+		if post.IsHidden || len(query.Tag) > 0 || (len(ids) > 0 && !ids[post.ID]) || (len(query.UserID) > 0 && post.UserID != query.UserID) {
+			continue
+		}
+		matched = append(matched, post)
+	}
+	return matched
+}
+
+func (posts *memoryPosts) List(query *PostQuery) ([]Post, error) {
+	var list []Post
+	err := posts.store.with(func(data *memoryData) error {
+		// before tells whether a comes before b in the order of the list.
+		before := func(a Key, b Key) bool {
+			if query.Backward {
+				return b.newer(a)
+			}
+			return a.newer(b)
+		}
+		matched := []Post{}
+		for _, post := range posts.matching(data, query) {
+			if query.After == nil || before(*query.After, post.key()) {
+				matched = append(matched, post)
+			}
+		}
+		sort.Slice(matched, func(i, j int) bool {
+			a, b := matched[i], matched[j]
+			if query.Liked {
+				if a.ReactionCount != b.ReactionCount {
+					return a.ReactionCount > b.ReactionCount
+				}
+				return a.key().newer(b.key())
+			}
+			return before(a.key(), b.key())
+		})
+		start, end := pageBounds(len(matched), query.Limit, query.Offset)
+		list = matched[start:end]
+		return nil
+	})
+	return list, err
+}
+
+func (posts *memoryPosts) Count(query *PostQuery) (int, error) {
+	count := 0
+	err := posts.store.with(func(data *memoryData) error {
+		count = len(posts.matching(data, query))
+		return nil
+	})
+	return count, err
+}
+
+type memoryComments struct {
+	store *memoryStore
+}
+
+func (comments *memoryComments) Create(comment *Comment) (int, error) {
+	var id int
+	err := comments.store.with(func(data *memoryData) error {
+		_, hasUser := data.users[comment.UserID]
+		_, hasPost := data.posts[comment.PostID]
+		if !hasUser || !hasPost {
+			return fmt.Errorf("%w: the comment refers to a missing record", db.ErrNotFound)
+		}
+		data.lastComment++
+		id = data.lastComment
+		created := *comment
+		created.ID = id
+		if comment.ParentID != nil {
+			parentID := *comment.ParentID
+			created.ParentID = &parentID
+		}
+		data.comments[id] = created
+		return nil
+	})
+	return id, err
+}
+
+func (comments *memoryComments) ByID(id int) (*Comment, error) {
+	var found *Comment
+	err := comments.store.with(func(data *memoryData) error {
+		comment, ok := data.comments[id]
+		if !ok {
+			return fmt.Errorf("%w: comment %d", db.ErrNotFound, id)
+		}
+		if comment.ParentID != nil {
+			parentID := *comment.ParentID
+			comment.ParentID = &parentID
+		}
+		found = &comment
+		return nil
+	})
+	return found, err
+}
+
+// update changes the comment when it exists.
+func (comments *memoryComments) update(id int, fn func(comment *Comment)) error {
+	return comments.store.with(func(data *memoryData) error {
+		comment, ok := data.comments[id]
+		if ok {
+			fn(&comment)
+			data.comments[id] = comment
+		}
+		return nil
+	})
+}
+
+func (comments *memoryComments) UpdateContent(id int, content string, updatedAt time.Time) error {
+	return comments.update(id, func(comment *Comment) {
+		comment.Content = content
+		comment.UpdatedAt = updatedAt
+	})
+}
+
+func (comments *memoryComments) ChangeStatus(id int, from string, to string) (bool, error) {
+	changed := false
+	err := comments.store.with(func(data *memoryData) error {
+		comment, ok := data.comments[id]
+		if ok && comment.Status == from {
+			comment.Status = to
+			data.comments[id] = comment
+			changed = true
+		}
+		return nil
+	})
+	return changed, err
+}
+
+func (comments *memoryComments) CountReplies(id int) (int, error) {
+	replies := 0
+	err := comments.store.with(func(data *memoryData) error {
+		for _, comment := range data.comments {
+			if comment.ParentID != nil && *comment.ParentID == id {
+				replies++
+			}
+		}
+		return nil
+	})
+	return replies, err
+}
+
+func (comments *memoryComments) MarkDeleted(id int) error {
+	return comments.update(id, func(comment *Comment) {
+		comment.Content = ""
+		comment.Deleted = true
+		comment.ReactionCount = 0
+	})
+}
+
+func (comments *memoryComments) Delete(id int) error {
+	return comments.store.with(func(data *memoryData) error {
+		delete(data.comments, id)
+		return nil
+	})
+}
+
+func (comments *memoryComments) DeleteByPost(postID int) error {
+	return comments.store.with(func(data *memoryData) error {
+		for id, comment := range data.comments {
+			if comment.PostID == postID {
+				delete(data.comments, id)
+			}
+		}
+		return nil
+	})
+}
+
+// matches tells whether the comment is selected by the query regardless of its order and page.
+func (query *CommentQuery) matches(comment Comment, parentIDs map[int]bool) bool {
+	if query.PostID > 0 && comment.PostID != query.PostID {
+		return false
+	}
+	if query.Roots && comment.ParentID != nil {
+		return false
+	}
+	if len(parentIDs) > 0 && (comment.ParentID == nil || !parentIDs[*comment.ParentID]) {
+		return false
+	}
+	if len(query.Statuses) == 0 || contains(query.Statuses, comment.Status) {
+		return true
+	}
+	return len(query.AuthorID) > 0 && comment.UserID == query.AuthorID && contains(query.AuthorStatuses, comment.Status)
+}
+
+func (comments *memoryComments) List(query *CommentQuery) ([]Comment, error) {
+	var list []Comment
+	err := comments.store.with(func(data *memoryData) error {
+		parentIDs := map[int]bool{}
+		for _, id := range query.ParentIDs {
+			parentIDs[id] = true
+		}
+		replies := map[int]int{}
+		for _, comment := range data.comments {
+			if comment.ParentID != nil && comment.Status == query.ReplyStatus && !comment.Deleted {
+				replies[*comment.ParentID]++
+			}
+		}
+		var less func(a Comment, b Comment) bool
+		switch query.Order {
+		case "", OrderOldest:
+			less = func(a Comment, b Comment) bool { return b.key().newer(a.key()) }
+		case OrderLatest:
+			less = func(a Comment, b Comment) bool { return a.key().newer(b.key()) }
+		case OrderReplied:
+			less = func(a Comment, b Comment) bool {
+				if replies[a.ID] != replies[b.ID] {
+					return replies[a.ID] > replies[b.ID]
+				}
+				return b.key().newer(a.key())
+			}
+		case OrderLiked:
+			less = func(a Comment, b Comment) bool {
+				if a.ReactionCount != b.ReactionCount {
+					return a.ReactionCount > b.ReactionCount
+				}
+				return b.key().newer(a.key())
+			}
+		default:
+			return fmt.Errorf("%w: unknown order %q", db.ErrInvalid, query.Order)
+		}
+		matched := []Comment{}
+		for _, comment := range data.comments {
+			if !query.matches(comment, parentIDs) {
+				continue
+			}
+			keyed := query.Order == "" || query.Order == OrderOldest || query.Order == OrderLatest
+			if query.After != nil && keyed && !less(Comment{CreatedAt: query.After.CreatedAt, ID: query.After.ID}, comment) {
+				continue
+			}
+			if comment.ParentID != nil {
+				parentID := *comment.ParentID
+				comment.ParentID = &parentID
+			}
+			matched = append(matched, comment)
+		}
+		sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+		start, end := pageBounds(len(matched), query.Limit, query.Offset)
+		list = matched[start:end]
+		return nil
+	})
+	return list, err
+}
+
+func (comments *memoryComments) CountByPost(postIDs []int, status string) (map[int]int, error) {
+	counts := map[int]int{}
+	err := comments.store.with(func(data *memoryData) error {
+		posts := map[int]bool{}
+		for _, id := range postIDs {
+			posts[id] = true
+		}
+		for _, comment := range data.comments {
+			if posts[comment.PostID] && comment.Status == status && !comment.Deleted {
+				counts[comment.PostID]++
+			}
+		}
+		return nil
+	})
+	return counts, err
+}
+
+func (comments *memoryComments) CountByUser(userID string, status string, since time.Time) (int, error) {
+	count := 0
+	err := comments.store.with(func(data *memoryData) error {
+		for _, comment := range data.comments {
+			if comment.UserID == userID && (len(status) == 0 || comment.Status == status) && comment.CreatedAt.After(since) {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// newer tells whether the key is after the other one, by the creation time and then by the id.
+func (key Key) newer(other Key) bool {
+	return key.CreatedAt.After(other.CreatedAt) || (key.CreatedAt.Equal(other.CreatedAt) && key.ID > other.ID)
+}
+
+func (post Post) key() Key {
+	return Key{CreatedAt: post.CreatedAt, ID: post.ID}
+}
+
+func (comment Comment) key() Key {
+	return Key{CreatedAt: comment.CreatedAt, ID: comment.ID}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// pageBounds returns the bounds of a page in a sorted list of length records. A zero limit
+// keeps every record after the offset.
+func pageBounds(length int, limit int, offset int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+	end := length
+	if limit > 0 && offset+limit < length {
+		end = offset + limit
+	}
+	return offset, end
+}
+
+type memoryTokens struct {
+	store *memoryStore
+}
+
+func (tokens *memoryTokens) CreateEmailToken(email string, token string, expiredAt time.Time) error {
+	return tokens.store.with(func(data *memoryData) error {
+		data.tokens = append(data.tokens, emailToken{email: email, token: token, expiredAt: expiredAt})
+		return nil
+	})
+}
+
+func (tokens *memoryTokens) EmailTokenExpiry(email string, token string) (time.Time, error) {
+	var expiredAt time.Time
+	err := tokens.store.with(func(data *memoryData) error {
+		for i := len(data.tokens) - 1; i >= 0; i-- {
+			if data.tokens[i].email == email && data.tokens[i].token == token {
+				expiredAt = data.tokens[i].expiredAt
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: email token", db.ErrNotFound)
+	})
+	return expiredAt, err
+}
+
+func (tokens *memoryTokens) VerifyEmail(email string) error {
+	return tokens.store.with(func(data *memoryData) error {
+		data.verified[email] = true
+		return nil
+	})
+}
+
+func (tokens *memoryTokens) IsVerified(email string) (bool, error) {
+	verified := false
+	err := tokens.store.with(func(data *memoryData) error {
+		verified = data.verified[email]
+		return nil
+	})
+	return verified, err
+}
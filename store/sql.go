@@ -0,0 +1,626 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/quavious/blog-factory-server/db"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// MySQL error numbers of the violated constraints.
+const (
+	errDuplicateEntry  = 1062
+	errNoReferencedRow = 1452
+)
+
+// PostgreSQL error codes of the violated constraints.
+const (
+	errUniqueViolation     = "23505"
+	errForeignKeyViolation = "23503"
+)
+
+type sqlStore struct {
+	executor db.Executor
+}
+
+// NewSQLStore returns the stores in the database. When the executor is a *db.Repository,
+// Transaction begins a transaction. Otherwise the executor is already a transaction, and
+// the stores take part in it, so that a service can change other tables in the same one.
+func NewSQLStore(executor db.Executor) Store {
+	return &sqlStore{executor: executor}
+}
+
+func (store *sqlStore) Users() UserStore {
+	return &sqlUsers{store.executor}
+}
+
+func (store *sqlStore) Posts() PostStore {
+	return &sqlPosts{store.executor}
+}
+
+func (store *sqlStore) Comments() CommentStore {
+	return &sqlComments{store.executor}
+}
+
+func (store *sqlStore) Tokens() TokenStore {
+	return &sqlTokens{store.executor}
+}
+
+func (store *sqlStore) Transaction(fn func(tx Store) error) error {
+	repository, ok := store.executor.(*db.Repository)
+	if !ok {
+		return fn(store)
+	}
+	return repository.Transaction(func(tx *db.Tx) error {
+		return fn(&sqlStore{executor: tx})
+	})
+}
+
+// internal logs the error of the database and wraps it.
+func internal(err error) error {
+	log.Println(err.Error())
+	return fmt.Errorf("%w: %v", db.ErrInternal, err)
+}
+
+// constraint maps the violated unique and foreign key constraints to the errors of the stores.
+func constraint(err error, what string) error {
+	duplicate, missing := false, false
+	var mysqlErr *mysql.MySQLError
+	var sqliteErr *sqlite.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &mysqlErr):
+		duplicate = mysqlErr.Number == errDuplicateEntry
+		missing = mysqlErr.Number == errNoReferencedRow
+	case errors.As(err, &sqliteErr):
+		duplicate = sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+		missing = sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+	case errors.As(err, &pqErr):
+		duplicate = pqErr.Code == errUniqueViolation
+		missing = pqErr.Code == errForeignKeyViolation
+	}
+	if duplicate {
+		return fmt.Errorf("%w: %s already exists", db.ErrConflict, what)
+	}
+	if missing {
+		return fmt.Errorf("%w: %s refers to a missing record", db.ErrNotFound, what)
+	}
+	return internal(err)
+}
+
+// placeholders returns "?, ?, ..." for an "in" clause of count values.
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// exec runs a statement which changes nothing to be returned.
+func exec(executor db.Executor, query string, args ...interface{}) error {
+	_, err := executor.Exec(query, args...)
+	if err != nil {
+		return internal(err)
+	}
+	return nil
+}
+
+type sqlUsers struct {
+	executor db.Executor
+}
+
+const userColumns = `id, email, username, password, is_admin, is_suspended, hashed_refresh_token, locale, created_at`
+
+func (users *sqlUsers) Create(user *User) error {
+	_, err := users.executor.Exec(`
+	insert into users (id, email, username, password, is_admin, is_suspended, locale, created_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?)`, user.ID, user.Email, user.Username, user.Password, user.IsAdmin, user.IsSuspended, user.Locale, createdAt(user.CreatedAt))
+	if err != nil {
+		return constraint(err, "the user")
+	}
+	return nil
+}
+
+func (users *sqlUsers) ByID(id string) (*User, error) {
+	return users.find("id", id)
+}
+
+func (users *sqlUsers) ByEmail(email string) (*User, error) {
+	return users.find(users.executor.Dialect().Fold("email"), strings.ToLower(email))
+}
+
+func (users *sqlUsers) ByUsername(username string) (*User, error) {
+	return users.find(users.executor.Dialect().Fold("username"), strings.ToLower(username))
+}
+
+func (users *sqlUsers) Usernames(ids []string) (map[string]string, error) {
+	usernames := map[string]string{}
+	if len(ids) == 0 {
+		return usernames, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := users.executor.Query(fmt.Sprintf(`select id, username from users where id in (%s)`, placeholders(len(ids))), args...)
+	if err != nil {
+		return nil, internal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, username string
+		err := rows.Scan(&id, &username)
+		if err != nil {
+			return nil, internal(err)
+		}
+		usernames[id] = username
+	}
+	return usernames, nil
+}
+
+func (users *sqlUsers) find(column string, value string) (*User, error) {
+	user := new(User)
+	var refreshToken sql.NullString
+	row := users.executor.QueryRow(fmt.Sprintf(`select %s from users where %s = ?`, userColumns, column), value)
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.IsAdmin, &user.IsSuspended, &refreshToken, &user.Locale, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: user %s", db.ErrNotFound, value)
+	}
+	if err != nil {
+		return nil, internal(err)
+	}
+	user.RefreshToken = refreshToken.String
+	return user, nil
+}
+
+func (users *sqlUsers) SetPassword(id string, hash string) error {
+	return exec(users.executor, `update users set password = ? where id = ?`, hash, id)
+}
+
+func (users *sqlUsers) SetRefreshToken(id string, hash string) error {
+	return exec(users.executor, `update users set hashed_refresh_token = ? where id = ?`, hash, id)
+}
+
+func (users *sqlUsers) SetAdmin(id string, isAdmin bool) error {
+	return exec(users.executor, `update users set is_admin = ? where id = ?`, isAdmin, id)
+}
+
+func (users *sqlUsers) SetLocale(id string, locale string) error {
+	return exec(users.executor, `update users set locale = ? where id = ?`, locale, id)
+}
+
+func (users *sqlUsers) Suspend(id string) error {
+	return exec(users.executor, `update users set is_suspended = true where id = ?`, id)
+}
+
+type sqlPosts struct {
+	executor db.Executor
+}
+
+const postColumns = `p.id, p.title, p.description, p.content, p.user_id, p.is_hidden, p.comment_moderation,
+	p.comments_disabled, p.comments_locked, p.comments_close_days, p.reaction_count, p.created_at, p.updated_at`
+
+func scanPost(row rowScanner) (*Post, error) {
+	post := new(Post)
+	err := row.Scan(&post.ID, &post.Title, &post.Description, &post.Content, &post.UserID, &post.IsHidden, &post.CommentModeration,
+		&post.CommentsDisabled, &post.CommentsLocked, &post.CommentsCloseDays, &post.ReactionCount, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+func (posts *sqlPosts) Create(post *Post) (int, error) {
+	id, err := db.Insert(posts.executor, `
+	insert into posts (title, description, content, user_id, is_hidden, comment_moderation,
+		comments_disabled, comments_locked, comments_close_days, reaction_count, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, post.Title, post.Description, post.Content, post.UserID, post.IsHidden, post.CommentModeration,
+		post.CommentsDisabled, post.CommentsLocked, post.CommentsCloseDays, post.ReactionCount, post.CreatedAt, post.UpdatedAt)
+	if err != nil {
+		return 0, constraint(err, "the post")
+	}
+	return int(id), nil
+}
+
+func (posts *sqlPosts) ByID(id int) (*Post, error) {
+	post, err := scanPost(posts.executor.QueryRow(fmt.Sprintf(`select %s from posts as p where p.id = ?`, postColumns), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: post %d", db.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, internal(err)
+	}
+	return post, nil
+}
+
+func (posts *sqlPosts) Update(post *Post) error {
+	return exec(posts.executor, `
+	update posts
+	set title = ?, description = ?, content = ?, comment_moderation = ?,
+		comments_disabled = ?, comments_locked = ?, comments_close_days = ?, updated_at = ?
+	where id = ?`, post.Title, post.Description, post.Content, post.CommentModeration,
+		post.CommentsDisabled, post.CommentsLocked, post.CommentsCloseDays, post.UpdatedAt, post.ID)
+}
+
+func (posts *sqlPosts) SetHidden(id int, hidden bool) error {
+	return exec(posts.executor, `update posts set is_hidden = ? where id = ?`, hidden, id)
+}
+
+func (posts *sqlPosts) Delete(id int) error {
+	return exec(posts.executor, `delete from posts where id = ?`, id)
+}
+
+// conditions returns the joins and the conditions of the query without its page.
+func (posts *sqlPosts) conditions(query *PostQuery) (string, []string, []interface{}) {
+	joins := ""
+	where := []string{"not p.is_hidden"}
+	args := []interface{}{}
+	if len(query.IDs) > 0 {
+		where = append(where, fmt.Sprintf("p.id in (%s)", placeholders(len(query.IDs))))
+		for _, id := range query.IDs {
+			args = append(args, id)
+		}
+	}
+	if len(query.Tag) > 0 {
+		joins = `
+	join posts_and_tags as pt on p.id = pt.post_id
+	join tags as t on pt.tag_id = t.id`
+		where = append(where, "t.tag = ?")
+		args = append(args, query.Tag)
+	}
+	if len(query.UserID) > 0 {
+		where = append(where, "p.user_id = ?")
+		args = append(args, query.UserID)
+	}
+	return joins, where, args
+}
+
+func (posts *sqlPosts) List(query *PostQuery) ([]Post, error) {
+	joins, where, args := posts.conditions(query)
+	order := "p.created_at desc, p.id desc"
+	comparison := "<"
+	if query.Backward {
+		order = "p.created_at asc, p.id asc"
+		comparison = ">"
+	}
+	if query.Liked {
+		order = "p.reaction_count desc, p.created_at desc, p.id desc"
+	}
+	if query.After != nil {
+		where = append(where, fmt.Sprintf("(p.created_at %s ? or (p.created_at = ? and p.id %s ?))", comparison, comparison))
+		args = append(args, query.After.CreatedAt, query.After.CreatedAt, query.After.ID)
+	}
+	page := ""
+	if query.Limit > 0 {
+		page = "limit ? offset ?"
+		args = append(args, query.Limit, query.Offset)
+	}
+	rows, err := posts.executor.Query(fmt.Sprintf(`
+	select %s
+	from posts as p %s
+	where %s
+	order by %s
+	%s`, postColumns, joins, strings.Join(where, " and "), order, page), args...)
+	if err != nil {
+		return nil, internal(err)
+	}
+	defer rows.Close()
+	list := []Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, internal(err)
+		}
+		list = append(list, *post)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, internal(err)
+	}
+	return list, nil
+}
+
+func (posts *sqlPosts) Count(query *PostQuery) (int, error) {
+	joins, where, args := posts.conditions(query)
+	var count int
+	row := posts.executor.QueryRow(fmt.Sprintf(`
+	select count(*)
+	from posts as p %s
+	where %s`, joins, strings.Join(where, " and ")), args...)
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, internal(err)
+	}
+	return count, nil
+}
+
+type sqlComments struct {
+	executor db.Executor
+}
+
+func (comments *sqlComments) Create(comment *Comment) (int, error) {
+	id, err := db.Insert(comments.executor, `
+	insert into comments (content, post_id, parent_id, depth, is_deleted, status, ip, user_agent, created_at, updated_at, user_id)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, comment.Content, comment.PostID, comment.ParentID, comment.Depth, comment.Deleted, comment.Status,
+		comment.IP, comment.UserAgent, comment.CreatedAt, comment.UpdatedAt, comment.UserID)
+	if err != nil {
+		return 0, constraint(err, "the comment")
+	}
+	return int(id), nil
+}
+
+const commentColumns = `c.id, c.post_id, c.parent_id, c.user_id, c.content, c.depth, c.is_deleted, c.status,
+	c.ip, c.user_agent, c.reaction_count, c.created_at, c.updated_at`
+
+func scanComment(row rowScanner) (*Comment, error) {
+	comment := new(Comment)
+	var parentID sql.NullInt64
+	var ip, userAgent sql.NullString
+	err := row.Scan(&comment.ID, &comment.PostID, &parentID, &comment.UserID, &comment.Content, &comment.Depth, &comment.Deleted, &comment.Status,
+		&ip, &userAgent, &comment.ReactionCount, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		parent := int(parentID.Int64)
+		comment.ParentID = &parent
+	}
+	comment.IP = ip.String
+	comment.UserAgent = userAgent.String
+	return comment, nil
+}
+
+func (comments *sqlComments) ByID(id int) (*Comment, error) {
+	comment, err := scanComment(comments.executor.QueryRow(fmt.Sprintf(`select %s from comments as c where c.id = ?`, commentColumns), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: comment %d", db.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, internal(err)
+	}
+	return comment, nil
+}
+
+func (comments *sqlComments) UpdateContent(id int, content string, updatedAt time.Time) error {
+	return exec(comments.executor, `update comments set content = ?, updated_at = ? where id = ?`, content, updatedAt, id)
+}
+
+func (comments *sqlComments) ChangeStatus(id int, from string, to string) (bool, error) {
+	res, err := comments.executor.Exec(`update comments set status = ? where id = ? and status = ?`, to, id, from)
+	if err != nil {
+		return false, internal(err)
+	}
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return false, internal(err)
+	}
+	return changed > 0, nil
+}
+
+func (comments *sqlComments) CountReplies(id int) (int, error) {
+	var replies int
+	row := comments.executor.QueryRow(`select count(*) from comments where parent_id = ?`, id)
+	err := row.Scan(&replies)
+	if err != nil {
+		return 0, internal(err)
+	}
+	return replies, nil
+}
+
+func (comments *sqlComments) MarkDeleted(id int) error {
+	return exec(comments.executor, `update comments set content = '', is_deleted = true, reaction_count = 0 where id = ?`, id)
+}
+
+func (comments *sqlComments) Delete(id int) error {
+	return exec(comments.executor, `delete from comments where id = ?`, id)
+}
+
+func (comments *sqlComments) DeleteByPost(postID int) error {
+	return exec(comments.executor, `delete from comments where post_id = ?`, postID)
+}
+
+func (comments *sqlComments) List(query *CommentQuery) ([]Comment, error) {
+	where := []string{}
+	args := []interface{}{}
+	if query.PostID > 0 {
+		where = append(where, "c.post_id = ?")
+		args = append(args, query.PostID)
+	}
+	if query.Roots {
+		where = append(where, "c.parent_id is null")
+	}
+	if len(query.ParentIDs) > 0 {
+		where = append(where, fmt.Sprintf("c.parent_id in (%s)", placeholders(len(query.ParentIDs))))
+		for _, id := range query.ParentIDs {
+			args = append(args, id)
+		}
+	}
+	if len(query.Statuses) > 0 {
+		visible := fmt.Sprintf("c.status in (%s)", placeholders(len(query.Statuses)))
+		for _, status := range query.Statuses {
+			args = append(args, status)
+		}
+		if len(query.AuthorID) > 0 && len(query.AuthorStatuses) > 0 {
+			visible += fmt.Sprintf(" or (c.user_id = ? and c.status in (%s))", placeholders(len(query.AuthorStatuses)))
+			args = append(args, query.AuthorID)
+			for _, status := range query.AuthorStatuses {
+				args = append(args, status)
+			}
+		}
+		where = append(where, "("+visible+")")
+	}
+	order := ""
+	switch query.Order {
+	case "", OrderOldest:
+		order = "c.created_at asc, c.id asc"
+		if query.After != nil {
+			where = append(where, "(c.created_at > ? or (c.created_at = ? and c.id > ?))")
+			args = append(args, query.After.CreatedAt, query.After.CreatedAt, query.After.ID)
+		}
+	case OrderLatest:
+		order = "c.created_at desc, c.id desc"
+		if query.After != nil {
+			where = append(where, "(c.created_at < ? or (c.created_at = ? and c.id < ?))")
+			args = append(args, query.After.CreatedAt, query.After.CreatedAt, query.After.ID)
+		}
+	case OrderReplied:
+		order = "(select count(*) from comments as r where r.parent_id = c.id and r.status = ? and not r.is_deleted) desc, c.created_at asc, c.id asc"
+		args = append(args, query.ReplyStatus)
+	case OrderLiked:
+		order = "c.reaction_count desc, c.created_at asc, c.id asc"
+	default:
+		return nil, fmt.Errorf("%w: unknown order %q", db.ErrInvalid, query.Order)
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = "where " + strings.Join(where, " and ")
+	}
+	page := ""
+	if query.Limit > 0 {
+		page = "limit ? offset ?"
+		args = append(args, query.Limit, query.Offset)
+	}
+	rows, err := comments.executor.Query(fmt.Sprintf(`
+	select %s
+	from comments as c
+	%s
+	order by %s
+	%s`, commentColumns, clause, order, page), args...)
+	if err != nil {
+		return nil, internal(err)
+	}
+	defer rows.Close()
+	list := []Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, internal(err)
+		}
+		list = append(list, *comment)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, internal(err)
+	}
+	return list, nil
+}
+
+func (comments *sqlComments) CountByPost(postIDs []int, status string) (map[int]int, error) {
+	counts := map[int]int{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+	args := []interface{}{}
+	for _, id := range postIDs {
+		args = append(args, id)
+	}
+	rows, err := comments.executor.Query(fmt.Sprintf(`
+	select post_id, count(*)
+	from comments
+	where post_id in (%s) and status = ? and not is_deleted
+	group by post_id`, placeholders(len(postIDs))), append(args, status)...)
+	if err != nil {
+		return nil, internal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID, count int
+		err := rows.Scan(&postID, &count)
+		if err != nil {
+			return nil, internal(err)
+		}
+		counts[postID] = count
+	}
+	return counts, nil
+}
+
+func (comments *sqlComments) CountByUser(userID string, status string, since time.Time) (int, error) {
+	where := []string{"user_id = ?"}
+	args := []interface{}{userID}
+	if len(status) > 0 {
+		where = append(where, "status = ?")
+		args = append(args, status)
+	}
+	if !since.IsZero() {
+		where = append(where, "created_at > ?")
+		args = append(args, since)
+	}
+	var count int
+	row := comments.executor.QueryRow(fmt.Sprintf(`select count(*) from comments where %s`, strings.Join(where, " and ")), args...)
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, internal(err)
+	}
+	return count, nil
+}
+
+type sqlTokens struct {
+	executor db.Executor
+}
+
+func (tokens *sqlTokens) CreateEmailToken(email string, token string, expiredAt time.Time) error {
+	return exec(tokens.executor, `insert into email_tokens (email, token, expired_at) values (?, ?, ?)`, email, token, expiredAt)
+}
+
+func (tokens *sqlTokens) EmailTokenExpiry(email string, token string) (time.Time, error) {
+	var expiredAt time.Time
+	row := tokens.executor.QueryRow(fmt.Sprintf(`
+	select expired_at from email_tokens where %s = ? and token = ?
+	order by id desc limit 1`, tokens.executor.Dialect().Fold("email")), strings.ToLower(email), token)
+	err := row.Scan(&expiredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("%w: email token", db.ErrNotFound)
+	}
+	if err != nil {
+		return time.Time{}, internal(err)
+	}
+	return expiredAt, nil
+}
+
+func (tokens *sqlTokens) VerifyEmail(email string) error {
+	return exec(tokens.executor, tokens.executor.Dialect().InsertIgnore(`insert into verified_emails (email) values (?)`), email)
+}
+
+func (tokens *sqlTokens) IsVerified(email string) (bool, error) {
+	var count int
+	row := tokens.executor.QueryRow(fmt.Sprintf(`select count(*) from verified_emails where %s = ?`, tokens.executor.Dialect().Fold("email")), strings.ToLower(email))
+	err := row.Scan(&count)
+	if err != nil {
+		return false, internal(err)
+	}
+	return count > 0, nil
+}
+
+// createdAt returns the time of a new record, which is now when it is zero.
+func createdAt(at time.Time) time.Time {
+	if at.IsZero() {
+		return time.Now().UTC()
+	}
+	return at
+}
+
+// Transaction runs fn in a transaction of the stores with the executor of the tables which the
+// stores do not keep, for the services which also change those tables. The executor takes part
+// in the transaction when the stores are in the database, and is the fallback otherwise.
+func Transaction(stores Store, fallback db.Executor, fn func(tx db.Executor, stores Store) error) error {
+	return stores.Transaction(func(tx Store) error {
+		return fn(Executor(tx, fallback), tx)
+	})
+}
+
+// Executor returns the executor of the stores in the database, or the fallback for other stores.
+func Executor(stores Store, fallback db.Executor) db.Executor {
+	if sqlStore, ok := stores.(*sqlStore); ok {
+		return sqlStore.executor
+	}
+	return fallback
+}
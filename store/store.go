@@ -0,0 +1,187 @@
+// Package store keeps the users, posts, comments and email tokens behind one interface per domain,
+// so that the services do not depend on the database. NewSQLStore stores them in the database and
+// NewMemoryStore keeps them in memory for the tests.
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+type User struct {
+	ID           string
+	Email        string
+	Username     string
+	Password     string
+	IsAdmin      bool
+	IsSuspended  bool
+	RefreshToken string
+	// Locale is the language of the mails to the user. It is empty for the default one.
+	Locale string
+	// CreatedAt is set to the time of the creation when it is zero.
+	CreatedAt time.Time
+}
+
+type Post struct {
+	ID          int
+	Title       string
+	Description string
+	Content     string
+	UserID      string
+	IsHidden    bool
+	// CommentModeration is null when the post follows the site mode.
+	CommentModeration sql.NullString
+	CommentsDisabled  bool
+	CommentsLocked    bool
+	// CommentsCloseDays is null when the post follows the site setting.
+	CommentsCloseDays sql.NullInt64
+	// ReactionCount is kept by the reactions, and only read by the stores.
+	ReactionCount int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Comment struct {
+	ID        int
+	PostID    int
+	ParentID  *int
+	UserID    string
+	Content   string
+	Depth     int
+	Deleted   bool
+	Status    string
+	IP        string
+	UserAgent string
+	// ReactionCount is kept by the reactions, and only read by the stores.
+	ReactionCount int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Key is the position of a record in a list ordered by the creation time and the id.
+type Key struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// Orders of the comment lists.
+const (
+	OrderOldest = "oldest"
+	OrderLatest = "latest"
+	// OrderReplied puts the comments with the most replies first, and OrderLiked those with
+	// the most reactions. Both put the oldest first among equals.
+	OrderReplied = "replied"
+	OrderLiked   = "liked"
+)
+
+// PostQuery selects the posts of a list. Hidden posts are never listed, and the zero fields
+// do not narrow the list. A zero Limit lists every post.
+type PostQuery struct {
+	IDs []int
+	// Tag is a normalized tag. The memory store keeps no tags, so it lists no posts of a tag.
+	Tag    string
+	UserID string
+	// Liked puts the posts with the most reactions first. Otherwise the latest are first.
+	Liked bool
+	// After keeps the posts after the key, the latest first. With Backward it keeps the posts
+	// before the key, the oldest first.
+	After    *Key
+	Backward bool
+	Limit    int
+	Offset   int
+}
+
+// CommentQuery selects the comments of a list. The zero fields do not narrow the list, and
+// a zero Limit lists every comment.
+type CommentQuery struct {
+	PostID int
+	// Roots keeps the top-level comments, and ParentIDs the replies of the comments.
+	Roots     bool
+	ParentIDs []int
+	// Statuses keeps the comments with one of the statuses, and the comments of AuthorID with
+	// one of AuthorStatuses.
+	Statuses       []string
+	AuthorID       string
+	AuthorStatuses []string
+	// Order is OrderOldest when it is empty. OrderReplied counts the replies with ReplyStatus
+	// which are not deleted.
+	Order       string
+	ReplyStatus string
+	// After keeps the comments after the key in the oldest or the latest order.
+	After  *Key
+	Limit  int
+	Offset int
+}
+
+// UserStore keeps the accounts. Email addresses and usernames are unique, and creating a
+// user with a taken one returns db.ErrConflict. Updating a missing user is not an error.
+type UserStore interface {
+	Create(user *User) error
+	ByID(id string) (*User, error)
+	ByEmail(email string) (*User, error)
+	// ByUsername finds the user regardless of the case of the username.
+	ByUsername(username string) (*User, error)
+	// Usernames returns the usernames of the users by their ids. Missing users are left out.
+	Usernames(ids []string) (map[string]string, error)
+	SetPassword(id string, hash string) error
+	SetRefreshToken(id string, hash string) error
+	SetAdmin(id string, isAdmin bool) error
+	SetLocale(id string, locale string) error
+	Suspend(id string) error
+}
+
+// PostStore keeps the posts. Creating a post of a missing user returns db.ErrNotFound.
+type PostStore interface {
+	Create(post *Post) (int, error)
+	ByID(id int) (*Post, error)
+	// Update writes the content and the comment settings of the post.
+	Update(post *Post) error
+	SetHidden(id int, hidden bool) error
+	Delete(id int) error
+	List(query *PostQuery) ([]Post, error)
+	// Count counts the posts of the query regardless of its page.
+	Count(query *PostQuery) (int, error)
+}
+
+// CommentStore keeps the comments. Creating a comment on a missing post or of a missing user
+// returns db.ErrNotFound.
+type CommentStore interface {
+	Create(comment *Comment) (int, error)
+	ByID(id int) (*Comment, error)
+	UpdateContent(id int, content string, updatedAt time.Time) error
+	// ChangeStatus changes the status of the comment when it has the from status, and reports
+	// whether it had.
+	ChangeStatus(id int, from string, to string) (bool, error)
+	CountReplies(id int) (int, error)
+	// MarkDeleted empties the comment and keeps it as the placeholder of its replies. Its
+	// reactions are removed with it, so it has no reaction count left.
+	MarkDeleted(id int) error
+	Delete(id int) error
+	DeleteByPost(postID int) error
+	List(query *CommentQuery) ([]Comment, error)
+	// CountByPost counts the comments with the status which are not deleted, by their posts.
+	CountByPost(postIDs []int, status string) (map[int]int, error)
+	// CountByUser counts the comments of the user with the status, of every status when it is
+	// empty, which were written after since unless it is zero.
+	CountByUser(userID string, status string, since time.Time) (int, error)
+}
+
+// TokenStore keeps the email verification tokens and the verified addresses.
+type TokenStore interface {
+	CreateEmailToken(email string, token string, expiredAt time.Time) error
+	// EmailTokenExpiry returns when the latest matching token expires.
+	EmailTokenExpiry(email string, token string) (time.Time, error)
+	VerifyEmail(email string) error
+	IsVerified(email string) (bool, error)
+}
+
+// Store gives the stores of every domain. Lookups of missing records return db.ErrNotFound.
+type Store interface {
+	Users() UserStore
+	Posts() PostStore
+	Comments() CommentStore
+	Tokens() TokenStore
+	// Transaction runs fn with stores which commit together when fn returns nil
+	// and roll back otherwise. Inside a transaction it runs fn in the same one.
+	Transaction(fn func(tx Store) error) error
+}
@@ -0,0 +1,698 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/migrations"
+)
+
+// testStores runs the conformance suite which every implementation of Store must pass.
+// open returns empty stores for each test.
+func testStores(t *testing.T, open func(t *testing.T) Store) {
+	tests := map[string]func(t *testing.T, store Store){
+		"Users":             testUsers,
+		"Tokens":            testTokens,
+		"Posts":             testPosts,
+		"Comments":          testComments,
+		"ListPosts":         testListPosts,
+		"ListComments":      testListComments,
+		"TransactionCommit": testTransactionCommit,
+		"TransactionAbort":  testTransactionAbort,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, open(t))
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStores(t, func(t *testing.T) Store {
+		return NewMemoryStore()
+	})
+}
+
+// TestSQLStore runs the suite against a new SQLite database, and against the MySQL and
+// PostgreSQL databases in TEST_DATABASE_DSN and TEST_POSTGRES_DSN when they are set. Every
+// test drops the tables of those databases, so they must not be used for anything else.
+func TestSQLStore(t *testing.T) {
+	t.Run("SQLite", func(t *testing.T) {
+		testStores(t, func(t *testing.T) Store {
+			return openSQLStore(t, db.SQLite, config.SQLiteAddress(filepath.Join(t.TempDir(), "blog.db")))
+		})
+	})
+	t.Run("MySQL", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_DSN")
+		if len(dsn) == 0 {
+			t.Skip("TEST_DATABASE_DSN is not set")
+		}
+		testStores(t, func(t *testing.T) Store {
+			return openSQLStore(t, db.MySQL, dsn)
+		})
+	})
+	t.Run("PostgreSQL", func(t *testing.T) {
+		dsn := os.Getenv("TEST_POSTGRES_DSN")
+		if len(dsn) == 0 {
+			t.Skip("TEST_POSTGRES_DSN is not set")
+		}
+		testStores(t, func(t *testing.T) Store {
+			return openSQLStore(t, db.PostgreSQL, dsn)
+		})
+	})
+}
+
+// openSQLStore migrates the database from scratch and returns its stores.
+func openSQLStore(t *testing.T, dialect db.Dialect, address string) Store {
+	repository, err := db.Open(dialect, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Down(math.MaxInt32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSQLStore(repository)
+}
+
+// now is truncated to the precision of the datetime(6) columns.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func createUser(t *testing.T, store Store, name string) *User {
+	t.Helper()
+	user := &User{
+		ID:        fmt.Sprintf("00000000-0000-0000-0000-%012s", name),
+		Email:     name + "@example.com",
+		Username:  name,
+		Password:  "hash",
+		CreatedAt: now(),
+	}
+	err := store.Users().Create(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createPost(t *testing.T, store Store, userID string) *Post {
+	t.Helper()
+	createdAt := now()
+	post := &Post{
+		Title:       "Title",
+		Description: "Description",
+		Content:     "Content",
+		UserID:      userID,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+	id, err := store.Posts().Create(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.ID = id
+	return post
+}
+
+func createComment(t *testing.T, store Store, postID int, parentID *int, userID string) *Comment {
+	t.Helper()
+	createdAt := now()
+	comment := &Comment{
+		PostID:    postID,
+		ParentID:  parentID,
+		UserID:    userID,
+		Content:   "Comment",
+		Status:    "approved",
+		IP:        "127.0.0.1",
+		UserAgent: "test",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if parentID != nil {
+		comment.Depth = 1
+	}
+	id, err := store.Comments().Create(comment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment.ID = id
+	return comment
+}
+
+func expectError(t *testing.T, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("got error %v, want %v", err, target)
+	}
+}
+
+func testUsers(t *testing.T, store Store) {
+	users := store.Users()
+	user := createUser(t, store, "alice")
+
+	found, err := users.ByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found.CreatedAt.Equal(user.CreatedAt) {
+		t.Fatalf("got created at %v, want %v", found.CreatedAt, user.CreatedAt)
+	}
+	found.CreatedAt = user.CreatedAt
+	if *found != *user {
+		t.Fatalf("got %+v, want %+v", found, user)
+	}
+	found, err = users.ByEmail(user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != user.ID {
+		t.Fatalf("got user %s by email, want %s", found.ID, user.ID)
+	}
+	found, err = users.ByUsername("ALICE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != user.ID {
+		t.Fatalf("got user %s by username, want %s", found.ID, user.ID)
+	}
+	_, err = users.ByID("00000000-0000-0000-0000-000000000000")
+	expectError(t, err, db.ErrNotFound)
+	_, err = users.ByEmail("nobody@example.com")
+	expectError(t, err, db.ErrNotFound)
+	_, err = users.ByUsername("nobody")
+	expectError(t, err, db.ErrNotFound)
+
+	usernames, err := users.Usernames([]string{user.ID, "00000000-0000-0000-0000-000000000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usernames) != 1 || usernames[user.ID] != user.Username {
+		t.Fatalf("got the usernames %v", usernames)
+	}
+	created := &User{ID: "00000000-0000-0000-0000-000000000003", Email: "bob@example.com", Username: "bob", Password: "hash"}
+	err = users.Create(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = users.ByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.CreatedAt.IsZero() {
+		t.Fatal("a user created without the creation time has none")
+	}
+
+	taken := []*User{
+		{ID: "00000000-0000-0000-0000-000000000001", Email: user.Email, Username: "other", Password: "hash"},
+		{ID: "00000000-0000-0000-0000-000000000002", Email: "other@example.com", Username: user.Username, Password: "hash"},
+	}
+	for _, other := range taken {
+		expectError(t, users.Create(other), db.ErrConflict)
+	}
+
+	for _, err := range []error{
+		users.SetPassword(user.ID, "new hash"),
+		users.SetRefreshToken(user.ID, "refresh hash"),
+		users.SetAdmin(user.ID, true),
+		users.SetLocale(user.ID, "ko"),
+		users.Suspend(user.ID),
+		users.Suspend("00000000-0000-0000-0000-000000000000"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, err = users.ByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Password != "new hash" || found.RefreshToken != "refresh hash" || !found.IsAdmin || !found.IsSuspended || found.Locale != "ko" {
+		t.Fatalf("the user is not updated: %+v", found)
+	}
+	found.Username = "changed"
+	again, err := users.ByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Username != user.Username {
+		t.Fatal("a returned user shares its state with the store")
+	}
+}
+
+func testTokens(t *testing.T, store Store) {
+	tokens := store.Tokens()
+	_, err := tokens.EmailTokenExpiry("alice@example.com", "123456")
+	expectError(t, err, db.ErrNotFound)
+
+	first := now().Add(time.Minute)
+	latest := first.Add(time.Minute)
+	for _, expiredAt := range []time.Time{first, latest} {
+		err = tokens.CreateEmailToken("alice@example.com", "123456", expiredAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expiredAt, err := tokens.EmailTokenExpiry("alice@example.com", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiredAt.Equal(latest) {
+		t.Fatalf("got expiry %v, want the latest %v", expiredAt, latest)
+	}
+	_, err = tokens.EmailTokenExpiry("alice@example.com", "654321")
+	expectError(t, err, db.ErrNotFound)
+
+	verified, err := tokens.IsVerified("alice@example.com")
+	if err != nil || verified {
+		t.Fatalf("got verified %v and error %v before the verification", verified, err)
+	}
+	for i := 0; i < 2; i++ {
+		err = tokens.VerifyEmail("alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	verified, err = tokens.IsVerified("alice@example.com")
+	if err != nil || !verified {
+		t.Fatalf("got verified %v and error %v after the verification", verified, err)
+	}
+}
+
+func testPosts(t *testing.T, store Store) {
+	posts := store.Posts()
+	user := createUser(t, store, "alice")
+	post := createPost(t, store, user.ID)
+	second := createPost(t, store, user.ID)
+	if post.ID < 1 || second.ID <= post.ID {
+		t.Fatalf("got post ids %d and %d, want increasing ids", post.ID, second.ID)
+	}
+
+	found, err := posts.ByID(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found.CreatedAt.Equal(post.CreatedAt) {
+		t.Fatalf("got created at %v, want %v", found.CreatedAt, post.CreatedAt)
+	}
+	found.CreatedAt, found.UpdatedAt = post.CreatedAt, post.UpdatedAt
+	if *found != *post {
+		t.Fatalf("got %+v, want %+v", found, post)
+	}
+	_, err = posts.ByID(second.ID + 100)
+	expectError(t, err, db.ErrNotFound)
+	_, err = posts.Create(&Post{Title: "Title", UserID: "00000000-0000-0000-0000-000000000000", CreatedAt: now(), UpdatedAt: now()})
+	expectError(t, err, db.ErrNotFound)
+
+	updated := *found
+	updated.Title = "New title"
+	updated.Description = "New description"
+	updated.Content = "New content"
+	updated.CommentModeration = sql.NullString{String: "all", Valid: true}
+	updated.CommentsDisabled = true
+	updated.CommentsLocked = true
+	updated.CommentsCloseDays = sql.NullInt64{Int64: 7, Valid: true}
+	updated.UpdatedAt = now().Add(time.Second)
+	updated.UserID = "00000000-0000-0000-0000-000000000000"
+	err = posts.Update(&updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = posts.SetHidden(post.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = posts.ByID(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Title != updated.Title || found.Description != updated.Description || found.Content != updated.Content ||
+		found.CommentModeration != updated.CommentModeration || !found.CommentsDisabled || !found.CommentsLocked ||
+		found.CommentsCloseDays != updated.CommentsCloseDays || !found.UpdatedAt.Equal(updated.UpdatedAt) || !found.IsHidden {
+		t.Fatalf("the post is not updated: %+v", found)
+	}
+	if found.UserID != user.ID || !found.CreatedAt.Equal(post.CreatedAt) {
+		t.Fatalf("the update changed the author or the creation time: %+v", found)
+	}
+
+	err = posts.Delete(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = posts.ByID(post.ID)
+	expectError(t, err, db.ErrNotFound)
+	_, err = posts.ByID(second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testComments(t *testing.T, store Store) {
+	comments := store.Comments()
+	user := createUser(t, store, "alice")
+	post := createPost(t, store, user.ID)
+	other := createPost(t, store, user.ID)
+	root := createComment(t, store, post.ID, nil, user.ID)
+	reply := createComment(t, store, post.ID, &root.ID, user.ID)
+	kept := createComment(t, store, other.ID, nil, user.ID)
+
+	found, err := comments.ByID(reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ParentID == nil || *found.ParentID != root.ID {
+		t.Fatalf("got parent %v, want %d", found.ParentID, root.ID)
+	}
+	if !found.CreatedAt.Equal(reply.CreatedAt) {
+		t.Fatalf("got created at %v, want %v", found.CreatedAt, reply.CreatedAt)
+	}
+	found.ParentID, found.CreatedAt, found.UpdatedAt = reply.ParentID, reply.CreatedAt, reply.UpdatedAt
+	if *found != *reply {
+		t.Fatalf("got %+v, want %+v", found, reply)
+	}
+	_, err = comments.ByID(kept.ID + 100)
+	expectError(t, err, db.ErrNotFound)
+	_, err = comments.Create(&Comment{PostID: other.ID + 100, UserID: user.ID, Status: "approved", CreatedAt: now(), UpdatedAt: now()})
+	expectError(t, err, db.ErrNotFound)
+
+	replies, err := comments.CountReplies(root.ID)
+	if err != nil || replies != 1 {
+		t.Fatalf("got %d replies and error %v, want 1", replies, err)
+	}
+	changed, err := comments.ChangeStatus(reply.ID, "pending", "rejected")
+	if err != nil || changed {
+		t.Fatalf("got changed %v and error %v for a comment of another status", changed, err)
+	}
+	changed, err = comments.ChangeStatus(reply.ID, "approved", "hidden")
+	if err != nil || !changed {
+		t.Fatalf("got changed %v and error %v, want the status changed", changed, err)
+	}
+	found, err = comments.ByID(reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Status != "hidden" {
+		t.Fatalf("got status %s, want hidden", found.Status)
+	}
+	updatedAt := now().Add(time.Second)
+	err = comments.UpdateContent(reply.ID, "Edited", updatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = comments.ByID(reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Content != "Edited" || !found.UpdatedAt.Equal(updatedAt) {
+		t.Fatalf("the comment is not updated: %+v", found)
+	}
+
+	err = comments.MarkDeleted(root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = comments.ByID(root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found.Deleted || found.Content != "" {
+		t.Fatalf("the comment is not marked deleted: %+v", found)
+	}
+
+	err = comments.Delete(reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = comments.ByID(reply.ID)
+	expectError(t, err, db.ErrNotFound)
+	replies, err = comments.CountReplies(root.ID)
+	if err != nil || replies != 0 {
+		t.Fatalf("got %d replies and error %v, want 0", replies, err)
+	}
+
+	err = comments.DeleteByPost(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = comments.ByID(root.ID)
+	expectError(t, err, db.ErrNotFound)
+	_, err = comments.ByID(kept.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionCommit(t *testing.T, store Store) {
+	var user *User
+	err := store.Transaction(func(tx Store) error {
+		user = createUser(t, tx, "alice")
+		return tx.Transaction(func(nested Store) error {
+			_, err := nested.Posts().Create(&Post{Title: "Title", UserID: user.ID, CreatedAt: now(), UpdatedAt: now()})
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Users().ByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionAbort(t *testing.T, store Store) {
+	user := createUser(t, store, "alice")
+	failure := errors.New("failure")
+	err := store.Transaction(func(tx Store) error {
+		createUser(t, tx, "bob")
+		err := tx.Users().SetAdmin(user.ID, true)
+		if err != nil {
+			return err
+		}
+		return failure
+	})
+	expectError(t, err, failure)
+	_, err = store.Users().ByEmail("bob@example.com")
+	expectError(t, err, db.ErrNotFound)
+	found, err := store.Users().ByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.IsAdmin {
+		t.Fatal("the change of an aborted transaction is kept")
+	}
+}
+
+// createPostAt creates a visible post of the user written at the time.
+func createPostAt(t *testing.T, store Store, userID string, createdAt time.Time) int {
+	t.Helper()
+	id, err := store.Posts().Create(&Post{Title: "Title", UserID: userID, CreatedAt: createdAt, UpdatedAt: createdAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func postIDs(posts []Post) []int {
+	ids := []int{}
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func commentIDs(comments []Comment) []int {
+	ids := []int{}
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
+
+func expectIDs(t *testing.T, name string, got []int, want ...int) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s: got ids %v, want %v", name, got, want)
+	}
+}
+
+// setReactionCount sets the reaction count of the comment, which the reactions keep outside of the stores.
+func setReactionCount(t *testing.T, store Store, id int, count int) {
+	t.Helper()
+	var err error
+	switch store := store.(type) {
+	case *sqlStore:
+		err = exec(store.executor, `update comments set reaction_count = ? where id = ?`, count, id)
+	case *memoryStore:
+		err = store.Comments().(*memoryComments).update(id, func(comment *Comment) {
+			comment.ReactionCount = count
+		})
+	default:
+		t.Fatalf("unknown store %T", store)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testListPosts(t *testing.T, store Store) {
+	posts := store.Posts()
+	alice := createUser(t, store, "alice")
+	bob := createUser(t, store, "bob")
+	createdAt := now()
+	first := createPostAt(t, store, alice.ID, createdAt)
+	second := createPostAt(t, store, bob.ID, createdAt.Add(time.Second))
+	// third is written at the same time as second, so the id orders them.
+	third := createPostAt(t, store, alice.ID, createdAt.Add(time.Second))
+	hidden := createPostAt(t, store, alice.ID, createdAt.Add(2*time.Second))
+	err := posts.SetHidden(hidden, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query PostQuery
+		want  []int
+	}{
+		{"latest", PostQuery{}, []int{third, second, first}},
+		{"page", PostQuery{Limit: 1, Offset: 1}, []int{second}},
+		{"after", PostQuery{After: &Key{CreatedAt: createdAt.Add(time.Second), ID: third}}, []int{second, first}},
+		{"backward", PostQuery{After: &Key{CreatedAt: createdAt, ID: first}, Backward: true, Limit: 1}, []int{second}},
+		{"ids", PostQuery{IDs: []int{first, hidden, third}}, []int{third, first}},
+		{"author", PostQuery{UserID: bob.ID}, []int{second}},
+	}
+	for _, test := range tests {
+		list, err := posts.List(&test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectIDs(t, test.name, postIDs(list), test.want...)
+	}
+	count, err := posts.Count(&PostQuery{UserID: alice.ID, Limit: 1})
+	if err != nil || count != 2 {
+		t.Fatalf("got %d posts and error %v, want 2", count, err)
+	}
+}
+
+func testListComments(t *testing.T, store Store) {
+	comments := store.Comments()
+	alice := createUser(t, store, "alice")
+	bob := createUser(t, store, "bob")
+	post := createPost(t, store, alice.ID)
+	other := createPost(t, store, alice.ID)
+	createdAt := now()
+	create := func(postID int, parentID *int, userID string, status string, at time.Duration) int {
+		t.Helper()
+		id, err := comments.Create(&Comment{
+			PostID:    postID,
+			ParentID:  parentID,
+			UserID:    userID,
+			Content:   "Comment",
+			Status:    status,
+			CreatedAt: createdAt.Add(at),
+			UpdatedAt: createdAt.Add(at),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	first := create(post.ID, nil, alice.ID, "approved", 0)
+	second := create(post.ID, nil, bob.ID, "approved", time.Second)
+	pending := create(post.ID, nil, bob.ID, "pending", 2*time.Second)
+	reply := create(post.ID, &second, alice.ID, "approved", 3*time.Second)
+	deleted := create(post.ID, &second, alice.ID, "approved", 4*time.Second)
+	err := comments.MarkDeleted(deleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere := create(other.ID, nil, bob.ID, "approved", 5*time.Second)
+
+	approved := []string{"approved"}
+	tests := []struct {
+		name  string
+		query CommentQuery
+		want  []int
+	}{
+		{"oldest", CommentQuery{PostID: post.ID, Roots: true}, []int{first, second, pending}},
+		{"latest", CommentQuery{PostID: post.ID, Roots: true, Order: OrderLatest}, []int{pending, second, first}},
+		{"after", CommentQuery{PostID: post.ID, Roots: true, After: &Key{CreatedAt: createdAt, ID: first}}, []int{second, pending}},
+		{"page", CommentQuery{PostID: post.ID, Roots: true, Limit: 1, Offset: 1}, []int{second}},
+		{"visible", CommentQuery{PostID: post.ID, Roots: true, Statuses: approved}, []int{first, second}},
+		{"own", CommentQuery{PostID: post.ID, Roots: true, Statuses: approved, AuthorID: bob.ID, AuthorStatuses: []string{"pending"}}, []int{first, second, pending}},
+		{"others", CommentQuery{PostID: post.ID, Roots: true, Statuses: approved, AuthorID: alice.ID, AuthorStatuses: []string{"pending"}}, []int{first, second}},
+		{"replies", CommentQuery{ParentIDs: []int{first, second}}, []int{reply, deleted}},
+		{"replied", CommentQuery{PostID: post.ID, Roots: true, Order: OrderReplied, ReplyStatus: "approved"}, []int{second, first, pending}},
+		{"status", CommentQuery{Statuses: []string{"pending"}}, []int{pending}},
+		{"every post", CommentQuery{Roots: true, Order: OrderLatest, Limit: 2}, []int{elsewhere, pending}},
+	}
+	for _, test := range tests {
+		list, err := comments.List(&test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectIDs(t, test.name, commentIDs(list), test.want...)
+	}
+	_, err = comments.List(&CommentQuery{Order: "unknown"})
+	expectError(t, err, db.ErrInvalid)
+
+	counts, err := comments.CountByPost([]int{post.ID, other.ID}, "approved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[post.ID] != 3 || counts[other.ID] != 1 {
+		t.Errorf("got the counts %v, want 3 and 1", counts)
+	}
+	for _, test := range []struct {
+		status string
+		since  time.Time
+		want   int
+	}{
+		{"", time.Time{}, 3},
+		{"approved", time.Time{}, 2},
+		{"", createdAt.Add(3 * time.Second), 1},
+	} {
+		count, err := comments.CountByUser(bob.ID, test.status, test.since)
+		if err != nil || count != test.want {
+			t.Errorf("got %d comments of status %q since %v and error %v, want %d", count, test.status, test.since, err, test.want)
+		}
+	}
+
+	// A liked comment which is deleted with replies left ranks as a comment without reactions.
+	setReactionCount(t, store, first, 1)
+	setReactionCount(t, store, second, 3)
+	liked := &CommentQuery{PostID: post.ID, Roots: true, Order: OrderLiked}
+	list, err := comments.List(liked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "liked", commentIDs(list), second, first, pending)
+	err = comments.MarkDeleted(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err = comments.List(liked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "liked after the delete", commentIDs(list), first, second, pending)
+}
@@ -0,0 +1,145 @@
+package tags
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/search"
+)
+
+type TagsController struct {
+	*echo.Echo
+	config          *config.Config
+	repository      *db.Repository
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+	index           *search.Index
+}
+
+func NewTagsController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc, index *search.Index) *TagsController {
+	return &TagsController{
+		Echo:            echo,
+		config:          config,
+		repository:      repository,
+		jwtMiddleware:   jwtMiddleware,
+		adminMiddleware: adminMiddleware,
+		index:           index,
+	}
+}
+
+func (controller *TagsController) UseRoute() {
+	tagsService := NewTagsService(controller.config, controller.repository, controller.index)
+	controller.GET("/tags", func(c echo.Context) error {
+		tags, err := tagsService.Tags()
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading tags is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": true,
+			"tags":   tags,
+		})
+	})
+
+	controller.PUT("/tags/:id", func(c echo.Context) error {
+		model := new(RenameTagModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		param := c.Param("id")
+		tagID, err := strconv.Atoi(param)
+		if err != nil || tagID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid tag id.",
+			})
+		}
+		err = tagsService.Rename(tagID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Renaming the tag is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The tag is renamed.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/tags/:id/merge", func(c echo.Context) error {
+		model := new(MergeTagModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		param := c.Param("id")
+		tagID, err := strconv.Atoi(param)
+		if err != nil || tagID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid tag id.",
+			})
+		}
+		err = tagsService.Merge(tagID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Merging the tags is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The tags are merged.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.DELETE("/tags/unused", func(c echo.Context) error {
+		deleted, err := tagsService.CleanUp()
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Cleaning up tags is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"deleted": deleted,
+			"message": "Unused tags are deleted.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.DELETE("/tags/:id", func(c echo.Context) error {
+		param := c.Param("id")
+		tagID, err := strconv.Atoi(param)
+		if err != nil || tagID < 1 {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid tag id.",
+			})
+		}
+		err = tagsService.Delete(tagID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Deleting the tag is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The tag is deleted.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+}
@@ -0,0 +1,15 @@
+package tags
+
+type TagModel struct {
+	ID        int    `json:"id"`
+	Tag       string `json:"tag"`
+	PostCount int    `json:"postCount"`
+}
+
+type RenameTagModel struct {
+	Tag string `json:"tag"`
+}
+
+type MergeTagModel struct {
+	TargetID int `json:"targetId"`
+}
@@ -0,0 +1,34 @@
+package tags
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Normalize trims and case-folds a tag. It returns false when the tag is empty
+// or longer than maxLength characters.
+func Normalize(tag string, maxLength int) (string, bool) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if len(normalized) == 0 {
+		return "", false
+	}
+	if maxLength > 0 && utf8.RuneCountInString(normalized) > maxLength {
+		return "", false
+	}
+	return normalized, true
+}
+
+// NormalizeAll normalizes every tag and drops invalid ones and duplicates.
+func NormalizeAll(tags []string, maxLength int) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		value, ok := Normalize(tag, maxLength)
+		if !ok || seen[value] {
+			continue
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	return normalized
+}
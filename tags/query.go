@@ -0,0 +1,119 @@
+package tags
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/quavious/blog-factory-server/db"
+)
+
+// Resolve normalizes the tag names and returns their ids, inserting the new tags.
+func Resolve(executor db.Executor, names []string, maxLength int) ([]int, error) {
+	tagIDs := []int{}
+	for _, tag := range NormalizeAll(names, maxLength) {
+		_, err := executor.Exec(executor.Dialect().InsertIgnore(`insert into tags (tag) values (?)`), tag)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		var tagID int
+		row := executor.QueryRow(`select id from tags where tag = ?`, tag)
+		err = row.Scan(&tagID)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+	return tagIDs, nil
+}
+
+// Link attaches the tags to the post. Tags already attached are ignored.
+func Link(executor db.Executor, postID int, tagIDs []int) error {
+	for _, tagID := range tagIDs {
+		_, err := executor.Exec(executor.Dialect().InsertIgnore(`insert into posts_and_tags (post_id, tag_id) values (?, ?)`), postID, tagID)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+	}
+	return nil
+}
+
+// Unlink detaches every tag from the post.
+func Unlink(executor db.Executor, postID int) error {
+	_, err := executor.Exec(`delete from posts_and_tags where post_id = ?`, postID)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// DeleteUnused removes the tags which no post refers to and returns how many were removed.
+func DeleteUnused(executor db.Executor) (int64, error) {
+	res, err := executor.Exec(`
+	delete from tags
+	where not exists (select 1 from posts_and_tags as pt where pt.tag_id = tags.id)`)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return deleted, nil
+}
+
+// Load returns the tags of the posts by their ids, each sorted by name.
+func Load(executor db.Executor, postIDs []int) (map[int][]string, error) {
+	tags := map[int][]string{}
+	if len(postIDs) == 0 {
+		return tags, nil
+	}
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+	rows, err := executor.Query(fmt.Sprintf(`
+	select pt.post_id, t.tag
+	from posts_and_tags as pt
+	join tags as t on pt.tag_id = t.id
+	where pt.post_id in (%s)
+	order by t.tag asc`, strings.TrimSuffix(strings.Repeat("?, ", len(postIDs)), ", ")), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID int
+		var tag string
+		err := rows.Scan(&postID, &tag)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		tags[postID] = append(tags[postID], tag)
+	}
+	return tags, nil
+}
+
+// Find returns the id of the normalized tag.
+func Find(executor db.Executor, tag string) (int, error) {
+	var id int
+	err := executor.QueryRow(`select id from tags where tag = ?`, tag).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: tag %s", db.ErrNotFound, tag)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,195 @@
+package tags
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/search"
+)
+
+type TagsService struct {
+	config     *config.Config
+	repository *db.Repository
+	index      *search.Index
+}
+
+func NewTagsService(config *config.Config, repository *db.Repository, index *search.Index) *TagsService {
+	return &TagsService{
+		config:     config,
+		repository: repository,
+		index:      index,
+	}
+}
+
+func (service *TagsService) Tags() ([]TagModel, error) {
+	rows, err := service.repository.Query(`
+	select t.id, t.tag, count(pt.post_id)
+	from tags as t
+	left join posts_and_tags as pt on pt.tag_id = t.id
+	group by t.id, t.tag
+	order by count(pt.post_id) desc, t.tag asc`)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	tags := []TagModel{}
+	for rows.Next() {
+		tag := new(TagModel)
+		err := rows.Scan(&tag.ID, &tag.Tag, &tag.PostCount)
+		if err != nil {
+			log.Println(err.Error())
+		} else {
+			tags = append(tags, *tag)
+		}
+	}
+	return tags, nil
+}
+
+func (service *TagsService) Rename(tagID int, model *RenameTagModel) error {
+	tag, ok := Normalize(model.Tag, service.config.GetTagMaxLength())
+	if !ok {
+		return fmt.Errorf("%w: invalid tag name", db.ErrInvalid)
+	}
+	var postIDs []int
+	err := service.repository.Transaction(func(tx *db.Tx) error {
+		err := findTag(tx, tagID)
+		if err != nil {
+			return err
+		}
+		postIDs, err = taggedPosts(tx, tagID)
+		if err != nil {
+			return err
+		}
+		var existingID int
+		row := tx.QueryRow(`select id from tags where tag = ?`, tag)
+		err = row.Scan(&existingID)
+		if err == nil && existingID != tagID {
+			return fmt.Errorf("%w: the tag already exists, merge the tags instead", db.ErrConflict)
+		}
+		_, err = tx.Exec(`update tags set tag = ? where id = ?`, tag, tagID)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return nil
+	})
+	return service.reindex(postIDs, err)
+}
+
+// Merge moves every post of the source tag to the target tag and removes the source tag.
+func (service *TagsService) Merge(sourceID int, model *MergeTagModel) error {
+	if sourceID == model.TargetID {
+		return fmt.Errorf("%w: a tag cannot be merged into itself", db.ErrInvalid)
+	}
+	var postIDs []int
+	err := service.repository.Transaction(func(tx *db.Tx) error {
+		err := findTag(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		err = findTag(tx, model.TargetID)
+		if err != nil {
+			return err
+		}
+		postIDs, err = taggedPosts(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(tx.Dialect().InsertIgnore(`
+		insert into posts_and_tags (post_id, tag_id)
+		select post_id, ? from posts_and_tags where tag_id = ?`), model.TargetID, sourceID)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		return deleteTag(tx, sourceID)
+	})
+	return service.reindex(postIDs, err)
+}
+
+func (service *TagsService) Delete(tagID int) error {
+	var postIDs []int
+	err := service.repository.Transaction(func(tx *db.Tx) error {
+		err := findTag(tx, tagID)
+		if err != nil {
+			return err
+		}
+		postIDs, err = taggedPosts(tx, tagID)
+		if err != nil {
+			return err
+		}
+		return deleteTag(tx, tagID)
+	})
+	return service.reindex(postIDs, err)
+}
+
+// CleanUp removes the tags which no post refers to and returns how many were removed.
+func (service *TagsService) CleanUp() (int64, error) {
+	return DeleteUnused(service.repository)
+}
+
+func findTag(executor db.Executor, tagID int) error {
+	var id int
+	row := executor.QueryRow(`select id from tags where id = ?`, tagID)
+	err := row.Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: tag %d", db.ErrNotFound, tagID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// taggedPosts returns the ids of the posts with the tag.
+func taggedPosts(executor db.Executor, tagID int) ([]int, error) {
+	rows, err := executor.Query(`select post_id from posts_and_tags where tag_id = ?`, tagID)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	postIDs := []int{}
+	for rows.Next() {
+		var postID int
+		err := rows.Scan(&postID)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		postIDs = append(postIDs, postID)
+	}
+	return postIDs, nil
+}
+
+func deleteTag(executor db.Executor, tagID int) error {
+	_, err := executor.Exec(`delete from posts_and_tags where tag_id = ?`, tagID)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	_, err = executor.Exec(`delete from tags where id = ?`, tagID)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return nil
+}
+
+// reindex reloads the posts whose tags changed into the search index.
+func (service *TagsService) reindex(postIDs []int, err error) error {
+	if err != nil {
+		return err
+	}
+	err = service.index.Reload(service.repository, postIDs)
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return nil
+}
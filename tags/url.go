@@ -0,0 +1,11 @@
+package tags
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// PageURL returns the address of the page of the posts with the tag on the site.
+func PageURL(siteURL string, tag string) string {
+	return fmt.Sprintf("%s/tags/%s", siteURL, url.PathEscape(tag))
+}
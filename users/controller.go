@@ -6,21 +6,26 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/store"
 )
 
 type UsersController struct {
 	*echo.Echo
-	config        *config.Config
-	repository    *db.Repository
-	jwtMiddleware *echo.MiddlewareFunc
+	config          *config.Config
+	repository      *db.Repository
+	stores          store.Store
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+	mailClient      *mail.MailClient
 }
 
-func NewUsersController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc) *UsersController {
-	return &UsersController{Echo: echo, config: config, repository: repository, jwtMiddleware: jwtMiddleware}
+func NewUsersController(echo *echo.Echo, config *config.Config, repository *db.Repository, stores store.Store, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc, mailClient *mail.MailClient) *UsersController {
+	return &UsersController{Echo: echo, config: config, repository: repository, stores: stores, jwtMiddleware: jwtMiddleware, adminMiddleware: adminMiddleware, mailClient: mailClient}
 }
 
 func (controller *UsersController) UseRoute() {
-	userService := NewUsersService(controller.config, controller.repository)
+	userService := NewUsersService(controller.config, controller.repository, controller.stores, controller.mailClient)
 	controller.GET("/users/account", func(c echo.Context) error {
 		userID := c.Get("userID").(string)
 		account := userService.GetAccount(userID)
@@ -35,4 +40,50 @@ func (controller *UsersController) UseRoute() {
 			"account": account,
 		})
 	}, *controller.jwtMiddleware)
+
+	controller.PUT("/users/locale", func(c echo.Context) error {
+		model := new(LocaleModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		userID := c.Get("userID").(string)
+		err = userService.SetLocale(userID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Changing the locale is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The locale is changed.",
+		})
+	}, *controller.jwtMiddleware)
+
+	controller.PUT("/users/:id/role", func(c echo.Context) error {
+		model := new(RoleModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		adminID := c.Get("userID").(string)
+		err = userService.SetRole(c.Param("id"), model, adminID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Changing the role is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The role is changed.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
 }
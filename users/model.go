@@ -1,17 +1,21 @@
 package users
 
-type User struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	Password     string `json:"password"`
-	Username     string `json:"username"`
-	IsAdmin      bool   `json:"isAdmin"`
-	RefreshToken string `json:"refreshToken"`
-}
+import "github.com/quavious/blog-factory-server/store"
+
+type User = store.User
 
 type UserAccount struct {
 	ID       string `json:"id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
 	IsAdmin  bool   `json:"isAdmin"`
+	Locale   string `json:"locale"`
+}
+
+type RoleModel struct {
+	IsAdmin bool `json:"isAdmin"`
+}
+
+type LocaleModel struct {
+	Locale string `json:"locale"`
 }
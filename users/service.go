@@ -1,31 +1,87 @@
 package users
 
 import (
-	"log"
+	"fmt"
 
 	"github.com/quavious/blog-factory-server/config"
 	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/mail"
+	"github.com/quavious/blog-factory-server/notifications"
+	"github.com/quavious/blog-factory-server/store"
 )
 
 type UsersService struct {
-	config     *config.Config
-	repository *db.Repository
+	config        *config.Config
+	users         store.UserStore
+	notifications *notifications.NotificationsService
 }
 
-func NewUsersService(config *config.Config, repository *db.Repository) *UsersService {
+func NewUsersService(config *config.Config, repository *db.Repository, stores store.Store, mailClient *mail.MailClient) *UsersService {
 	return &UsersService{
-		config:     config,
-		repository: repository,
+		config:        config,
+		users:         stores.Users(),
+		notifications: notifications.NewNotificationsService(config, repository, mailClient),
 	}
 }
 
 func (service *UsersService) GetAccount(userID string) *UserAccount {
-	model := new(UserAccount)
-	row := service.repository.QueryRow("select id, email, username, is_admin from users where id = ?", userID)
-	err := row.Scan(&model.ID, &model.Email, &model.Username, &model.IsAdmin)
+	user, err := service.users.ByID(userID)
 	if err != nil {
-		log.Println("error: no users with this id.")
 		return nil
 	}
-	return model
+	return &UserAccount{
+		ID:       user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+		IsAdmin:  user.IsAdmin,
+		Locale:   user.Locale,
+	}
+}
+
+// SetLocale changes the language of the mails to the user. An empty locale is the default one.
+func (service *UsersService) SetLocale(userID string, model *LocaleModel) error {
+	locale := ""
+	if len(model.Locale) > 0 {
+		locales := mail.ParseLocales(model.Locale)
+		if len(locales) != 1 || len(locales[0]) > 16 {
+			return fmt.Errorf("%w: invalid locale", db.ErrInvalid)
+		}
+		locale = locales[0]
+	}
+	_, err := service.users.ByID(userID)
+	if err != nil {
+		return err
+	}
+	return service.users.SetLocale(userID, locale)
+}
+
+// Suspend keeps the user from signing in and writing comments.
+func (service *UsersService) Suspend(userID string) bool {
+	return service.users.Suspend(userID) == nil
+}
+
+// SetRole makes the user an administrator or takes the role away, and notifies the user of the change.
+func (service *UsersService) SetRole(userID string, model *RoleModel, adminID string) error {
+	user, err := service.users.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin == model.IsAdmin {
+		return nil
+	}
+	err = service.users.SetAdmin(userID, model.IsAdmin)
+	if err != nil {
+		return err
+	}
+	message := "You are now an administrator."
+	if !model.IsAdmin {
+		message = "You are no longer an administrator."
+	}
+	service.notifications.Notify(notifications.Event{
+		Type:    notifications.TypeRole,
+		UserID:  userID,
+		ActorID: adminID,
+		Message: message,
+	})
+	return nil
 }
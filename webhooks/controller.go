@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+)
+
+type WebhooksController struct {
+	*echo.Echo
+	config          *config.Config
+	repository      *db.Repository
+	jwtMiddleware   *echo.MiddlewareFunc
+	adminMiddleware *echo.MiddlewareFunc
+}
+
+func NewWebhooksController(echo *echo.Echo, config *config.Config, repository *db.Repository, jwtMiddleware *echo.MiddlewareFunc, adminMiddleware *echo.MiddlewareFunc) *WebhooksController {
+	return &WebhooksController{
+		Echo:            echo,
+		config:          config,
+		repository:      repository,
+		jwtMiddleware:   jwtMiddleware,
+		adminMiddleware: adminMiddleware,
+	}
+}
+
+func (controller *WebhooksController) UseRoute() {
+	webhooksService := NewWebhooksService(controller.config, controller.repository)
+	controller.GET("/webhooks", func(c echo.Context) error {
+		webhooks, err := webhooksService.List()
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the webhooks is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":   true,
+			"webhooks": webhooks,
+			"events":   Events,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/webhooks", func(c echo.Context) error {
+		model := new(SaveWebhookModel)
+		err := c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		webhook, err := webhooksService.Create(model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Creating the webhook is failed.",
+			})
+		}
+		return c.JSON(http.StatusCreated, echo.Map{
+			"status":  true,
+			"webhook": webhook,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.PUT("/webhooks/:id", func(c echo.Context) error {
+		webhookID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid webhook id.",
+			})
+		}
+		model := new(SaveWebhookModel)
+		err = c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid data form.",
+			})
+		}
+		err = webhooksService.Update(webhookID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Updating the webhook is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The webhook is updated.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.DELETE("/webhooks/:id", func(c echo.Context) error {
+		webhookID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid webhook id.",
+			})
+		}
+		err = webhooksService.Delete(webhookID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Deleting the webhook is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":  true,
+			"message": "The webhook is deleted.",
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.GET("/webhooks/:id/deliveries", func(c echo.Context) error {
+		webhookID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid webhook id.",
+			})
+		}
+		model := new(ListDeliveriesModel)
+		err = c.Bind(model)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid query.",
+			})
+		}
+		deliveries, err := webhooksService.Deliveries(webhookID, model)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Loading the deliveries is failed.",
+			})
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"status":     true,
+			"deliveries": deliveries,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+
+	controller.POST("/webhooks/deliveries/:id/replay", func(c echo.Context) error {
+		deliveryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &db.BadResponse{
+				Status:  false,
+				Message: "Invalid delivery id.",
+			})
+		}
+		id, err := webhooksService.Replay(deliveryID)
+		if err != nil {
+			return c.JSON(db.StatusCode(err), &db.BadResponse{
+				Status:  false,
+				Message: "Replaying the delivery is failed.",
+			})
+		}
+		return c.JSON(http.StatusCreated, echo.Map{
+			"status":     true,
+			"deliveryId": id,
+		})
+	}, *controller.jwtMiddleware, *controller.adminMiddleware)
+}
@@ -0,0 +1,97 @@
+// Package webhooks tells the services of the admins, like static site builders and chat bots,
+// about the changes of the content. Every event is delivered as a signed JSON request to the
+// webhooks which subscribe to it, through the job queue, so that failed deliveries are retried.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/jobs"
+)
+
+// JobDeliver is the type of the jobs which send the deliveries.
+const JobDeliver = "webhook.deliver"
+
+// Dispatch adds a delivery of the event for every active webhook which subscribes to it. The
+// executor should be the transaction of the change, so that nothing is delivered when it is
+// rolled back.
+func Dispatch(executor db.Executor, event string, data interface{}) error {
+	rows, err := executor.Query(`select id, events from webhooks where is_active = ?`, true)
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	webhookIDs := []int{}
+	for rows.Next() {
+		var id int
+		var events string
+		err = rows.Scan(&id, &events)
+		if err != nil {
+			rows.Close()
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		if subscribes(events, event) {
+			webhookIDs = append(webhookIDs, id)
+		}
+	}
+	rows.Close()
+	if len(webhookIDs) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	payload, err := json.Marshal(&Payload{Event: event, CreatedAt: now, Data: data})
+	if err != nil {
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	for _, webhookID := range webhookIDs {
+		_, err = addDelivery(executor, webhookID, event, string(payload), now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDelivery stores a pending delivery and enqueues its job.
+func addDelivery(executor db.Executor, webhookID int, event string, payload string, createdAt time.Time) (int64, error) {
+	id, err := db.Insert(executor, `
+	insert into webhook_deliveries (webhook_id, event, payload, status, created_at)
+	values (?, ?, ?, ?, ?)`, webhookID, event, payload, DeliveryPending, createdAt)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	_, err = jobs.Enqueue(executor, JobDeliver, &deliveryJob{DeliveryID: id}, fmt.Sprintf("webhook-delivery:%d", id))
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func subscribes(events string, event string) bool {
+	for _, subscribed := range strings.Split(events, ",") {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256, keyed by the secret of the
+// webhook, of the timestamp, a dot and the body.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,104 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventPostPublished  = "post.published"
+	EventPostUpdated    = "post.updated"
+	EventPostDeleted    = "post.deleted"
+	EventCommentCreated = "comment.created"
+)
+
+// Events are the events which the webhooks subscribe to.
+var Events = []string{EventPostPublished, EventPostUpdated, EventPostDeleted, EventCommentCreated}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	// DeliveryFailed is the status of a delivery whose last attempt failed. Its job may still retry it.
+	DeliveryFailed = "failed"
+)
+
+type WebhookModel struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"isActive"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CreatedWebhookModel is the webhook with its secret, which is shown only when it is created.
+type CreatedWebhookModel struct {
+	WebhookModel
+	Secret string `json:"secret"`
+}
+
+// SaveWebhookModel creates or updates a webhook. A new webhook without a secret gets a random one,
+// and an update without a secret keeps the current one. A webhook is active unless IsActive is false.
+type SaveWebhookModel struct {
+	URL      string   `json:"url"`
+	Secret   string   `json:"secret"`
+	Events   []string `json:"events"`
+	IsActive *bool    `json:"isActive"`
+}
+
+type DeliveryModel struct {
+	ID             int64           `json:"id"`
+	WebhookID      int             `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"responseStatus"`
+	ResponseBody   *string         `json:"responseBody"`
+	LastError      *string         `json:"lastError"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+}
+
+type ListDeliveriesModel struct {
+	// Before is the id below which the deliveries are listed, for the next page.
+	Before int64 `query:"before"`
+	Limit  int   `query:"limit"`
+}
+
+// Payload is the JSON body of the deliveries.
+type Payload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// PostData is the data of the post events. The deleted posts are as they were before they were deleted.
+type PostData struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	UserID      string    `json:"userId"`
+	IsHidden    bool      `json:"isHidden"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// CommentData is the data of the comment events. A comment is created for the webhooks when it is
+// shown, so a comment waiting for approval or classified as spam sends it once approved.
+type CommentData struct {
+	ID        int       `json:"id"`
+	PostID    int       `json:"postId"`
+	ParentID  *int      `json:"parentId"`
+	UserID    string    `json:"userId"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// deliveryJob is the payload of the jobs which send the deliveries.
+type deliveryJob struct {
+	DeliveryID int64 `json:"deliveryId"`
+}
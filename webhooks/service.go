@@ -0,0 +1,350 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/jobs"
+)
+
+// minSecretLength is the shortest secret which the admins may choose.
+const minSecretLength = 16
+
+// maxResponseLength is how much of the response body of a delivery is kept.
+const maxResponseLength = 1000
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, response_status, response_body, last_error, created_at, delivered_at`
+
+type WebhooksService struct {
+	config     *config.Config
+	repository *db.Repository
+	client     *http.Client
+}
+
+func NewWebhooksService(config *config.Config, repository *db.Repository) *WebhooksService {
+	return &WebhooksService{
+		config:     config,
+		repository: repository,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (service *WebhooksService) List() ([]WebhookModel, error) {
+	rows, err := service.repository.Query(`
+	select id, url, events, is_active, created_at, updated_at
+	from webhooks
+	order by id asc`)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	webhooks := []WebhookModel{}
+	for rows.Next() {
+		webhook := WebhookModel{}
+		var events string
+		err := rows.Scan(&webhook.ID, &webhook.URL, &events, &webhook.IsActive, &webhook.CreatedAt, &webhook.UpdatedAt)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		webhook.Events = strings.Split(events, ",")
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// Create adds the webhook and returns it with its secret, which is not shown again.
+func (service *WebhooksService) Create(model *SaveWebhookModel) (*CreatedWebhookModel, error) {
+	events, err := validate(model)
+	if err != nil {
+		return nil, err
+	}
+	secret := model.Secret
+	if len(secret) == 0 {
+		random := make([]byte, 32)
+		_, err = rand.Read(random)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		secret = hex.EncodeToString(random)
+	}
+	now := time.Now().UTC()
+	webhook := &CreatedWebhookModel{
+		WebhookModel: WebhookModel{
+			URL:       model.URL,
+			Events:    events,
+			IsActive:  model.IsActive == nil || *model.IsActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Secret: secret,
+	}
+	id, err := db.Insert(service.repository, `
+	insert into webhooks (url, secret, events, is_active, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?)`, webhook.URL, secret, strings.Join(events, ","), webhook.IsActive, now, now)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	webhook.ID = int(id)
+	return webhook, nil
+}
+
+func (service *WebhooksService) Update(webhookID int, model *SaveWebhookModel) error {
+	events, err := validate(model)
+	if err != nil {
+		return err
+	}
+	assignments := "url = ?, events = ?, updated_at = ?"
+	args := []interface{}{model.URL, strings.Join(events, ","), time.Now().UTC()}
+	if len(model.Secret) > 0 {
+		assignments += ", secret = ?"
+		args = append(args, model.Secret)
+	}
+	if model.IsActive != nil {
+		assignments += ", is_active = ?"
+		args = append(args, *model.IsActive)
+	}
+	res, err := service.repository.Exec(fmt.Sprintf(`update webhooks set %s where id = ?`, assignments), append(args, webhookID)...)
+	return affected(res, err, webhookID)
+}
+
+// Delete removes the webhook with its deliveries. The deliveries which wait for a retry are dropped.
+func (service *WebhooksService) Delete(webhookID int) error {
+	return service.repository.Transaction(func(tx *db.Tx) error {
+		_, err := tx.Exec(`delete from webhook_deliveries where webhook_id = ?`, webhookID)
+		if err != nil {
+			log.Println(err.Error())
+			return fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		res, err := tx.Exec(`delete from webhooks where id = ?`, webhookID)
+		return affected(res, err, webhookID)
+	})
+}
+
+// Deliveries returns the deliveries of the webhook, the newest first.
+func (service *WebhooksService) Deliveries(webhookID int, model *ListDeliveriesModel) ([]DeliveryModel, error) {
+	var id int
+	err := service.repository.QueryRow(`select id from webhooks where id = ?`, webhookID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: webhook %d", db.ErrNotFound, webhookID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	where := "webhook_id = ?"
+	args := []interface{}{webhookID}
+	if model.Before > 0 {
+		where += " and id < ?"
+		args = append(args, model.Before)
+	}
+	pageSize, maxPageSize := service.config.GetPageSize()
+	args = append(args, db.PageLimit(model.Limit, pageSize, maxPageSize))
+	rows, err := service.repository.Query(fmt.Sprintf(`
+	select %s
+	from webhook_deliveries
+	where %s
+	order by id desc
+	limit ?`, deliveryColumns, where), args...)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	defer rows.Close()
+	deliveries := []DeliveryModel{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (service *WebhooksService) Delivery(deliveryID int64) (*DeliveryModel, error) {
+	row := service.repository.QueryRow(fmt.Sprintf(`select %s from webhook_deliveries where id = ?`, deliveryColumns), deliveryID)
+	delivery, err := scanDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: delivery %d", db.ErrNotFound, deliveryID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return nil, fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	return delivery, nil
+}
+
+// Replay sends the payload of the delivery again as a new delivery, and returns the id of the new one.
+func (service *WebhooksService) Replay(deliveryID int64) (int64, error) {
+	delivery, err := service.Delivery(deliveryID)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = service.repository.Transaction(func(tx *db.Tx) error {
+		id, err = addDelivery(tx, delivery.WebhookID, delivery.Event, string(delivery.Payload), time.Now().UTC())
+		return err
+	})
+	return id, err
+}
+
+// Deliver is the handler of the jobs of the deliveries. It posts the payload to the webhook and
+// logs the response. A response which is not 2xx fails the job, which retries it.
+func (service *WebhooksService) Deliver(job *jobs.Job) error {
+	payload := new(deliveryJob)
+	err := job.Decode(payload)
+	if err != nil {
+		return err
+	}
+	var event, body, address, secret string
+	row := service.repository.QueryRow(`
+	select d.event, d.payload, w.url, w.secret
+	from webhook_deliveries as d
+	join webhooks as w on w.id = d.webhook_id
+	where d.id = ?`, payload.DeliveryID)
+	err = row.Scan(&event, &body, &address, &secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The webhook was deleted with its deliveries.
+		return nil
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	status, response, sendErr := service.send(payload.DeliveryID, event, address, secret, []byte(body))
+	responseStatus := sql.NullInt64{Int64: int64(status), Valid: status > 0}
+	responseBody := sql.NullString{String: response, Valid: status > 0}
+	if sendErr == nil {
+		_, err = service.repository.Exec(`
+		update webhook_deliveries
+		set status = ?, attempts = attempts + 1, response_status = ?, response_body = ?, last_error = null, delivered_at = ?
+		where id = ?`, DeliverySucceeded, responseStatus, responseBody, time.Now().UTC(), payload.DeliveryID)
+	} else {
+		_, err = service.repository.Exec(`
+		update webhook_deliveries
+		set status = ?, attempts = attempts + 1, response_status = ?, response_body = ?, last_error = ?
+		where id = ?`, DeliveryFailed, responseStatus, responseBody, sendErr.Error(), payload.DeliveryID)
+	}
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return sendErr
+}
+
+// send posts the signed body and returns the status and the start of the body of the response.
+func (service *WebhooksService) send(deliveryID int64, event string, address string, secret string, body []byte) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-factory-webhooks")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(deliveryID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(secret, timestamp, body))
+	res, err := service.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseLength))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, string(response), fmt.Errorf("the webhook responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, string(response), nil
+}
+
+// validate checks the URL and the secret of the webhook, and returns its events without duplicates.
+func validate(model *SaveWebhookModel) ([]string, error) {
+	address, err := url.Parse(model.URL)
+	if err != nil || (address.Scheme != "http" && address.Scheme != "https") || len(address.Host) == 0 {
+		return nil, fmt.Errorf("%w: the url is not an http or https url", db.ErrInvalid)
+	}
+	if len(model.Secret) > 0 && len(model.Secret) < minSecretLength {
+		return nil, fmt.Errorf("%w: the secret is shorter than %d characters", db.ErrInvalid, minSecretLength)
+	}
+	if len(model.Events) == 0 {
+		return nil, fmt.Errorf("%w: no events", db.ErrInvalid)
+	}
+	chosen := map[string]bool{}
+	for _, event := range model.Events {
+		if !subscribes(strings.Join(Events, ","), event) {
+			return nil, fmt.Errorf("%w: unknown event %q", db.ErrInvalid, event)
+		}
+		chosen[event] = true
+	}
+	events := []string{}
+	for _, event := range Events {
+		if chosen[event] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// affected turns an update of no rows into a not found error.
+func affected(res sql.Result, err error, webhookID int) error {
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err.Error())
+		return fmt.Errorf("%w: %v", db.ErrInternal, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: webhook %d", db.ErrNotFound, webhookID)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDelivery(row rowScanner) (*DeliveryModel, error) {
+	delivery := new(DeliveryModel)
+	var payload string
+	var responseStatus sql.NullInt64
+	var responseBody, lastError sql.NullString
+	var deliveredAt sql.NullTime
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts, &responseStatus, &responseBody, &lastError, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = []byte(payload)
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+	if responseBody.Valid {
+		delivery.ResponseBody = &responseBody.String
+	}
+	if lastError.Valid {
+		delivery.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
@@ -0,0 +1,149 @@
+package webhooks
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/quavious/blog-factory-server/config"
+	"github.com/quavious/blog-factory-server/db"
+	"github.com/quavious/blog-factory-server/jobs"
+	"github.com/quavious/blog-factory-server/migrations"
+)
+
+func newTestService(t *testing.T) (*WebhooksService, *jobs.Queue) {
+	t.Helper()
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "webhooks.db"))
+	t.Setenv("JOB_MAX_ATTEMPTS", "2")
+	t.Setenv("JOB_BACKOFF_SECONDS", "0")
+	config := config.FromEnv()
+	repository := db.NewRepository(config)
+	if repository == nil {
+		t.Fatal("the database is not opened")
+	}
+	t.Cleanup(func() { repository.Close() })
+	migrator, err := migrations.NewMigrator(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewWebhooksService(config, repository)
+	queue := jobs.NewQueue(config, repository)
+	queue.Handle(JobDeliver, service.Deliver)
+	return service, queue
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		model *SaveWebhookModel
+		valid bool
+	}{
+		{&SaveWebhookModel{URL: "https://example.com/hook", Events: []string{EventPostDeleted, EventPostPublished, EventPostDeleted}}, true},
+		{&SaveWebhookModel{URL: "ftp://example.com/hook", Events: []string{EventPostPublished}}, false},
+		{&SaveWebhookModel{URL: "/hook", Events: []string{EventPostPublished}}, false},
+		{&SaveWebhookModel{URL: "https://example.com/hook"}, false},
+		{&SaveWebhookModel{URL: "https://example.com/hook", Events: []string{"post.liked"}}, false},
+		{&SaveWebhookModel{URL: "https://example.com/hook", Events: []string{EventPostPublished}, Secret: "short"}, false},
+	}
+	for _, c := range cases {
+		events, err := validate(c.model)
+		if c.valid != (err == nil) {
+			t.Errorf("validate(%+v) = %v", c.model, err)
+		}
+		if err != nil && !errors.Is(err, db.ErrInvalid) {
+			t.Errorf("got the error %v", err)
+		}
+		if c.valid && (len(events) != 2 || events[0] != EventPostPublished || events[1] != EventPostDeleted) {
+			t.Errorf("got the events %v", events)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// The HMAC-SHA256 of `1650000000.{"event":"post.published"}` with the key "secret".
+	want := "457f05ca4f12901f22fadcae7c96c0cb1ccfa56afa88e333b8303755bbc437c4"
+	if got := Sign("secret", "1650000000", []byte(`{"event":"post.published"}`)); got != want {
+		t.Errorf("got the signature %s, want %s", got, want)
+	}
+}
+
+func TestDeliverLogsFailures(t *testing.T) {
+	service, queue := newTestService(t)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Webhook-Signature") != "sha256="+Sign("0123456789abcdef", r.Header.Get("X-Webhook-Timestamp"), body) {
+			t.Errorf("the signature %q does not match", r.Header.Get("X-Webhook-Signature"))
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, "try again")
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+	webhook, err := service.Create(&SaveWebhookModel{URL: server.URL, Secret: "0123456789abcdef", Events: []string{EventCommentCreated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Dispatch(service.repository, EventPostPublished, &PostData{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Dispatch(service.repository, EventCommentCreated, &CommentData{ID: 1, PostID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran := queue.RunDue(); ran != 2 || calls != 2 {
+		t.Fatalf("ran %d jobs which called the webhook %d times", ran, calls)
+	}
+	deliveries, err := service.Deliveries(webhook.ID, &ListDeliveriesModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("got the deliveries %+v", deliveries)
+	}
+	delivery := deliveries[0]
+	if delivery.Status != DeliverySucceeded || delivery.Attempts != 2 || *delivery.ResponseStatus != http.StatusOK || delivery.DeliveredAt == nil {
+		t.Errorf("got the delivery %+v", delivery)
+	}
+
+	err = service.Update(webhook.ID, &SaveWebhookModel{URL: server.URL + "/missing", Events: []string{EventCommentCreated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	replayed, err := service.Replay(delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.RunDue()
+	failed, err := service.Delivery(replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != DeliveryFailed || failed.Attempts != 2 || failed.LastError == nil || string(failed.Payload) != string(delivery.Payload) {
+		t.Errorf("got the replayed delivery %+v", failed)
+	}
+
+	err = service.Delete(webhook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.Deliveries(webhook.ID, &ListDeliveriesModel{}); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("the deliveries of a deleted webhook are listed: %v", err)
+	}
+	if err = service.Update(webhook.ID, &SaveWebhookModel{URL: server.URL, Events: []string{EventPostDeleted}}); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("a deleted webhook is updated: %v", err)
+	}
+}